@@ -0,0 +1,311 @@
+// Command coc-exporter periodically retrieves clans, clan members, players and current wars from
+// the Clash of Clans API and exposes them as Prometheus metrics.
+//
+// Usage:  coc-exporter -t <APITOKEN> -c <CLANTAG> [-c <CLANTAG>...] [-p <PLAYERTAG>...] [-l :9101] [-i 5m] [--timeout 30s]
+package main
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/rbrabson/coc/v1"
+	"github.com/urfave/cli/v2"
+)
+
+const (
+	appName = "coc-exporter"
+	usage   = "Prometheus exporter for Clash of Clans clan and player metrics"
+)
+
+var (
+	// flags are the set of flags supported by the exporter
+	flags = []cli.Flag{
+		&cli.StringFlag{
+			Name:        "token",
+			Aliases:     []string{"t"},
+			EnvVars:     []string{"COC_TOKEN"},
+			Usage:       "API token to use for authentication with the Clash of Clans REST server (required)",
+			DefaultText: " ",
+			Required:    true,
+		},
+		&cli.StringSliceFlag{
+			Name:    "clantag",
+			Aliases: []string{"c"},
+			EnvVars: []string{"COC_CLAN_TAGS"},
+			Usage:   "The tag of a clan to export metrics for; may be repeated",
+		},
+		&cli.StringSliceFlag{
+			Name:    "playertag",
+			Aliases: []string{"p"},
+			EnvVars: []string{"COC_PLAYER_TAGS"},
+			Usage:   "The tag of a player to export metrics for; may be repeated",
+		},
+		&cli.StringFlag{
+			Name:    "listen",
+			Aliases: []string{"l"},
+			Usage:   "The address on which to serve the metrics",
+			Value:   ":9101",
+		},
+		&cli.DurationFlag{
+			Name:    "interval",
+			Aliases: []string{"i"},
+			Usage:   "The interval between polls of the Clash of Clans API",
+			Value:   5 * time.Minute,
+		},
+		&cli.DurationFlag{
+			Name:    "timeout",
+			EnvVars: []string{"COC_TIMEOUT"},
+			Usage:   "Time limit for each request to the Clash of Clans API",
+			Value:   30 * time.Second,
+		},
+	}
+)
+
+func main() {
+	app := &cli.App{
+		Name:   appName,
+		Flags:  flags,
+		Usage:  usage,
+		Action: run,
+	}
+
+	err := app.Run(os.Args)
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+// run polls the API and serves the metrics until interrupted
+func run(c *cli.Context) error {
+	clanTags := c.StringSlice("clantag")
+	playerTags := c.StringSlice("playertag")
+	if len(clanTags) == 0 && len(playerTags) == 0 {
+		return errors.New("at least one clan or player tag is required")
+	}
+
+	e := newExporter(coc.NewClient(c.String("token"), coc.WithTimeout(c.Duration("timeout"))))
+	ctx, stop := signal.NotifyContext(c.Context, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", e)
+	server := &http.Server{Addr: c.String("listen"), Handler: mux}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		server.Shutdown(shutdownCtx)
+	}()
+	go e.run(ctx, c.Duration("interval"), clanTags, playerTags)
+
+	log.Printf("serving metrics on %s/metrics", c.String("listen"))
+	if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		return err
+	}
+	return nil
+}
+
+// exporter polls the API and holds the most recent metrics for each clan, player and war.
+type exporter struct {
+	mu      sync.Mutex
+	client  coc.Client
+	targets map[string]*metricSet
+	api     *metricSet
+}
+
+// newExporter returns an exporter that uses the client to poll the API
+func newExporter(client coc.Client) *exporter {
+	return &exporter{
+		client:  client,
+		targets: make(map[string]*metricSet),
+		api:     newMetricSet(),
+	}
+}
+
+// ServeHTTP writes the current metrics in the Prometheus text exposition format
+func (e *exporter) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	e.mu.Lock()
+	all := newMetricSet()
+	for _, ms := range e.targets {
+		all.merge(ms)
+	}
+	all.merge(e.api)
+	e.mu.Unlock()
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	all.write(w)
+}
+
+// run polls each clan and player at the given interval until the context is cancelled
+func (e *exporter) run(ctx context.Context, interval time.Duration, clanTags []string, playerTags []string) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		for _, tag := range clanTags {
+			e.pollClan(tag)
+			e.pollMembers(tag)
+			e.pollWar(tag)
+		}
+		for _, tag := range playerTags {
+			e.pollPlayer(tag)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// pollClan updates the metrics for the clan
+func (e *exporter) pollClan(tag string) {
+	var clan *coc.Clan
+	err := e.call("clan", func() (err error) {
+		clan, err = e.client.GetClan(tag)
+		return err
+	})
+	if err != nil {
+		log.Printf("failed to get clan %s: %v", tag, err)
+		return
+	}
+
+	ms := newMetricSet()
+	labels := []string{"clan", clan.Tag, "clan_name", clan.Name}
+	ms.set("coc_clan_level", float64(clan.ClanLevel), labels...)
+	ms.set("coc_clan_points", float64(clan.ClanPoints), labels...)
+	ms.set("coc_clan_versus_points", float64(clan.ClanVersusPoints), labels...)
+	ms.set("coc_clan_capital_points", float64(clan.ClanCapitalPoints), labels...)
+	ms.set("coc_clan_members", float64(clan.Members), labels...)
+	ms.set("coc_clan_war_wins", float64(clan.WarWins), labels...)
+	ms.set("coc_clan_war_losses", float64(clan.WarLosses), labels...)
+	ms.set("coc_clan_war_ties", float64(clan.WarTies), labels...)
+	ms.set("coc_clan_war_win_streak", float64(clan.WarWinStreak), labels...)
+	e.update("clan:"+tag, ms)
+}
+
+// pollMembers updates the metrics for the clan's members
+func (e *exporter) pollMembers(tag string) {
+	var members []coc.ClanMember
+	err := e.call("members", func() (err error) {
+		members, _, err = e.client.GetClanMembers(tag)
+		return err
+	})
+	if err != nil {
+		log.Printf("failed to get members of clan %s: %v", tag, err)
+		return
+	}
+
+	ms := newMetricSet()
+	clanTag := coc.NormalizeTag(tag)
+	for _, m := range members {
+		labels := []string{"clan", clanTag, "player", m.Tag, "player_name", m.Name, "role", m.Role}
+		ms.set("coc_member_trophies", float64(m.Trophies), labels...)
+		ms.set("coc_member_versus_trophies", float64(m.VersusTrophies), labels...)
+		ms.set("coc_member_donations", float64(m.Donations), labels...)
+		ms.set("coc_member_donations_received", float64(m.DonationsReceived), labels...)
+	}
+	e.update("members:"+tag, ms)
+}
+
+// pollWar updates the metrics for the clan's current war
+func (e *exporter) pollWar(tag string) {
+	var war *coc.ClanWar
+	err := e.call("currentwar", func() (err error) {
+		war, err = e.client.GetClanWarCurrent(tag)
+		if errors.Is(err, coc.ErrNotInWar) {
+			war, err = nil, nil
+		}
+		return err
+	})
+	if err != nil {
+		log.Printf("failed to get current war of clan %s: %v", tag, err)
+		return
+	}
+
+	ms := newMetricSet()
+	clanTag := coc.NormalizeTag(tag)
+	state := string(coc.WarPhaseNotInWar)
+	if war != nil {
+		state = war.State
+	}
+	for _, s := range []coc.WarPhase{coc.WarPhaseNotInWar, coc.WarPhasePreparation, coc.WarPhaseInWar, coc.WarPhaseEnded} {
+		value := 0.0
+		if string(s) == state {
+			value = 1
+		}
+		ms.set("coc_war_state", value, "clan", clanTag, "state", string(s))
+	}
+	if war != nil {
+		if oriented, ok := war.ForClan(clanTag); ok {
+			war = &oriented
+		}
+		for side, team := range map[string]coc.ClanWarTeam{"clan": war.Clan, "opponent": war.Opponent} {
+			labels := []string{"clan", clanTag, "side", side, "team", team.Tag, "team_name", team.Name}
+			ms.set("coc_war_team_size", float64(war.TeamSize), labels...)
+			ms.set("coc_war_stars", float64(team.Stars), labels...)
+			ms.set("coc_war_destruction_percentage", float64(team.DestructionPercentage), labels...)
+			ms.set("coc_war_attacks", float64(team.Attacks), labels...)
+		}
+	}
+	e.update("war:"+tag, ms)
+}
+
+// pollPlayer updates the metrics for the player
+func (e *exporter) pollPlayer(tag string) {
+	var player *coc.Player
+	err := e.call("player", func() (err error) {
+		player, err = e.client.GetPlayer(tag)
+		return err
+	})
+	if err != nil {
+		log.Printf("failed to get player %s: %v", tag, err)
+		return
+	}
+
+	ms := newMetricSet()
+	labels := []string{"player", player.Tag, "player_name", player.Name, "clan", player.Clan.Tag}
+	ms.set("coc_player_trophies", float64(player.Trophies), labels...)
+	ms.set("coc_player_best_trophies", float64(player.BestTrophies), labels...)
+	ms.set("coc_player_versus_trophies", float64(player.VersusTrophies), labels...)
+	ms.set("coc_player_war_stars", float64(player.WarStars), labels...)
+	ms.set("coc_player_donations", float64(player.Donations), labels...)
+	ms.set("coc_player_donations_received", float64(player.DonationsReceived), labels...)
+	ms.set("coc_player_attack_wins", float64(player.AttackWins), labels...)
+	ms.set("coc_player_defense_wins", float64(player.DefenseWins), labels...)
+	ms.set("coc_player_exp_level", float64(player.ExpLevel), labels...)
+	ms.set("coc_player_town_hall_level", float64(player.TownHallLevel), labels...)
+	e.update("player:"+tag, ms)
+}
+
+// call invokes the API request, recording its duration and whether it failed
+func (e *exporter) call(endpoint string, request func() error) error {
+	start := time.Now()
+	err := request()
+	duration := time.Since(start)
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.api.observe("coc_api_request_duration_seconds", duration.Seconds(), "endpoint", endpoint)
+	e.api.add("coc_api_requests_total", 1, "endpoint", endpoint)
+	e.api.add("coc_api_errors_total", 0, "endpoint", endpoint)
+	if err != nil {
+		e.api.add("coc_api_errors_total", 1, "endpoint", endpoint)
+	}
+
+	return err
+}
+
+// update replaces the metrics for the target with the most recently polled values
+func (e *exporter) update(target string, ms *metricSet) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.targets[target] = ms
+}
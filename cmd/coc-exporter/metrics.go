@@ -0,0 +1,175 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// metricType is the Prometheus type of a metric
+type metricType string
+
+const (
+	gauge   metricType = "gauge"
+	counter metricType = "counter"
+	summary metricType = "summary"
+)
+
+// family is a metric and its samples, one per unique set of labels. The samples of a summary
+// are keyed by their suffix, "_sum" or "_count", followed by their labels.
+type family struct {
+	name    string
+	help    string
+	typ     metricType
+	samples map[string]float64
+}
+
+// metricSet is a set of metric families, written in the Prometheus text exposition format.
+type metricSet struct {
+	families map[string]*family
+}
+
+// newMetricSet returns an empty set of metrics
+func newMetricSet() *metricSet {
+	return &metricSet{families: make(map[string]*family)}
+}
+
+// set sets the value of the sample of the metric with the given labels. The labels are given as
+// name/value pairs.
+func (ms *metricSet) set(name string, value float64, labels ...string) {
+	f := ms.family(name)
+	f.samples[formatLabels(labels)] = value
+}
+
+// add adds to the value of the sample of the metric with the given labels. The labels are given
+// as name/value pairs.
+func (ms *metricSet) add(name string, value float64, labels ...string) {
+	f := ms.family(name)
+	f.samples[formatLabels(labels)] += value
+}
+
+// observe records an observation of the metric, such as the duration of a request, with the
+// given labels. The sum and count of the observations are kept, from which a rate or average
+// may be derived. The labels are given as name/value pairs.
+func (ms *metricSet) observe(name string, value float64, labels ...string) {
+	f := ms.family(name)
+	l := formatLabels(labels)
+	f.samples["_sum"+l] += value
+	f.samples["_count"+l]++
+}
+
+// family returns the family for the metric, creating it if required
+func (ms *metricSet) family(name string) *family {
+	f, ok := ms.families[name]
+	if !ok {
+		d := descriptions[name]
+		f = &family{name: name, help: d.help, typ: d.typ, samples: make(map[string]float64)}
+		ms.families[name] = f
+	}
+	return f
+}
+
+// merge adds the samples in the other set to this one
+func (ms *metricSet) merge(other *metricSet) {
+	for name, f := range other.families {
+		mf := ms.family(name)
+		for labels, value := range f.samples {
+			mf.samples[labels] = value
+		}
+	}
+}
+
+// write writes the metrics to the writer in the Prometheus text exposition format
+func (ms *metricSet) write(w io.Writer) error {
+	names := make([]string, 0, len(ms.families))
+	for name := range ms.families {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		f := ms.families[name]
+		if _, err := fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", f.name, f.help, f.name, f.typ); err != nil {
+			return err
+		}
+		labels := make([]string, 0, len(f.samples))
+		for l := range f.samples {
+			labels = append(labels, l)
+		}
+		sort.Strings(labels)
+		for _, l := range labels {
+			value := strconv.FormatFloat(f.samples[l], 'g', -1, 64)
+			if _, err := fmt.Fprintf(w, "%s%s %s\n", f.name, l, value); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// formatLabels formats the name/value pairs as a Prometheus label set
+func formatLabels(labels []string) string {
+	if len(labels) == 0 {
+		return ""
+	}
+	var sb strings.Builder
+	sb.WriteString("{")
+	for i := 0; i+1 < len(labels); i += 2 {
+		if i > 0 {
+			sb.WriteString(",")
+		}
+		sb.WriteString(labels[i])
+		sb.WriteString(`="`)
+		sb.WriteString(labelEscaper.Replace(labels[i+1]))
+		sb.WriteString(`"`)
+	}
+	sb.WriteString("}")
+	return sb.String()
+}
+
+// labelEscaper escapes label values as required by the text exposition format
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// description is the help text and type of a metric
+type description struct {
+	help string
+	typ  metricType
+}
+
+// descriptions of each metric exported
+var descriptions = map[string]description{
+	"coc_clan_level":                   {"Level of the clan.", gauge},
+	"coc_clan_points":                  {"Clan trophy points.", gauge},
+	"coc_clan_versus_points":           {"Clan builder base trophy points.", gauge},
+	"coc_clan_capital_points":          {"Clan capital trophy points.", gauge},
+	"coc_clan_members":                 {"Number of members in the clan.", gauge},
+	"coc_clan_war_wins":                {"Number of wars won by the clan.", gauge},
+	"coc_clan_war_losses":              {"Number of wars lost by the clan.", gauge},
+	"coc_clan_war_ties":                {"Number of wars tied by the clan.", gauge},
+	"coc_clan_war_win_streak":          {"Number of consecutive wars won by the clan.", gauge},
+	"coc_member_trophies":              {"Trophies of a clan member.", gauge},
+	"coc_member_versus_trophies":       {"Builder base trophies of a clan member.", gauge},
+	"coc_member_donations":             {"Troops donated by a clan member this season.", gauge},
+	"coc_member_donations_received":    {"Troops received by a clan member this season.", gauge},
+	"coc_player_trophies":              {"Trophies of a player.", gauge},
+	"coc_player_best_trophies":         {"Best trophies of a player.", gauge},
+	"coc_player_versus_trophies":       {"Builder base trophies of a player.", gauge},
+	"coc_player_war_stars":             {"War stars earned by a player.", gauge},
+	"coc_player_donations":             {"Troops donated by a player this season.", gauge},
+	"coc_player_donations_received":    {"Troops received by a player this season.", gauge},
+	"coc_player_attack_wins":           {"Attacks won by a player this season.", gauge},
+	"coc_player_defense_wins":          {"Defenses won by a player this season.", gauge},
+	"coc_player_exp_level":             {"Experience level of a player.", gauge},
+	"coc_player_town_hall_level":       {"Town hall level of a player.", gauge},
+	"coc_war_state":                    {"Whether the clan's current war is in the given state.", gauge},
+	"coc_war_team_size":                {"Number of members on each side of the clan's current war.", gauge},
+	"coc_war_stars":                    {"Stars earned by each side of the clan's current war.", gauge},
+	"coc_war_destruction_percentage":   {"Destruction percentage of each side of the clan's current war.", gauge},
+	"coc_war_attacks":                  {"Attacks made by each side of the clan's current war.", gauge},
+	"coc_api_request_duration_seconds": {"Duration of requests to the Clash of Clans API.", summary},
+	"coc_api_requests_total":           {"Number of requests sent to the Clash of Clans API.", counter},
+	"coc_api_errors_total":             {"Number of requests to the Clash of Clans API that failed.", counter},
+}
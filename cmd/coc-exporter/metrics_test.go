@@ -0,0 +1,81 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/rbrabson/coc/v1"
+)
+
+// update rewrites the golden files with the output of the tests
+var update = flag.Bool("update", false, "update the golden files")
+
+// checkGolden compares the output with the golden file in testdata
+func checkGolden(t *testing.T, name string, got []byte) {
+	t.Helper()
+	path := filepath.Join("testdata", name)
+	if *update {
+		if err := ioutil.WriteFile(path, got, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("output doesn't match %s\ngot:\n%s\nwant:\n%s", path, got, want)
+	}
+}
+
+func TestMetricSetWrite(t *testing.T) {
+	ms := newMetricSet()
+	ms.set("coc_clan_level", 12, "clan", "#2PP", "clan_name", "The \"Best\" Clan")
+	ms.set("coc_clan_level", 7, "clan", "#8QU", "clan_name", `Back\slash`)
+	ms.set("coc_war_destruction_percentage", 87.25, "clan", "#2PP", "side", "clan")
+
+	other := newMetricSet()
+	other.set("coc_clan_points", 41250, "clan", "#2PP", "clan_name", "Line\nBreak")
+	other.add("coc_api_requests_total", 1, "endpoint", "clan")
+	other.add("coc_api_requests_total", 1, "endpoint", "clan")
+	other.add("coc_api_requests_total", 1, "endpoint", "player")
+	other.observe("coc_api_request_duration_seconds", 0.25, "endpoint", "clan")
+	other.observe("coc_api_request_duration_seconds", 0.5, "endpoint", "clan")
+	other.observe("coc_api_request_duration_seconds", 1.5, "endpoint", "player")
+	ms.merge(other)
+
+	var buf bytes.Buffer
+	if err := ms.write(&buf); err != nil {
+		t.Fatal(err)
+	}
+	checkGolden(t, "metrics.golden", buf.Bytes())
+}
+
+func TestExporterCall(t *testing.T) {
+	e := newExporter(coc.NewClient("token"))
+	failed := errors.New("failed")
+	for _, err := range []error{nil, failed, nil} {
+		if got := e.call("clan", func() error { return err }); got != err {
+			t.Errorf("call() = %v, want %v", got, err)
+		}
+	}
+
+	tests := []struct {
+		name   string
+		sample string
+		want   float64
+	}{
+		{name: "coc_api_requests_total", sample: `{endpoint="clan"}`, want: 3},
+		{name: "coc_api_errors_total", sample: `{endpoint="clan"}`, want: 1},
+		{name: "coc_api_request_duration_seconds", sample: `_count{endpoint="clan"}`, want: 3},
+	}
+	for _, tt := range tests {
+		if got := e.api.families[tt.name].samples[tt.sample]; got != tt.want {
+			t.Errorf("%s%s = %v, want %v", tt.name, tt.sample, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,281 @@
+package main
+
+import (
+	"github.com/urfave/cli/v2"
+)
+
+var (
+	// clanCommands are the commands that retrieve information about clans
+	clanCommands = []*cli.Command{
+		{
+			Name:        "clan",
+			Usage:       "Retrieves information about a clan",
+			Description: "Retrieves information about a clan",
+			Action:      getClan,
+			Flags:       []cli.Flag{clanTagFlag},
+		},
+		{
+			Name:        "search",
+			Usage:       "Searches for clans",
+			Description: "Searches for clans by name and other criteria. At least one criteria must be provided.",
+			Action:      searchClans,
+			Flags: withPaging(
+				&cli.StringFlag{
+					Name:    "name",
+					Aliases: []string{"n"},
+					Usage:   "Search for clans whose name contains this text",
+				},
+				&cli.StringFlag{
+					Name:  "warfrequency",
+					Usage: "Filter by war frequency",
+				},
+				&cli.StringFlag{
+					Name:  "locationid",
+					Usage: "Filter by location identifier",
+				},
+				&cli.IntFlag{
+					Name:  "minmembers",
+					Usage: "Filter by the minimum number of members",
+				},
+				&cli.IntFlag{
+					Name:  "maxmembers",
+					Usage: "Filter by the maximum number of members",
+				},
+				&cli.IntFlag{
+					Name:  "minclanpoints",
+					Usage: "Filter by the minimum clan points",
+				},
+				&cli.IntFlag{
+					Name:  "minclanlevel",
+					Usage: "Filter by the minimum clan level",
+				},
+				&cli.StringFlag{
+					Name:  "labelids",
+					Usage: "Filter by a comma separated list of label identifiers",
+				},
+			),
+		},
+		{
+			Name:        "members",
+			Usage:       "Lists the members of a clan",
+			Description: "Lists the members of a clan",
+			Action:      getClanMembers,
+			Flags:       withPaging(clanTagFlag),
+		},
+		{
+			Name:        "warlog",
+			Usage:       "Retrieves a clan's war log",
+			Description: "Retrieves a clan's war log. The clan's war log must be public.",
+			Action:      getClanWarLog,
+			Flags:       withPaging(clanTagFlag),
+		},
+		{
+			Name:        "currentwar",
+			Usage:       "Retrieves a clan's current war",
+			Description: "Retrieves a clan's current war. The clan's war log must be public.",
+			Action:      getClanWarCurrent,
+			Flags:       []cli.Flag{clanTagFlag},
+		},
+		{
+			Name:        "cwl",
+			Usage:       "Retrieves information about clan war leagues",
+			Description: "Retrieves information about clan war leagues",
+			Subcommands: []*cli.Command{
+				{
+					Name:        "group",
+					Usage:       "Retrieves a clan's current clan war league group",
+					Description: "Retrieves a clan's current clan war league group",
+					Action:      getCWLGroup,
+					Flags:       []cli.Flag{clanTagFlag},
+				},
+				{
+					Name:        "war",
+					Usage:       "Retrieves a clan war league war",
+					Description: "Retrieves a clan war league war by its war tag, which can be found in the clan war league group",
+					Action:      getCWLWar,
+					Flags: []cli.Flag{
+						&cli.StringFlag{
+							Name:    "wartag",
+							Aliases: []string{"w"},
+							Usage:   "The tag of the war (required)",
+						},
+					},
+				},
+				{
+					Name:        "season",
+					Usage:       "Retrieves every war in a clan's current clan war league season",
+					Description: "Retrieves every war in a clan's current clan war league season, along with the group's standings",
+					Action:      getCWLSeason,
+					Flags:       []cli.Flag{clanTagFlag},
+				},
+			},
+		},
+		{
+			Name:        "capital",
+			Usage:       "Retrieves a clan's capital raid seasons",
+			Description: "Retrieves a clan's capital raid seasons",
+			Action:      getCapitalRaidSeasons,
+			Flags:       withPaging(clanTagFlag),
+		},
+	}
+)
+
+// getClan gets information about a clan
+func getClan(c *cli.Context) error {
+	if err := requireFlags(c, "clantag"); err != nil {
+		return err
+	}
+	client, err := newClient(c)
+	if err != nil {
+		return err
+	}
+
+	clan, err := client.GetClan(setting(c, "clantag"))
+	if err != nil {
+		return err
+	}
+	return printResult(c, clan)
+}
+
+// searchClans searches for clans
+func searchClans(c *cli.Context) error {
+	client, err := newClient(c)
+	if err != nil {
+		return err
+	}
+
+	qparms := pagingQParms(c)
+	qparms.Name = c.String("name")
+	qparms.WarFrequency = c.String("warfrequency")
+	qparms.LocationID = c.String("locationid")
+	qparms.MinMembers = c.Int("minmembers")
+	qparms.MaxMembers = c.Int("maxmembers")
+	qparms.MinClanPoints = c.Int("minclanpoints")
+	qparms.MinClanLevel = c.Int("minclanlevel")
+	qparms.LabelIDs = c.String("labelids")
+
+	clans, _, err := client.SearchClans(qparms)
+	if err != nil {
+		return err
+	}
+	return printResult(c, clans)
+}
+
+// getClanMembers lists the members of a clan
+func getClanMembers(c *cli.Context) error {
+	if err := requireFlags(c, "clantag"); err != nil {
+		return err
+	}
+	client, err := newClient(c)
+	if err != nil {
+		return err
+	}
+
+	members, _, err := client.GetClanMembers(setting(c, "clantag"), pagingQParms(c))
+	if err != nil {
+		return err
+	}
+	return printResult(c, members)
+}
+
+// getClanWarLog gets a clan's war log
+func getClanWarLog(c *cli.Context) error {
+	if err := requireFlags(c, "clantag"); err != nil {
+		return err
+	}
+	client, err := newClient(c)
+	if err != nil {
+		return err
+	}
+
+	wars, _, err := client.GetClanWarLog(setting(c, "clantag"), pagingQParms(c))
+	if err != nil {
+		return err
+	}
+	return printResult(c, wars)
+}
+
+// getClanWarCurrent gets a clan's current war
+func getClanWarCurrent(c *cli.Context) error {
+	if err := requireFlags(c, "clantag"); err != nil {
+		return err
+	}
+	client, err := newClient(c)
+	if err != nil {
+		return err
+	}
+
+	war, err := client.GetClanWarCurrent(setting(c, "clantag"))
+	if err != nil {
+		return err
+	}
+	return printResult(c, war)
+}
+
+// getCWLGroup gets a clan's clan war league group
+func getCWLGroup(c *cli.Context) error {
+	if err := requireFlags(c, "clantag"); err != nil {
+		return err
+	}
+	client, err := newClient(c)
+	if err != nil {
+		return err
+	}
+
+	group, err := client.GetClanWarLeagueGroup(setting(c, "clantag"))
+	if err != nil {
+		return err
+	}
+	return printResult(c, group)
+}
+
+// getCWLWar gets a clan war league war
+func getCWLWar(c *cli.Context) error {
+	if err := requireFlags(c, "wartag"); err != nil {
+		return err
+	}
+	client, err := newClient(c)
+	if err != nil {
+		return err
+	}
+
+	war, err := client.GetClanWarLeagueWar(c.String("wartag"))
+	if err != nil {
+		return err
+	}
+	return printResult(c, war)
+}
+
+// getCWLSeason gets every war in a clan's clan war league season
+func getCWLSeason(c *cli.Context) error {
+	if err := requireFlags(c, "clantag"); err != nil {
+		return err
+	}
+	client, err := newClient(c)
+	if err != nil {
+		return err
+	}
+
+	season, err := client.GetCWLSeason(setting(c, "clantag"))
+	if err != nil {
+		return err
+	}
+	return printResult(c, season)
+}
+
+// getCapitalRaidSeasons gets a clan's capital raid seasons
+func getCapitalRaidSeasons(c *cli.Context) error {
+	if err := requireFlags(c, "clantag"); err != nil {
+		return err
+	}
+	client, err := newClient(c)
+	if err != nil {
+		return err
+	}
+
+	seasons, _, err := client.ListCapitalRaidSeasons(setting(c, "clantag"), pagingQParms(c))
+	if err != nil {
+		return err
+	}
+	return printResult(c, seasons)
+}
@@ -0,0 +1,438 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/urfave/cli/v2"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	defaultProfile = "default"
+)
+
+// config is the contents of the CLI's configuration file.
+type config struct {
+	CurrentProfile string              `yaml:"currentProfile,omitempty"`
+	Profiles       map[string]*profile `yaml:"profiles,omitempty"`
+}
+
+// profile is a named set of settings. Settings in a profile are used when the corresponding
+// flag or environment variable isn't set.
+type profile struct {
+	Token    string        `yaml:"token,omitempty"`
+	Tokens   []string      `yaml:"tokens,omitempty"`
+	BaseURL  string        `yaml:"baseURL,omitempty"`
+	Timeout  time.Duration `yaml:"timeout,omitempty"`
+	ClanTag  string        `yaml:"clanTag,omitempty"`
+	Output   string        `yaml:"output,omitempty"`
+	Template string        `yaml:"template,omitempty"`
+	Cache    cacheSettings `yaml:"cache,omitempty"`
+}
+
+// cacheSettings control the caching of API responses on disk.
+type cacheSettings struct {
+	Enabled bool          `yaml:"enabled,omitempty"`
+	Dir     string        `yaml:"dir,omitempty"`
+	TTL     time.Duration `yaml:"ttl,omitempty"`
+}
+
+var (
+	// configFlags are the global flags that select the configuration file and profile
+	configFlags = []cli.Flag{
+		&cli.StringFlag{
+			Name:    "config",
+			EnvVars: []string{"COC_CONFIG"},
+			Usage:   "Path of the configuration file (default: $XDG_CONFIG_HOME/coc/config.yaml)",
+		},
+		&cli.StringFlag{
+			Name:    "profile",
+			Aliases: []string{"P"},
+			EnvVars: []string{"COC_PROFILE"},
+			Usage:   "Name of the profile in the configuration file to use (default: the current profile)",
+		},
+		&cli.BoolFlag{
+			Name:  "no-cache",
+			Usage: "Don't use cached API responses, even if caching is enabled in the profile",
+		},
+	}
+
+	// cfg is the loaded configuration, cfgPath is where it is stored, and active is the name
+	// of the selected profile
+	cfg     *config
+	cfgPath string
+	active  string
+)
+
+// loadConfig loads the configuration file and selects the profile. It is run before any
+// command.
+func loadConfig(c *cli.Context) error {
+	cfgPath = c.String("config")
+	if cfgPath == "" {
+		cfgPath = filepath.Join(xdgDir("XDG_CONFIG_HOME", ".config"), "coc", "config.yaml")
+	}
+
+	cfg = &config{}
+	b, err := os.ReadFile(cfgPath)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+	case err != nil:
+		return err
+	default:
+		if err := yaml.Unmarshal(b, cfg); err != nil {
+			return cli.Exit(fmt.Sprintf("invalid configuration file %s: %v", cfgPath, err), exitUsage)
+		}
+	}
+	if cfg.Profiles == nil {
+		cfg.Profiles = make(map[string]*profile)
+	}
+
+	active = c.String("profile")
+	if active == "" {
+		active = cfg.CurrentProfile
+	}
+	if active == "" {
+		active = defaultProfile
+	}
+	// A profile that doesn't exist may only be named when it is being configured
+	if c.IsSet("profile") && cfg.Profiles[active] == nil && c.Args().First() != "config" {
+		return cli.Exit(fmt.Sprintf("profile %q not found in %s", active, cfgPath), exitUsage)
+	}
+
+	return nil
+}
+
+// saveConfig writes the configuration file. The file is only readable by the user, as it
+// may hold API tokens.
+func saveConfig() error {
+	if err := os.MkdirAll(filepath.Dir(cfgPath), 0700); err != nil {
+		return err
+	}
+	b, err := yaml.Marshal(cfg)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(cfgPath, b, 0600)
+}
+
+// activeProfile returns the selected profile, or an empty profile if it doesn't exist
+func activeProfile() *profile {
+	if cfg == nil || cfg.Profiles[active] == nil {
+		return &profile{}
+	}
+	return cfg.Profiles[active]
+}
+
+// setting returns the value of a string flag. If the flag isn't set, the value from the selected
+// profile is used, and if the profile doesn't set it, the flag's default value.
+func setting(c *cli.Context, name string) string {
+	if c.IsSet(name) {
+		return c.String(name)
+	}
+	p := activeProfile()
+	var value string
+	switch name {
+	case "token":
+		value = p.Token
+		if value == "" && len(p.Tokens) > 0 {
+			value = p.Tokens[0]
+		}
+	case "base-url":
+		value = p.BaseURL
+	case "clantag":
+		value = p.ClanTag
+	case "output":
+		value = p.Output
+	case "template":
+		value = p.Template
+	}
+	if value != "" {
+		return value
+	}
+	return c.String(name)
+}
+
+// xdgDir returns the directory named by the XDG environment variable, or the fallback directory
+// within the user's home directory
+func xdgDir(env string, fallback string) string {
+	if dir := os.Getenv(env); dir != "" {
+		return dir
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return fallback
+	}
+	return filepath.Join(home, fallback)
+}
+
+// profileKey is a setting in a profile that may be read and changed using the config command
+type profileKey struct {
+	name  string
+	usage string
+	get   func(p *profile) string
+	set   func(p *profile, value string) error
+}
+
+// profileKeys are the settings in a profile
+var profileKeys = []profileKey{
+	{
+		name:  "token",
+		usage: "API token",
+		get:   func(p *profile) string { return p.Token },
+		set:   func(p *profile, v string) error { p.Token = v; return nil },
+	},
+	{
+		name:  "tokens",
+		usage: "comma separated API tokens, each tried in turn if the server rejects the previous one",
+		get:   func(p *profile) string { return strings.Join(p.Tokens, ",") },
+		set: func(p *profile, v string) error {
+			p.Tokens = nil
+			for _, token := range strings.Split(v, ",") {
+				if token = strings.TrimSpace(token); token != "" {
+					p.Tokens = append(p.Tokens, token)
+				}
+			}
+			return nil
+		},
+	},
+	{
+		name:  "base-url",
+		usage: "base URL of the Clash of Clans API",
+		get:   func(p *profile) string { return p.BaseURL },
+		set:   func(p *profile, v string) error { p.BaseURL = v; return nil },
+	},
+	{
+		name:  "timeout",
+		usage: "time limit for each request, such as 30s",
+		get:   func(p *profile) string { return formatDuration(p.Timeout) },
+		set:   func(p *profile, v string) (err error) { p.Timeout, err = parseDuration(v); return err },
+	},
+	{
+		name:  "clantag",
+		usage: "clan tag used when a command's --clantag flag isn't set",
+		get:   func(p *profile) string { return p.ClanTag },
+		set:   func(p *profile, v string) error { p.ClanTag = v; return nil },
+	},
+	{
+		name:  "output",
+		usage: "output format: json, yaml, csv, table or template",
+		get:   func(p *profile) string { return p.Output },
+		set: func(p *profile, v string) error {
+			switch v {
+			case "", outputJSON, outputYAML, outputCSV, outputTable, outputTemplate:
+				p.Output = v
+				return nil
+			default:
+				return fmt.Errorf("unsupported output format %q", v)
+			}
+		},
+	},
+	{
+		name:  "template",
+		usage: "Go template used when the output format is template",
+		get:   func(p *profile) string { return p.Template },
+		set:   func(p *profile, v string) error { p.Template = v; return nil },
+	},
+	{
+		name:  "cache.enabled",
+		usage: "whether API responses are cached on disk: true or false",
+		get:   func(p *profile) string { return strconv.FormatBool(p.Cache.Enabled) },
+		set: func(p *profile, v string) (err error) {
+			if v == "" {
+				p.Cache.Enabled = false
+				return nil
+			}
+			p.Cache.Enabled, err = strconv.ParseBool(v)
+			return err
+		},
+	},
+	{
+		name:  "cache.dir",
+		usage: "directory holding cached responses (default: $XDG_CACHE_HOME/coc)",
+		get:   func(p *profile) string { return p.Cache.Dir },
+		set:   func(p *profile, v string) error { p.Cache.Dir = v; return nil },
+	},
+	{
+		name:  "cache.ttl",
+		usage: "how long responses are cached, such as 10m (default: as long as the server caches them)",
+		get:   func(p *profile) string { return formatDuration(p.Cache.TTL) },
+		set:   func(p *profile, v string) (err error) { p.Cache.TTL, err = parseDuration(v); return err },
+	},
+}
+
+// findProfileKey returns the profile setting with the given name
+func findProfileKey(name string) (profileKey, error) {
+	for _, key := range profileKeys {
+		if key.name == name {
+			return key, nil
+		}
+	}
+	names := make([]string, 0, len(profileKeys))
+	for _, key := range profileKeys {
+		names = append(names, key.name)
+	}
+	return profileKey{}, cli.Exit(fmt.Sprintf("unknown setting %q; settings are %s", name, strings.Join(names, ", ")), exitUsage)
+}
+
+// parseDuration parses a duration, treating an empty string as zero
+func parseDuration(s string) (time.Duration, error) {
+	if s == "" {
+		return 0, nil
+	}
+	return time.ParseDuration(s)
+}
+
+// formatDuration formats a duration, leaving zero blank
+func formatDuration(d time.Duration) string {
+	if d == 0 {
+		return ""
+	}
+	return d.String()
+}
+
+// configCommand reads and changes the configuration file
+var configCommand = &cli.Command{
+	Name:        "config",
+	Usage:       "Reads and changes the configuration file",
+	Description: configDescription(),
+	Subcommands: []*cli.Command{
+		{
+			Name:        "list",
+			Usage:       "Lists the profiles",
+			Description: "Lists the profiles in the configuration file. Tokens are masked.",
+			Action:      listProfiles,
+		},
+		{
+			Name:        "get",
+			Usage:       "Prints a setting of the profile",
+			Description: "Prints a setting of the profile selected by --profile, or of the current profile",
+			ArgsUsage:   "<setting>",
+			Action:      getProfileSetting,
+		},
+		{
+			Name:        "set",
+			Usage:       "Changes a setting of the profile",
+			Description: "Changes a setting of the profile selected by --profile, or of the current profile. The profile is created if it doesn't exist. An empty value clears the setting.",
+			ArgsUsage:   "<setting> <value>",
+			Action:      setProfileSetting,
+		},
+		{
+			Name:        "use",
+			Usage:       "Selects the current profile",
+			Description: "Selects the profile used when --profile isn't set",
+			ArgsUsage:   "<profile>",
+			Action:      useProfile,
+		},
+	},
+}
+
+// configDescription describes the configuration file and the settings in a profile
+func configDescription() string {
+	var sb strings.Builder
+	sb.WriteString("The configuration file holds named profiles. Settings in the selected profile are used when the corresponding flag or environment variable isn't set. The settings are:\n")
+	for _, key := range profileKeys {
+		sb.WriteString(fmt.Sprintf("\n   %-14s %s", key.name, key.usage))
+	}
+	return sb.String()
+}
+
+// profileSummary describes a profile in the output of config list
+type profileSummary struct {
+	Name    string `json:"name"`
+	Current bool   `json:"current"`
+	Token   string `json:"token"`
+	Tokens  int    `json:"tokens"`
+	BaseURL string `json:"baseURL"`
+	ClanTag string `json:"clanTag"`
+	Output  string `json:"output"`
+	Cache   bool   `json:"cache"`
+}
+
+// listProfiles lists the profiles in the configuration file
+func listProfiles(c *cli.Context) error {
+	names := make([]string, 0, len(cfg.Profiles))
+	for name := range cfg.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	summaries := make([]profileSummary, 0, len(names))
+	for _, name := range names {
+		p := cfg.Profiles[name]
+		summaries = append(summaries, profileSummary{
+			Name:    name,
+			Current: name == active,
+			Token:   maskToken(p.Token),
+			Tokens:  len(p.Tokens),
+			BaseURL: p.BaseURL,
+			ClanTag: p.ClanTag,
+			Output:  p.Output,
+			Cache:   p.Cache.Enabled,
+		})
+	}
+	return printResult(c, summaries)
+}
+
+// getProfileSetting prints a setting of the selected profile
+func getProfileSetting(c *cli.Context) error {
+	if c.NArg() != 1 {
+		return cli.Exit("usage: coc config get <setting>", exitUsage)
+	}
+	key, err := findProfileKey(c.Args().First())
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(c.App.Writer, key.get(activeProfile()))
+	return nil
+}
+
+// setProfileSetting changes a setting of the selected profile and saves the configuration file
+func setProfileSetting(c *cli.Context) error {
+	if c.NArg() != 2 {
+		return cli.Exit("usage: coc config set <setting> <value>", exitUsage)
+	}
+	key, err := findProfileKey(c.Args().Get(0))
+	if err != nil {
+		return err
+	}
+	p := cfg.Profiles[active]
+	if p == nil {
+		p = &profile{}
+		cfg.Profiles[active] = p
+	}
+	if err := key.set(p, c.Args().Get(1)); err != nil {
+		return cli.Exit(fmt.Sprintf("invalid value for %s: %v", key.name, err), exitUsage)
+	}
+	if cfg.CurrentProfile == "" {
+		cfg.CurrentProfile = active
+	}
+	return saveConfig()
+}
+
+// useProfile selects the current profile and saves the configuration file
+func useProfile(c *cli.Context) error {
+	if c.NArg() != 1 {
+		return cli.Exit("usage: coc config use <profile>", exitUsage)
+	}
+	name := c.Args().First()
+	if cfg.Profiles[name] == nil {
+		return cli.Exit(fmt.Sprintf("profile %q not found in %s", name, cfgPath), exitUsage)
+	}
+	cfg.CurrentProfile = name
+	return saveConfig()
+}
+
+// maskToken hides all but the end of a token
+func maskToken(token string) string {
+	if len(token) <= 4 {
+		return strings.Repeat("*", len(token))
+	}
+	return "****" + token[len(token)-4:]
+}
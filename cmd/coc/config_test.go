@@ -0,0 +1,216 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/urfave/cli/v2"
+)
+
+// settingNames are the settings reported by the test app's show command
+var settingNames = []string{"token", "base-url", "output", "template"}
+
+// runApp runs the CLI with the arguments, using the configuration file at the path, and returns
+// what it wrote. A show command reports the value of each setting in settingNames.
+func runApp(t *testing.T, path string, args ...string) (string, error) {
+	t.Helper()
+	var appFlags []cli.Flag
+	appFlags = append(appFlags, flags...)
+	appFlags = append(appFlags, outputFlags...)
+	appFlags = append(appFlags, configFlags...)
+	show := &cli.Command{
+		Name: "show",
+		Action: func(c *cli.Context) error {
+			values := make(map[string]string)
+			for _, name := range settingNames {
+				values[name] = setting(c, name)
+			}
+			b, err := json.Marshal(values)
+			c.App.Writer.Write(b)
+			return err
+		},
+	}
+
+	var buf bytes.Buffer
+	app := &cli.App{
+		Name:           appName,
+		Commands:       []*cli.Command{configCommand, show},
+		Flags:          appFlags,
+		Before:         loadConfig,
+		Writer:         &buf,
+		ErrWriter:      &buf,
+		ExitErrHandler: func(c *cli.Context, err error) {},
+	}
+	err := app.Run(append([]string{appName, "--config", path}, args...))
+	return buf.String(), err
+}
+
+// clearEnv unsets the environment variables read by the CLI for the duration of the test
+func clearEnv(t *testing.T) {
+	for _, name := range []string{"COC_TOKEN", "COC_BASE_URL", "COC_TIMEOUT", "COC_OUTPUT", "COC_CONFIG", "COC_PROFILE"} {
+		if value, ok := os.LookupEnv(name); ok {
+			os.Unsetenv(name)
+			t.Cleanup(func() { os.Setenv(name, value) })
+		}
+	}
+}
+
+// writeConfig writes the configuration file and returns its path
+func writeConfig(t *testing.T, contents string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte(contents), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestSettingPrecedence(t *testing.T) {
+	const profiles = `currentProfile: main
+profiles:
+  main:
+    token: profile-token
+    output: yaml
+    template: '{{.Name}}'
+  alt:
+    tokens: [first-token, second-token]
+    baseURL: http://proxy.example.com/v1
+`
+	tests := []struct {
+		name   string
+		config string
+		env    map[string]string
+		args   []string
+		want   map[string]string
+	}{
+		{
+			name: "defaults",
+			want: map[string]string{"token": "", "base-url": "https://api.clashofclans.com/v1", "output": "json", "template": ""},
+		},
+		{
+			name:   "profile",
+			config: profiles,
+			want:   map[string]string{"token": "profile-token", "base-url": "https://api.clashofclans.com/v1", "output": "yaml", "template": "{{.Name}}"},
+		},
+		{
+			name:   "selected profile",
+			config: profiles,
+			args:   []string{"--profile", "alt"},
+			want:   map[string]string{"token": "first-token", "base-url": "http://proxy.example.com/v1", "output": "json", "template": ""},
+		},
+		{
+			name:   "environment over profile",
+			config: profiles,
+			env:    map[string]string{"COC_TOKEN": "env-token", "COC_OUTPUT": "csv"},
+			want:   map[string]string{"token": "env-token", "base-url": "https://api.clashofclans.com/v1", "output": "csv", "template": "{{.Name}}"},
+		},
+		{
+			name:   "flag over environment",
+			config: profiles,
+			env:    map[string]string{"COC_TOKEN": "env-token", "COC_OUTPUT": "csv"},
+			args:   []string{"--token", "flag-token", "-o", "table", "--template", "{{.Tag}}"},
+			want:   map[string]string{"token": "flag-token", "base-url": "https://api.clashofclans.com/v1", "output": "table", "template": "{{.Tag}}"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clearEnv(t)
+			for name, value := range tt.env {
+				t.Setenv(name, value)
+			}
+			path := writeConfig(t, tt.config)
+			out, err := runApp(t, path, append(tt.args, "show")...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got map[string]string
+			if err := json.Unmarshal([]byte(out), &got); err != nil {
+				t.Fatalf("invalid output %q: %v", out, err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("settings = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConfigCommands(t *testing.T) {
+	clearEnv(t)
+	path := filepath.Join(t.TempDir(), "coc", "config.yaml")
+	run := func(args ...string) string {
+		t.Helper()
+		out, err := runApp(t, path, args...)
+		if err != nil {
+			t.Fatalf("%v: %v", args, err)
+		}
+		return out
+	}
+
+	run("config", "set", "token", "main-token-1234")
+	run("config", "set", "output", "template")
+	run("config", "set", "template", "{{.Name}}")
+	run("--profile", "alt", "config", "set", "timeout", "45s")
+	run("--profile", "alt", "config", "set", "tokens", "a, b ,")
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mode := info.Mode().Perm(); mode != 0600 {
+		t.Errorf("config file mode = %o, want 600", mode)
+	}
+
+	// The first profile configured becomes the current profile
+	for setting, want := range map[string]string{"token": "main-token-1234", "output": "template", "template": "{{.Name}}", "timeout": ""} {
+		if got := strings.TrimSpace(run("config", "get", setting)); got != want {
+			t.Errorf("config get %s = %q, want %q", setting, got, want)
+		}
+	}
+	for setting, want := range map[string]string{"timeout": "45s", "tokens": "a,b", "token": ""} {
+		if got := strings.TrimSpace(run("--profile", "alt", "config", "get", setting)); got != want {
+			t.Errorf("config get %s of alt = %q, want %q", setting, got, want)
+		}
+	}
+
+	// Results are written with the template set in the profile
+	run("config", "set", "template", "{{range .}}{{.Name}} {{.Current}} {{.Token}}\n{{end}}")
+	want := "alt false \ndefault true ****1234\n"
+	if got := run("config", "list"); got != want {
+		t.Errorf("config list = %q, want %q", got, want)
+	}
+
+	run("config", "use", "alt")
+	if got := strings.TrimSpace(run("config", "get", "timeout")); got != "45s" {
+		t.Errorf("config get timeout after use = %q, want 45s", got)
+	}
+
+	// Errors are usage errors, and don't change the file
+	before, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, args := range [][]string{
+		{"config", "use", "missing"},
+		{"config", "set", "output", "xml"},
+		{"config", "set", "unknown", "value"},
+		{"config", "get"},
+		{"--profile", "missing", "show"},
+	} {
+		_, err := runApp(t, path, args...)
+		if exitErr, ok := err.(cli.ExitCoder); !ok || exitErr.ExitCode() != exitUsage {
+			t.Errorf("%v: error = %v, want a usage error", args, err)
+		}
+	}
+	after, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(before, after) {
+		t.Errorf("config file changed by failed commands:\n%s", after)
+	}
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/rbrabson/coc/v1"
+	"github.com/urfave/cli/v2"
+)
+
+var (
+	// clanTagFlag is the tag of the clan a command applies to
+	clanTagFlag = &cli.StringFlag{
+		Name:    "clantag",
+		Aliases: []string{"c"},
+		EnvVars: []string{"COC_CLAN_TAG"},
+		Usage:   "The tag of the clan (required unless set in the profile)",
+	}
+
+	// playerTagFlag is the tag of the player a command applies to
+	playerTagFlag = &cli.StringFlag{
+		Name:    "playertag",
+		Aliases: []string{"p"},
+		Usage:   "The tag of the player (required)",
+	}
+
+	// locationIDFlag is the location a ranking command applies to
+	locationIDFlag = &cli.StringFlag{
+		Name:    "locationid",
+		Aliases: []string{"l"},
+		Usage:   "The location identifier, or \"global\" (required)",
+	}
+
+	// pagingFlags are the flags used to page through the items returned by list commands
+	pagingFlags = []cli.Flag{
+		&cli.IntFlag{
+			Name:  "limit",
+			Usage: "The maximum number of items to return",
+		},
+		&cli.StringFlag{
+			Name:  "after",
+			Usage: "Return only items that occur after this marker",
+		},
+		&cli.StringFlag{
+			Name:  "before",
+			Usage: "Return only items that occur before this marker",
+		},
+	}
+)
+
+// withPaging returns the flags with the paging flags added
+func withPaging(flags ...cli.Flag) []cli.Flag {
+	return append(flags, pagingFlags...)
+}
+
+// pagingQParms returns the query parameters set by the paging flags
+func pagingQParms(c *cli.Context) coc.QParms {
+	return coc.QParms{
+		Limit:  c.Int("limit"),
+		After:  c.String("after"),
+		Before: c.String("before"),
+	}
+}
+
+// requireFlags returns a usage error if any of the string flags isn't set, either directly or
+// in the selected profile
+func requireFlags(c *cli.Context, names ...string) error {
+	for _, name := range names {
+		if setting(c, name) == "" {
+			return cli.Exit(fmt.Sprintf("required flag %q not set", name), exitUsage)
+		}
+	}
+	return nil
+}
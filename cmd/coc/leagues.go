@@ -0,0 +1,370 @@
+package main
+
+import (
+	"github.com/urfave/cli/v2"
+)
+
+var (
+	// leagueIDFlag is the league a command applies to
+	leagueIDFlag = &cli.StringFlag{
+		Name:    "leagueid",
+		Aliases: []string{"i"},
+		Usage:   "The league identifier; if not set, all leagues are listed",
+	}
+
+	// leagueCommands are the commands that retrieve information about leagues, locations,
+	// rankings, labels and the gold pass
+	leagueCommands = []*cli.Command{
+		{
+			Name:        "leagues",
+			Usage:       "Retrieves the leagues",
+			Description: "Retrieves a league, or lists the leagues if no league identifier is provided",
+			Action:      getLeagues,
+			Flags:       withPaging(leagueIDFlag),
+			Subcommands: []*cli.Command{
+				{
+					Name:        "seasons",
+					Usage:       "Lists the seasons of a league",
+					Description: "Lists the seasons of a league. Season information is only available for Legend League.",
+					Action:      getLeagueSeasons,
+					Flags:       withPaging(leagueIDFlag),
+				},
+				{
+					Name:        "rankings",
+					Usage:       "Retrieves the season rankings of a league",
+					Description: "Retrieves the season rankings of a league. Season information is only available for Legend League.",
+					Action:      getLeagueSeasonRankings,
+					Flags:       []cli.Flag{leagueIDFlag},
+				},
+			},
+		},
+		{
+			Name:        "warleagues",
+			Usage:       "Retrieves the clan war leagues",
+			Description: "Retrieves a clan war league, or lists the clan war leagues if no league identifier is provided",
+			Action:      getWarLeagues,
+			Flags:       withPaging(leagueIDFlag),
+		},
+		{
+			Name:        "capitalleagues",
+			Usage:       "Retrieves the clan capital leagues",
+			Description: "Retrieves a clan capital league, or lists the clan capital leagues if no league identifier is provided",
+			Action:      getCapitalLeagues,
+			Flags:       withPaging(leagueIDFlag),
+		},
+		{
+			Name:        "locations",
+			Usage:       "Retrieves the locations",
+			Description: "Retrieves a location, or lists the locations if no location identifier is provided",
+			Action:      getLocations,
+			Flags: withPaging(
+				&cli.StringFlag{
+					Name:    "locationid",
+					Aliases: []string{"l"},
+					Usage:   "The location identifier; if not set, all locations are listed",
+				},
+			),
+		},
+		{
+			Name:        "rankings",
+			Usage:       "Retrieves the rankings for a location",
+			Description: "Retrieves the rankings for a location",
+			Subcommands: []*cli.Command{
+				{
+					Name:        "clans",
+					Usage:       "Retrieves the clan rankings for a location",
+					Description: "Retrieves the clan rankings for a location",
+					Action:      getClanRankings,
+					Flags:       withPaging(locationIDFlag),
+				},
+				{
+					Name:        "clans-versus",
+					Usage:       "Retrieves the clan builder base rankings for a location",
+					Description: "Retrieves the clan builder base rankings for a location",
+					Action:      getClanVersusRankings,
+					Flags:       withPaging(locationIDFlag),
+				},
+				{
+					Name:        "players",
+					Usage:       "Retrieves the player rankings for a location",
+					Description: "Retrieves the player rankings for a location",
+					Action:      getPlayerRankings,
+					Flags:       withPaging(locationIDFlag),
+				},
+				{
+					Name:        "players-versus",
+					Usage:       "Retrieves the player builder base rankings for a location",
+					Description: "Retrieves the player builder base rankings for a location",
+					Action:      getPlayerVersusRankings,
+					Flags:       withPaging(locationIDFlag),
+				},
+				{
+					Name:        "capitals",
+					Usage:       "Retrieves the clan capital rankings for a location",
+					Description: "Retrieves the clan capital rankings for a location",
+					Action:      getCapitalRankings,
+					Flags:       withPaging(locationIDFlag),
+				},
+			},
+		},
+		{
+			Name:        "labels",
+			Usage:       "Lists the labels",
+			Description: "Lists the labels that may be assigned to clans or players",
+			Subcommands: []*cli.Command{
+				{
+					Name:        "clans",
+					Usage:       "Lists the clan labels",
+					Description: "Lists the clan labels",
+					Action:      getClanLabels,
+					Flags:       pagingFlags,
+				},
+				{
+					Name:        "players",
+					Usage:       "Lists the player labels",
+					Description: "Lists the player labels",
+					Action:      getPlayerLabels,
+					Flags:       pagingFlags,
+				},
+			},
+		},
+		{
+			Name:        "goldpass",
+			Usage:       "Retrieves the current gold pass season",
+			Description: "Retrieves the current gold pass season",
+			Action:      getGoldPass,
+		},
+	}
+)
+
+// getLeagues gets a league, or lists the leagues
+func getLeagues(c *cli.Context) error {
+	client, err := newClient(c)
+	if err != nil {
+		return err
+	}
+
+	if id := c.String("leagueid"); id != "" {
+		league, err := client.GetLeague(id)
+		if err != nil {
+			return err
+		}
+		return printResult(c, league)
+	}
+	leagues, _, err := client.GetLeagues(pagingQParms(c))
+	if err != nil {
+		return err
+	}
+	return printResult(c, leagues)
+}
+
+// getLeagueSeasons lists the seasons of a league
+func getLeagueSeasons(c *cli.Context) error {
+	if err := requireFlags(c, "leagueid"); err != nil {
+		return err
+	}
+	client, err := newClient(c)
+	if err != nil {
+		return err
+	}
+
+	seasons, _, err := client.GetLeagueSeasons(c.String("leagueid"), pagingQParms(c))
+	if err != nil {
+		return err
+	}
+	return printResult(c, seasons)
+}
+
+// getLeagueSeasonRankings gets the season rankings of a league
+func getLeagueSeasonRankings(c *cli.Context) error {
+	if err := requireFlags(c, "leagueid"); err != nil {
+		return err
+	}
+	client, err := newClient(c)
+	if err != nil {
+		return err
+	}
+
+	rankings, _, err := client.GetLeagueSeasonRankings(c.String("leagueid"))
+	if err != nil {
+		return err
+	}
+	return printResult(c, rankings)
+}
+
+// getWarLeagues gets a clan war league, or lists the clan war leagues
+func getWarLeagues(c *cli.Context) error {
+	client, err := newClient(c)
+	if err != nil {
+		return err
+	}
+
+	if id := c.String("leagueid"); id != "" {
+		league, err := client.GetWarLeague(id)
+		if err != nil {
+			return err
+		}
+		return printResult(c, league)
+	}
+	leagues, _, err := client.GetWarLeagues(pagingQParms(c))
+	if err != nil {
+		return err
+	}
+	return printResult(c, leagues)
+}
+
+// getCapitalLeagues gets a clan capital league, or lists the clan capital leagues
+func getCapitalLeagues(c *cli.Context) error {
+	client, err := newClient(c)
+	if err != nil {
+		return err
+	}
+
+	if id := c.String("leagueid"); id != "" {
+		league, err := client.GetCapitalLeague(id)
+		if err != nil {
+			return err
+		}
+		return printResult(c, league)
+	}
+	leagues, _, err := client.ListCapitalLeagues(pagingQParms(c))
+	if err != nil {
+		return err
+	}
+	return printResult(c, leagues)
+}
+
+// getLocations gets a location, or lists the locations
+func getLocations(c *cli.Context) error {
+	client, err := newClient(c)
+	if err != nil {
+		return err
+	}
+
+	if id := c.String("locationid"); id != "" {
+		location, err := client.GetLocation(id)
+		if err != nil {
+			return err
+		}
+		return printResult(c, location)
+	}
+	locations, _, err := client.GetLocations(pagingQParms(c))
+	if err != nil {
+		return err
+	}
+	return printResult(c, locations)
+}
+
+// getRankings gets the rankings for the location using the given function
+func getRankings(c *cli.Context, rankings func(locationID string) (interface{}, error)) error {
+	if err := requireFlags(c, "locationid"); err != nil {
+		return err
+	}
+	result, err := rankings(c.String("locationid"))
+	if err != nil {
+		return err
+	}
+	return printResult(c, result)
+}
+
+// getClanRankings gets the clan rankings for a location
+func getClanRankings(c *cli.Context) error {
+	client, err := newClient(c)
+	if err != nil {
+		return err
+	}
+	return getRankings(c, func(locationID string) (interface{}, error) {
+		rankings, _, err := client.GetClanRankings(locationID, pagingQParms(c))
+		return rankings, err
+	})
+}
+
+// getClanVersusRankings gets the clan builder base rankings for a location
+func getClanVersusRankings(c *cli.Context) error {
+	client, err := newClient(c)
+	if err != nil {
+		return err
+	}
+	return getRankings(c, func(locationID string) (interface{}, error) {
+		rankings, _, err := client.GetClanVersusRankings(locationID, pagingQParms(c))
+		return rankings, err
+	})
+}
+
+// getPlayerRankings gets the player rankings for a location
+func getPlayerRankings(c *cli.Context) error {
+	client, err := newClient(c)
+	if err != nil {
+		return err
+	}
+	return getRankings(c, func(locationID string) (interface{}, error) {
+		rankings, _, err := client.GetPlayerRankings(locationID, pagingQParms(c))
+		return rankings, err
+	})
+}
+
+// getPlayerVersusRankings gets the player builder base rankings for a location
+func getPlayerVersusRankings(c *cli.Context) error {
+	client, err := newClient(c)
+	if err != nil {
+		return err
+	}
+	return getRankings(c, func(locationID string) (interface{}, error) {
+		rankings, _, err := client.GetPlayerVersusRankings(locationID, pagingQParms(c))
+		return rankings, err
+	})
+}
+
+// getCapitalRankings gets the clan capital rankings for a location
+func getCapitalRankings(c *cli.Context) error {
+	client, err := newClient(c)
+	if err != nil {
+		return err
+	}
+	return getRankings(c, func(locationID string) (interface{}, error) {
+		rankings, _, err := client.GetCapitalRankings(locationID, pagingQParms(c))
+		return rankings, err
+	})
+}
+
+// getClanLabels lists the clan labels
+func getClanLabels(c *cli.Context) error {
+	client, err := newClient(c)
+	if err != nil {
+		return err
+	}
+
+	labels, _, err := client.GetClanLabels(pagingQParms(c))
+	if err != nil {
+		return err
+	}
+	return printResult(c, labels)
+}
+
+// getPlayerLabels lists the player labels
+func getPlayerLabels(c *cli.Context) error {
+	client, err := newClient(c)
+	if err != nil {
+		return err
+	}
+
+	labels, _, err := client.GetPlayerLabels(pagingQParms(c))
+	if err != nil {
+		return err
+	}
+	return printResult(c, labels)
+}
+
+// getGoldPass gets the current gold pass season
+func getGoldPass(c *cli.Context) error {
+	client, err := newClient(c)
+	if err != nil {
+		return err
+	}
+
+	goldPass, err := client.GetGoldPass()
+	if err != nil {
+		return err
+	}
+	return printResult(c, goldPass)
+}
@@ -0,0 +1,177 @@
+// Command coc retrieves information from the Clash of Clans API.
+//
+// Usage:  coc [global options] <command> [command options]
+//
+// For example:
+//
+//	coc -t <APITOKEN> clan -c <CLANTAG>
+//	coc -t <APITOKEN> currentwar -c <CLANTAG>
+//	coc -t <APITOKEN> player -p <PLAYERTAG>
+//	coc -t <APITOKEN> cwl group -c <CLANTAG>
+//	coc -t <APITOKEN> roster record -c <CLANTAG> -f roster.jsonl
+//
+// Settings such as the API token, default clan tag and output format may be kept in named
+// profiles in a configuration file, by default $XDG_CONFIG_HOME/coc/config.yaml:
+//
+//	coc config set token <APITOKEN>
+//	coc --profile alt config set clantag <CLANTAG>
+//	coc --profile alt members
+//
+// The exit status is 0 on success, 1 for an unexpected error, 2 for invalid usage, 3 if the
+// clan, player or other item isn't found, 4 if access is denied (including a private war log),
+// 5 if the clan isn't in a war, and 6 if the API is unavailable or the rate limit was exceeded.
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/rbrabson/coc/pkg/rest"
+	"github.com/rbrabson/coc/v1"
+	"github.com/urfave/cli/v2"
+)
+
+const (
+	appName = "coc"
+	usage   = "Command line interface to the Clash of Clans API"
+)
+
+// Exit codes returned by the command
+const (
+	exitOK          = 0
+	exitError       = 1
+	exitUsage       = 2
+	exitNotFound    = 3
+	exitForbidden   = 4
+	exitNotInWar    = 5
+	exitUnavailable = 6
+)
+
+var (
+	// flags are the global flags supported by every command
+	flags = []cli.Flag{
+		&cli.StringFlag{
+			Name:        "token",
+			Aliases:     []string{"t"},
+			EnvVars:     []string{"COC_TOKEN"},
+			Usage:       "API token to use for authentication with the Clash of Clans REST server",
+			DefaultText: " ",
+		},
+		&cli.StringFlag{
+			Name:    "base-url",
+			EnvVars: []string{"COC_BASE_URL"},
+			Usage:   "Base URL of the Clash of Clans API, such as that of a proxy",
+			Value:   coc.DefaultBaseURL,
+		},
+		&cli.DurationFlag{
+			Name:    "timeout",
+			EnvVars: []string{"COC_TIMEOUT"},
+			Usage:   "Time limit for each request to the Clash of Clans API",
+			Value:   30 * time.Second,
+		},
+	}
+
+	// commands are the commands supported by the CLI
+	commands = []*cli.Command{}
+)
+
+func main() {
+	commands = append(commands, clanCommands...)
+	commands = append(commands, playerCommands...)
+	commands = append(commands, leagueCommands...)
+	commands = append(commands, rosterCommand, configCommand)
+	flags = append(flags, outputFlags...)
+	flags = append(flags, configFlags...)
+
+	app := &cli.App{
+		Name:     appName,
+		Commands: commands,
+		Flags:    flags,
+		Usage:    usage,
+		Before:   loadConfig,
+		OnUsageError: func(c *cli.Context, err error, isSubcommand bool) error {
+			return cli.Exit(err, exitUsage)
+		},
+		ExitErrHandler: func(c *cli.Context, err error) {},
+	}
+
+	err := app.Run(os.Args)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(exitCode(err))
+	}
+	os.Exit(exitOK)
+}
+
+// newClient creates a client using the global flags and the selected profile
+func newClient(c *cli.Context) (*coc.Client, error) {
+	p := activeProfile()
+	token := setting(c, "token")
+	if token == "" {
+		return nil, cli.Exit("an API token is required; use --token, set COC_TOKEN or run 'coc config set token <token>'", exitUsage)
+	}
+	timeout := c.Duration("timeout")
+	if !c.IsSet("timeout") && p.Timeout > 0 {
+		timeout = p.Timeout
+	}
+
+	// When the token comes from a profile with several tokens, each is tried in turn
+	transport := rest.DefaultTransport()
+	if !c.IsSet("token") && p.Token == "" && len(p.Tokens) > 1 {
+		transport = &tokenTransport{tokens: p.Tokens, base: transport}
+	}
+	if p.Cache.Enabled && !c.Bool("no-cache") {
+		dir := p.Cache.Dir
+		if dir == "" {
+			dir = filepath.Join(xdgDir("XDG_CACHE_HOME", ".cache"), "coc")
+		}
+		transport = &cacheTransport{dir: dir, ttl: p.Cache.TTL, base: transport}
+	}
+
+	client := coc.NewClient(token,
+		coc.WithBaseURL(setting(c, "base-url")),
+		coc.WithTimeout(timeout),
+		coc.WithTransport(transport),
+		coc.WithCWLDetection(),
+	)
+	return &client, nil
+}
+
+// exitCode returns the exit code for the error
+func exitCode(err error) int {
+	var exitErr cli.ExitCoder
+	if errors.As(err, &exitErr) {
+		return exitErr.ExitCode()
+	}
+
+	switch {
+	case errors.Is(err, coc.ErrTagMissing):
+		return exitUsage
+	case errors.Is(err, coc.ErrClanNotFound):
+		return exitNotFound
+	case errors.Is(err, coc.ErrPrivateWarLog):
+		return exitForbidden
+	case errors.Is(err, coc.ErrNotInWar), errors.Is(err, coc.ErrInCWL):
+		return exitNotInWar
+	}
+
+	var httpErr rest.ErrHttp
+	if errors.As(err, &httpErr) {
+		switch httpErr.StatusCode {
+		case http.StatusBadRequest:
+			return exitUsage
+		case http.StatusForbidden:
+			return exitForbidden
+		case http.StatusNotFound:
+			return exitNotFound
+		case http.StatusTooManyRequests, http.StatusServiceUnavailable:
+			return exitUnavailable
+		}
+	}
+
+	return exitError
+}
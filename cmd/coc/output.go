@@ -0,0 +1,423 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"text/template"
+	"time"
+
+	"github.com/rbrabson/coc/pkg/roster"
+	"github.com/rbrabson/coc/v1"
+	"github.com/urfave/cli/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// Output formats supported by the CLI
+const (
+	outputJSON     = "json"
+	outputYAML     = "yaml"
+	outputCSV      = "csv"
+	outputTable    = "table"
+	outputTemplate = "template"
+)
+
+var (
+	// outputFlags are the global flags that select how results are written
+	outputFlags = []cli.Flag{
+		&cli.StringFlag{
+			Name:    "output",
+			Aliases: []string{"o"},
+			EnvVars: []string{"COC_OUTPUT"},
+			Usage:   "Output format: json, yaml, csv, table or template",
+			Value:   outputJSON,
+		},
+		&cli.StringFlag{
+			Name:  "template",
+			Usage: "Go template used to format the result when the output format is template, such as '{{range .}}{{.Name}}{{\"\\n\"}}{{end}}'",
+		},
+	}
+
+	// templateFuncs are the functions available to output templates
+	templateFuncs = template.FuncMap{
+		"json": func(v interface{}) (string, error) {
+			b, err := json.Marshal(v)
+			return string(b), err
+		},
+		"join":  strings.Join,
+		"upper": strings.ToUpper,
+		"lower": strings.ToLower,
+	}
+)
+
+// printResult writes the result of a command to the app's writer, normally standard output, in the
+// format selected by the output flag
+func printResult(c *cli.Context, v interface{}) error {
+	return writeResult(c.App.Writer, setting(c, "output"), setting(c, "template"), v)
+}
+
+// writeResult writes the value to the writer in the given format
+func writeResult(w io.Writer, format string, tmpl string, v interface{}) error {
+	switch format {
+	case outputJSON, "":
+		return writeJSON(w, v)
+	case outputYAML:
+		return writeYAML(w, v)
+	case outputCSV:
+		header, rows := tabulate(v)
+		return writeCSV(w, header, rows)
+	case outputTable:
+		header, rows := tabulate(v)
+		return writeTable(w, header, rows)
+	case outputTemplate:
+		return writeTemplate(w, tmpl, v)
+	default:
+		return cli.Exit(fmt.Sprintf("unsupported output format %q", format), exitUsage)
+	}
+}
+
+// writeJSON writes the value as indented JSON
+func writeJSON(w io.Writer, v interface{}) error {
+	b, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(w, string(b))
+	return err
+}
+
+// writeYAML writes the value as YAML. The value is converted to JSON first, so that the field
+// names and their order match the JSON output.
+func writeYAML(w io.Writer, v interface{}) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	var node yaml.Node
+	if err := yaml.Unmarshal(b, &node); err != nil {
+		return err
+	}
+	blockStyle(&node)
+
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(&node); err != nil {
+		return err
+	}
+	return enc.Close()
+}
+
+// blockStyle clears the JSON flow and quoting styles from the YAML nodes, so that they are
+// written in YAML's block style
+func blockStyle(node *yaml.Node) {
+	node.Style = 0
+	for _, child := range node.Content {
+		blockStyle(child)
+	}
+}
+
+// writeCSV writes the rows as comma separated values, preceded by the header
+func writeCSV(w io.Writer, header []string, rows [][]string) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(header); err != nil {
+		return err
+	}
+	if err := cw.WriteAll(rows); err != nil {
+		return err
+	}
+	return cw.Error()
+}
+
+// writeTable writes the rows as aligned columns, preceded by the header
+func writeTable(w io.Writer, header []string, rows [][]string) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	upper := make([]string, len(header))
+	for i, h := range header {
+		upper[i] = strings.ToUpper(h)
+	}
+	fmt.Fprintln(tw, strings.Join(upper, "\t"))
+	for _, row := range rows {
+		fmt.Fprintln(tw, strings.Join(row, "\t"))
+	}
+	return tw.Flush()
+}
+
+// writeTemplate writes the value using the Go template
+func writeTemplate(w io.Writer, tmpl string, v interface{}) error {
+	if tmpl == "" {
+		return cli.Exit("a template is required when the output format is template; use --template or run 'coc config set template <template>'", exitUsage)
+	}
+	t, err := template.New("output").Funcs(templateFuncs).Parse(tmpl)
+	if err != nil {
+		return cli.Exit(fmt.Sprintf("invalid template: %v", err), exitUsage)
+	}
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, v); err != nil {
+		return err
+	}
+	if buf.Len() > 0 && buf.Bytes()[buf.Len()-1] != '\n' {
+		buf.WriteByte('\n')
+	}
+	_, err = w.Write(buf.Bytes())
+	return err
+}
+
+// tabulate converts the value to a header and rows for table and CSV output. Results with a
+// curated set of columns are handled explicitly; other lists have a column for each of the
+// simple fields of their items, and single items have a row for each simple field.
+func tabulate(v interface{}) ([]string, [][]string) {
+	switch v := v.(type) {
+	case []coc.ClanMember:
+		header := []string{"rank", "tag", "name", "role", "level", "league", "trophies", "donations", "received"}
+		rows := make([][]string, 0, len(v))
+		for _, m := range v {
+			rows = append(rows, []string{
+				strconv.Itoa(m.ClanRank), m.Tag, m.Name, m.Role, strconv.Itoa(m.ExpLevel), m.League.Name,
+				strconv.Itoa(m.Trophies), strconv.Itoa(m.Donations), strconv.Itoa(m.DonationsReceived),
+			})
+		}
+		return header, rows
+	case []coc.ClanWar:
+		header := []string{"end", "result", "size", "opponent", "opponent tag", "stars", "destruction", "opponent stars", "opponent destruction"}
+		rows := make([][]string, 0, len(v))
+		for _, w := range v {
+			rows = append(rows, []string{
+				formatTime(w.EndTime.Time()), w.Result, strconv.Itoa(w.TeamSize), w.Opponent.Name, w.Opponent.Tag,
+				strconv.Itoa(w.Clan.Stars), formatFloat32(w.Clan.DestructionPercentage),
+				strconv.Itoa(w.Opponent.Stars), formatFloat32(w.Opponent.DestructionPercentage),
+			})
+		}
+		return header, rows
+	case *coc.ClanWar:
+		header := []string{"side", "tag", "name", "attacks", "stars", "destruction"}
+		var rows [][]string
+		for _, t := range []coc.ClanWarTeam{v.Clan, v.Opponent} {
+			rows = append(rows, []string{
+				"", t.Tag, t.Name, strconv.Itoa(t.Attacks), strconv.Itoa(t.Stars), formatFloat32(t.DestructionPercentage),
+			})
+		}
+		rows[0][0], rows[1][0] = "clan", "opponent"
+		return header, rows
+	case *coc.CWLSeason:
+		header := []string{"rank", "tag", "name", "stars", "destruction", "wins", "ties", "losses", "wars"}
+		rows := make([][]string, 0, len(v.Standings))
+		for _, s := range v.Standings {
+			rows = append(rows, []string{
+				strconv.Itoa(s.Rank), s.Tag, s.Name, strconv.Itoa(s.Stars), formatFloat(s.Destruction),
+				strconv.Itoa(s.Wins), strconv.Itoa(s.Ties), strconv.Itoa(s.Losses), strconv.Itoa(s.WarsPlayed),
+			})
+		}
+		return header, rows
+	case roster.Diff:
+		header := []string{"time", "change", "tag", "name", "role"}
+		type change struct {
+			time time.Time
+			row  []string
+		}
+		var changes []change
+		for _, m := range v.Joined {
+			changes = append(changes, change{m.Time, []string{formatTime(m.Time), "joined", m.Tag, m.Name, m.Role}})
+		}
+		for _, m := range v.Left {
+			changes = append(changes, change{m.Time, []string{formatTime(m.Time), "left", m.Tag, m.Name, m.Role}})
+		}
+		for _, r := range v.RoleChanges {
+			changes = append(changes, change{r.Time, []string{formatTime(r.Time), "role", r.Tag, r.Name, r.OldRole + " -> " + r.NewRole}})
+		}
+		sort.SliceStable(changes, func(i, j int) bool {
+			return changes[i].time.Before(changes[j].time)
+		})
+		rows := make([][]string, 0, len(changes))
+		for _, c := range changes {
+			rows = append(rows, c.row)
+		}
+		return header, rows
+	}
+
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Ptr || rv.Kind() == reflect.Interface {
+		if rv.IsNil() {
+			return nil, nil
+		}
+		rv = rv.Elem()
+	}
+	switch rv.Kind() {
+	case reflect.Slice, reflect.Array:
+		return tabulateList(rv)
+	case reflect.Struct:
+		return tabulateFields(rv)
+	case reflect.Map:
+		return tabulateMap(rv)
+	default:
+		return []string{"value"}, [][]string{{formatValue(rv)}}
+	}
+}
+
+// tabulateList returns a row for each item in the list, with a column for each simple field
+func tabulateList(rv reflect.Value) ([]string, [][]string) {
+	elemType := rv.Type().Elem()
+	for elemType.Kind() == reflect.Ptr {
+		elemType = elemType.Elem()
+	}
+	if elemType.Kind() != reflect.Struct {
+		rows := make([][]string, 0, rv.Len())
+		for i := 0; i < rv.Len(); i++ {
+			rows = append(rows, []string{formatValue(rv.Index(i))})
+		}
+		return []string{"value"}, rows
+	}
+
+	fields := simpleFields(elemType)
+	header := make([]string, 0, len(fields))
+	for _, f := range fields {
+		header = append(header, f.name)
+	}
+	rows := make([][]string, 0, rv.Len())
+	for i := 0; i < rv.Len(); i++ {
+		item := reflect.Indirect(rv.Index(i))
+		row := make([]string, 0, len(fields))
+		for _, f := range fields {
+			if !item.IsValid() {
+				row = append(row, "")
+				continue
+			}
+			row = append(row, formatValue(item.FieldByIndex(f.index)))
+		}
+		rows = append(rows, row)
+	}
+	return header, rows
+}
+
+// tabulateFields returns a row for each simple field of the struct
+func tabulateFields(rv reflect.Value) ([]string, [][]string) {
+	var rows [][]string
+	for _, f := range simpleFields(rv.Type()) {
+		rows = append(rows, []string{f.name, formatValue(rv.FieldByIndex(f.index))})
+	}
+	return []string{"field", "value"}, rows
+}
+
+// tabulateMap returns a row for each entry in the map, ordered by key
+func tabulateMap(rv reflect.Value) ([]string, [][]string) {
+	var rows [][]string
+	for _, k := range rv.MapKeys() {
+		rows = append(rows, []string{formatValue(k), formatValue(rv.MapIndex(k))})
+	}
+	sort.Slice(rows, func(i, j int) bool {
+		return rows[i][0] < rows[j][0]
+	})
+	return []string{"key", "value"}, rows
+}
+
+// field is a struct field included in a table
+type field struct {
+	name  string
+	index []int
+}
+
+// simpleFields returns the exported fields of the struct type that hold a single value, such as
+// a string, number or time, named as they are in the JSON output
+func simpleFields(t reflect.Type) []field {
+	var fields []field
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		// As in the JSON output, the exported fields of unexported embedded structs are included
+		if f.PkgPath != "" && !(f.Anonymous && f.Type.Kind() == reflect.Struct) {
+			continue
+		}
+		name := f.Name
+		if tag := strings.Split(f.Tag.Get("json"), ",")[0]; tag == "-" {
+			continue
+		} else if tag != "" {
+			name = tag
+		}
+		ft := f.Type
+		if f.Anonymous && ft.Kind() == reflect.Struct && !isTime(ft) {
+			for _, embedded := range simpleFields(ft) {
+				fields = append(fields, field{name: embedded.name, index: append([]int{i}, embedded.index...)})
+			}
+			continue
+		}
+		if isSimple(ft) {
+			fields = append(fields, field{name: name, index: []int{i}})
+		}
+	}
+	return fields
+}
+
+var (
+	timeType    = reflect.TypeOf(time.Time{})
+	cocTimeType = reflect.TypeOf(coc.Time{})
+)
+
+// isTime returns an indication as to whether the type is a time
+func isTime(t reflect.Type) bool {
+	return t == timeType || t == cocTimeType
+}
+
+// isSimple returns an indication as to whether the type holds a single value
+func isSimple(t reflect.Type) bool {
+	if isTime(t) {
+		return true
+	}
+	switch t.Kind() {
+	case reflect.String, reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
+
+// formatValue formats a single value for a table or CSV cell
+func formatValue(rv reflect.Value) string {
+	for rv.Kind() == reflect.Ptr || rv.Kind() == reflect.Interface {
+		if rv.IsNil() {
+			return ""
+		}
+		rv = rv.Elem()
+	}
+	switch {
+	case rv.Type() == timeType:
+		return formatTime(rv.Interface().(time.Time))
+	case rv.Type() == cocTimeType:
+		return formatTime(rv.Interface().(coc.Time).Time())
+	}
+	switch rv.Kind() {
+	case reflect.Float32:
+		return formatFloat32(float32(rv.Float()))
+	case reflect.Float64:
+		return formatFloat(rv.Float())
+	case reflect.Struct, reflect.Slice, reflect.Array, reflect.Map:
+		b, _ := json.Marshal(rv.Interface())
+		return string(b)
+	default:
+		return fmt.Sprint(rv.Interface())
+	}
+}
+
+// formatTime formats a time for a table or CSV cell. The zero time is left blank.
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(time.RFC3339)
+}
+
+// formatFloat formats a number for a table or CSV cell
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
+// formatFloat32 formats a single precision number for a table or CSV cell
+func formatFloat32(f float32) string {
+	return strconv.FormatFloat(float64(f), 'f', -1, 32)
+}
@@ -0,0 +1,272 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/rbrabson/coc/pkg/roster"
+	"github.com/rbrabson/coc/v1"
+	"github.com/urfave/cli/v2"
+)
+
+// update rewrites the golden files with the output of the tests
+var update = flag.Bool("update", false, "update the golden files")
+
+// checkGolden compares the output with the golden file in testdata
+func checkGolden(t *testing.T, name string, got []byte) {
+	t.Helper()
+	path := filepath.Join("testdata", name)
+	if *update {
+		if err := ioutil.WriteFile(path, got, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("output doesn't match %s\ngot:\n%s\nwant:\n%s", path, got, want)
+	}
+}
+
+// members are the clan members written in each format
+var members = []coc.ClanMember{
+	{ClanRank: 1, Tag: "#P1", Name: "Leader", Role: "leader", ExpLevel: 210, League: coc.League{Name: "Legend League"}, Trophies: 5400, Donations: 1200, DonationsReceived: 300},
+	{ClanRank: 2, Tag: "#P2", Name: `Smith, "Jr"`, Role: "member", ExpLevel: 95, League: coc.League{Name: "Gold League I"}, Trophies: 1850, Donations: 0, DonationsReceived: 450},
+}
+
+func TestWriteResult(t *testing.T) {
+	tests := []struct {
+		format string
+		tmpl   string
+		golden string
+	}{
+		{format: "", golden: "members.json"},
+		{format: outputJSON, golden: "members.json"},
+		{format: outputYAML, golden: "members.yaml"},
+		{format: outputCSV, golden: "members.csv"},
+		{format: outputTable, golden: "members.table"},
+		{format: outputTemplate, tmpl: `{{range .}}{{.Tag | lower}} {{upper .Role}} {{json .League}}{{"\n"}}{{end}}`, golden: "members.template"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.golden+"/"+tt.format, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := writeResult(&buf, tt.format, tt.tmpl, members); err != nil {
+				t.Fatal(err)
+			}
+			checkGolden(t, tt.golden, buf.Bytes())
+		})
+	}
+}
+
+func TestWriteResultErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		format string
+		tmpl   string
+	}{
+		{name: "unsupported format", format: "xml"},
+		{name: "missing template", format: outputTemplate},
+		{name: "invalid template", format: outputTemplate, tmpl: "{{.Name"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			err := writeResult(&buf, tt.format, tt.tmpl, members)
+			exitErr, ok := err.(cli.ExitCoder)
+			if !ok || exitErr.ExitCode() != exitUsage {
+				t.Errorf("writeResult() error = %v, want a usage error", err)
+			}
+			if buf.Len() != 0 {
+				t.Errorf("writeResult() wrote %q", buf.String())
+			}
+		})
+	}
+}
+
+func TestWriteCSV(t *testing.T) {
+	var buf bytes.Buffer
+	header := []string{"name", "note"}
+	rows := [][]string{{"a,b", `say "hi"`}, {"multi\nline", ""}}
+	if err := writeCSV(&buf, header, rows); err != nil {
+		t.Fatal(err)
+	}
+	checkGolden(t, "quoting.csv", buf.Bytes())
+}
+
+func TestWriteTable(t *testing.T) {
+	var buf bytes.Buffer
+	header := []string{"tag", "name", "opponent tag"}
+	rows := [][]string{{"#P1", "A", "#O1"}, {"#LONGTAG", "A much longer name", ""}}
+	if err := writeTable(&buf, header, rows); err != nil {
+		t.Fatal(err)
+	}
+	checkGolden(t, "columns.table", buf.Bytes())
+}
+
+func TestWriteTemplate(t *testing.T) {
+	tests := []struct {
+		name string
+		tmpl string
+		want string
+	}{
+		{name: "newline added", tmpl: "{{len .}} members", want: "2 members\n"},
+		{name: "trailing newline kept", tmpl: "{{(index . 0).Name}}\n", want: "Leader\n"},
+		{name: "functions", tmpl: `{{join (index . 0).Labels ","}}`, want: "a,b\n"},
+		{name: "empty output", tmpl: "{{if false}}x{{end}}", want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			v := []struct {
+				Name   string
+				Labels []string
+			}{{Name: "Leader", Labels: []string{"a", "b"}}, {Name: "Member"}}
+			if err := writeTemplate(&buf, tt.tmpl, v); err != nil {
+				t.Fatal(err)
+			}
+			if got := buf.String(); got != tt.want {
+				t.Errorf("writeTemplate() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// item is a value tabulated using reflection
+type item struct {
+	Name     string    `json:"name"`
+	Count    int       `json:"count,omitempty"`
+	Ratio    float64   `json:"ratio"`
+	Seen     time.Time `json:"seen"`
+	Ended    coc.Time  `json:"ended"`
+	Tags     []string  `json:"tags"`
+	Secret   string    `json:"-"`
+	Untagged bool
+	hidden   string
+	embedded
+}
+
+// embedded is a struct whose fields are promoted into item
+type embedded struct {
+	Level int `json:"level"`
+}
+
+func TestTabulate(t *testing.T) {
+	at := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	war := coc.ClanWar{
+		TeamSize: 15,
+		EndTime:  coc.NewTime(at),
+		Result:   "win",
+		Clan:     coc.ClanWarTeam{Tag: "#2PP", Name: "Us", Attacks: 28, Stars: 40, DestructionPercentage: 93.5},
+		Opponent: coc.ClanWarTeam{Tag: "#8QU", Name: "Them", Attacks: 30, Stars: 38, DestructionPercentage: 90.25},
+	}
+	tests := []struct {
+		name       string
+		v          interface{}
+		wantHeader []string
+		wantRows   [][]string
+	}{
+		{
+			name:       "members",
+			v:          members[:1],
+			wantHeader: []string{"rank", "tag", "name", "role", "level", "league", "trophies", "donations", "received"},
+			wantRows:   [][]string{{"1", "#P1", "Leader", "leader", "210", "Legend League", "5400", "1200", "300"}},
+		},
+		{
+			name:       "war log",
+			v:          []coc.ClanWar{war},
+			wantHeader: []string{"end", "result", "size", "opponent", "opponent tag", "stars", "destruction", "opponent stars", "opponent destruction"},
+			wantRows:   [][]string{{"2024-03-01T12:00:00Z", "win", "15", "Them", "#8QU", "40", "93.5", "38", "90.25"}},
+		},
+		{
+			name:       "current war",
+			v:          &war,
+			wantHeader: []string{"side", "tag", "name", "attacks", "stars", "destruction"},
+			wantRows: [][]string{
+				{"clan", "#2PP", "Us", "28", "40", "93.5"},
+				{"opponent", "#8QU", "Them", "30", "38", "90.25"},
+			},
+		},
+		{
+			name: "league season",
+			v: &coc.CWLSeason{Standings: []coc.CWLStanding{
+				{Rank: 1, Tag: "#2PP", Name: "Us", Stars: 65, Destruction: 612.5, Wins: 6, Losses: 1, WarsPlayed: 7},
+			}},
+			wantHeader: []string{"rank", "tag", "name", "stars", "destruction", "wins", "ties", "losses", "wars"},
+			wantRows:   [][]string{{"1", "#2PP", "Us", "65", "612.5", "6", "0", "1", "7"}},
+		},
+		{
+			name: "roster changes",
+			v: roster.Diff{
+				Joined:      []roster.MemberChange{{Tag: "#P3", Name: "New", Role: "member", Time: at.Add(2 * time.Hour)}},
+				Left:        []roster.MemberChange{{Tag: "#P4", Name: "Gone", Role: "elder", Time: at}},
+				RoleChanges: []roster.RoleChange{{Tag: "#P1", Name: "Leader", OldRole: "coLeader", NewRole: "leader", Time: at.Add(time.Hour)}},
+			},
+			wantHeader: []string{"time", "change", "tag", "name", "role"},
+			wantRows: [][]string{
+				{"2024-03-01T12:00:00Z", "left", "#P4", "Gone", "elder"},
+				{"2024-03-01T13:00:00Z", "role", "#P1", "Leader", "coLeader -> leader"},
+				{"2024-03-01T14:00:00Z", "joined", "#P3", "New", "member"},
+			},
+		},
+		{
+			name: "list of structs",
+			v: []*item{
+				{Name: "a", Count: 3, Ratio: 0.5, Seen: at, Ended: coc.NewTime(at), Tags: []string{"x"}, Secret: "s", Untagged: true, embedded: embedded{Level: 2}},
+				nil,
+			},
+			wantHeader: []string{"name", "count", "ratio", "seen", "ended", "Untagged", "level"},
+			wantRows: [][]string{
+				{"a", "3", "0.5", "2024-03-01T12:00:00Z", "2024-03-01T12:00:00Z", "true", "2"},
+				{"", "", "", "", "", "", ""},
+			},
+		},
+		{
+			name:       "single struct",
+			v:          &item{Name: "b", Ratio: 1.25},
+			wantHeader: []string{"field", "value"},
+			wantRows: [][]string{
+				{"name", "b"}, {"count", "0"}, {"ratio", "1.25"}, {"seen", ""}, {"ended", ""}, {"Untagged", "false"}, {"level", "0"},
+			},
+		},
+		{
+			name:       "map",
+			v:          map[string][]int{"b": {1, 2}, "a": nil},
+			wantHeader: []string{"key", "value"},
+			wantRows:   [][]string{{"a", "null"}, {"b", "[1,2]"}},
+		},
+		{
+			name:       "list of values",
+			v:          []string{"x", "y"},
+			wantHeader: []string{"value"},
+			wantRows:   [][]string{{"x"}, {"y"}},
+		},
+		{
+			name:       "single value",
+			v:          42,
+			wantHeader: []string{"value"},
+			wantRows:   [][]string{{"42"}},
+		},
+		{
+			name: "nil",
+			v:    (*item)(nil),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			header, rows := tabulate(tt.v)
+			if !reflect.DeepEqual(header, tt.wantHeader) {
+				t.Errorf("tabulate() header = %q, want %q", header, tt.wantHeader)
+			}
+			if !reflect.DeepEqual(rows, tt.wantRows) {
+				t.Errorf("tabulate() rows = %q, want %q", rows, tt.wantRows)
+			}
+		})
+	}
+}
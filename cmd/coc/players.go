@@ -0,0 +1,79 @@
+package main
+
+import (
+	"github.com/urfave/cli/v2"
+)
+
+var (
+	// playerCommands are the commands that retrieve information about players
+	playerCommands = []*cli.Command{
+		{
+			Name:        "player",
+			Usage:       "Retrieves information about a player",
+			Description: "Retrieves information about a player",
+			Action:      getPlayer,
+			Flags:       []cli.Flag{playerTagFlag},
+		},
+		{
+			Name:        "verify",
+			Usage:       "Verifies a player's API token",
+			Description: "Verifies the API token shown in a player's in-game settings, to confirm the account belongs to a user",
+			Action:      verifyPlayerToken,
+			Flags: []cli.Flag{
+				playerTagFlag,
+				&cli.StringFlag{
+					Name:    "apitoken",
+					Aliases: []string{"a"},
+					Usage:   "The player's API token from the in-game settings (required)",
+				},
+			},
+		},
+	}
+)
+
+// verification is the result of verifying a player's API token
+type verification struct {
+	Tag   string `json:"tag"`
+	Valid bool   `json:"valid"`
+}
+
+// getPlayer gets information about a player
+func getPlayer(c *cli.Context) error {
+	if err := requireFlags(c, "playertag"); err != nil {
+		return err
+	}
+	client, err := newClient(c)
+	if err != nil {
+		return err
+	}
+
+	player, err := client.GetPlayer(c.String("playertag"))
+	if err != nil {
+		return err
+	}
+	return printResult(c, player)
+}
+
+// verifyPlayerToken verifies a player's API token. The exit status is non-zero if the token
+// is invalid.
+func verifyPlayerToken(c *cli.Context) error {
+	if err := requireFlags(c, "playertag", "apitoken"); err != nil {
+		return err
+	}
+	client, err := newClient(c)
+	if err != nil {
+		return err
+	}
+
+	valid, err := client.VerifyPlayerToken(c.String("playertag"), c.String("apitoken"))
+	if err != nil {
+		return err
+	}
+	if err := printResult(c, verification{Tag: c.String("playertag"), Valid: valid}); err != nil {
+		return err
+	}
+	if !valid {
+		return cli.Exit("", exitForbidden)
+	}
+	return nil
+}
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/rbrabson/coc/pkg/roster"
+	"github.com/urfave/cli/v2"
+)
+
+var (
+	// rosterFlags are the flags common to each of the roster commands
+	rosterFlags = []cli.Flag{
+		clanTagFlag,
+		&cli.StringFlag{
+			Name:    "file",
+			Aliases: []string{"f"},
+			Usage:   "The file in which roster snapshots are stored",
+			Value:   "roster.jsonl",
+		},
+	}
+
+	// rosterCommand tracks the history of a clan's members
+	rosterCommand = &cli.Command{
+		Name:        "roster",
+		Usage:       "Tracks the history of a clan's members",
+		Description: "Records snapshots of a clan's members and reports how the members changed over time",
+		Subcommands: []*cli.Command{
+			{
+				Name:        "record",
+				Usage:       "Records a snapshot of the members of the clan",
+				Description: "Records a snapshot of the members of the clan",
+				Action:      recordRoster,
+				Flags:       rosterFlags,
+			},
+			{
+				Name:        "diff",
+				Usage:       "Lists the players who joined and left the clan between two times",
+				Description: "Lists the players who joined and left the clan, and the role changes of its members, between two times. Times are either RFC3339 timestamps or durations before now, such as 24h.",
+				Action:      diffRoster,
+				Flags: append([]cli.Flag{
+					&cli.StringFlag{
+						Name:    "since",
+						Aliases: []string{"s"},
+						Usage:   "The start time (required)",
+					},
+					&cli.StringFlag{
+						Name:    "until",
+						Aliases: []string{"u"},
+						Usage:   "The end time; defaults to now",
+					},
+				}, rosterFlags...),
+			},
+			{
+				Name:        "members",
+				Usage:       "Lists how long each player has been a member of the clan",
+				Description: "Lists how long each player has been a member of the clan",
+				Action:      listMemberships,
+				Flags:       rosterFlags,
+			},
+			{
+				Name:        "roles",
+				Usage:       "Lists the promotions and demotions of the members of the clan",
+				Description: "Lists the promotions and demotions of the members of the clan",
+				Action:      listRoleChanges,
+				Flags: append([]cli.Flag{
+					&cli.StringFlag{
+						Name:    "playertag",
+						Aliases: []string{"p"},
+						Usage:   "The tag of the player; defaults to all players",
+					},
+				}, rosterFlags...),
+			},
+		},
+	}
+)
+
+// recordRoster records a snapshot of the clan's members
+func recordRoster(c *cli.Context) error {
+	if err := requireFlags(c, "clantag"); err != nil {
+		return err
+	}
+	client, err := newClient(c)
+	if err != nil {
+		return err
+	}
+	tracker, err := roster.Open(c.String("file"))
+	if err != nil {
+		return err
+	}
+
+	tag := setting(c, "clantag")
+	members, _, err := client.GetClanMembers(tag)
+	if err != nil {
+		return err
+	}
+	if err := tracker.Record(tag, time.Now(), members); err != nil {
+		return err
+	}
+	fmt.Printf("Recorded %d members\n", len(members))
+
+	return nil
+}
+
+// diffRoster lists the players who joined and left the clan, and the role changes of its members
+func diffRoster(c *cli.Context) error {
+	if err := requireFlags(c, "clantag", "since"); err != nil {
+		return err
+	}
+	since, err := parseTime(c.String("since"))
+	if err != nil {
+		return cli.Exit(err, exitUsage)
+	}
+	until, err := parseTime(c.String("until"))
+	if err != nil {
+		return cli.Exit(err, exitUsage)
+	}
+	tracker, err := roster.Open(c.String("file"))
+	if err != nil {
+		return err
+	}
+
+	return printResult(c, tracker.Changes(setting(c, "clantag"), since, until))
+}
+
+// listMemberships lists the membership history of each player
+func listMemberships(c *cli.Context) error {
+	if err := requireFlags(c, "clantag"); err != nil {
+		return err
+	}
+	tracker, err := roster.Open(c.String("file"))
+	if err != nil {
+		return err
+	}
+
+	return printResult(c, tracker.Memberships(setting(c, "clantag"), time.Now()))
+}
+
+// listRoleChanges lists the role changes of the clan's members
+func listRoleChanges(c *cli.Context) error {
+	if err := requireFlags(c, "clantag"); err != nil {
+		return err
+	}
+	tracker, err := roster.Open(c.String("file"))
+	if err != nil {
+		return err
+	}
+
+	return printResult(c, tracker.RoleChanges(setting(c, "clantag"), c.String("playertag")))
+}
+
+// parseTime parses either a RFC3339 timestamp or a duration before now. An empty string is
+// the current time.
+func parseTime(s string) (time.Time, error) {
+	if s == "" {
+		return time.Now(), nil
+	}
+	if d, err := time.ParseDuration(s); err == nil {
+		return time.Now().Add(-d), nil
+	}
+	return time.Parse(time.RFC3339, s)
+}
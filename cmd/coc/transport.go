@@ -0,0 +1,187 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httputil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/rbrabson/coc/pkg/rest"
+)
+
+// tokenTransport sends requests using each of a list of API tokens in turn. API tokens are
+// restricted to a set of IP addresses, so when the server rejects a token because of the
+// client's IP address the request is retried with the next one, and the accepted token is
+// used for later requests. Other responses, including other denials, are returned as is.
+type tokenTransport struct {
+	mu     sync.Mutex
+	tokens []string
+	next   int
+	base   http.RoundTripper
+}
+
+// RoundTrip sends the request, trying each token until one is accepted from the client's IP
+// address
+func (t *tokenTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.mu.Lock()
+	start := t.next
+	t.mu.Unlock()
+
+	var resp *http.Response
+	for i := 0; i < len(t.tokens); i++ {
+		n := (start + i) % len(t.tokens)
+		attempt := req.Clone(req.Context())
+		if req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			attempt.Body = body
+		}
+		attempt.Header.Set("Authorization", "Bearer "+t.tokens[n])
+
+		var err error
+		resp, err = t.base.RoundTrip(attempt)
+		if err != nil {
+			return nil, err
+		}
+		if resp.StatusCode != http.StatusForbidden {
+			t.mu.Lock()
+			t.next = n
+			t.mu.Unlock()
+			break
+		}
+		invalidIP, err := isInvalidIP(resp)
+		if err != nil {
+			return nil, err
+		}
+		if !invalidIP || i == len(t.tokens)-1 {
+			break
+		}
+		resp.Body.Close()
+	}
+
+	return resp, nil
+}
+
+// isInvalidIP returns an indication as to whether the response rejected the token because of
+// the client's IP address. The response body is read and replaced, so it may still be read by
+// the caller.
+func isInvalidIP(resp *http.Response) (bool, error) {
+	b, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return false, err
+	}
+	resp.Body = ioutil.NopCloser(bytes.NewReader(b))
+
+	reason, _ := rest.ParseClientError(b)
+	return reason == rest.ReasonInvalidIP, nil
+}
+
+// cacheTransport caches successful responses to GET requests in a directory, so repeated
+// commands don't need to contact the server. Responses are cached for the TTL, or if it is
+// zero, for as long as the server's Cache-Control header allows.
+type cacheTransport struct {
+	dir  string
+	ttl  time.Duration
+	base http.RoundTripper
+}
+
+// RoundTrip returns the cached response for the request if it hasn't expired, and otherwise
+// sends the request and caches the response
+func (t *cacheTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method != http.MethodGet {
+		return t.base.RoundTrip(req)
+	}
+
+	path := t.path(req)
+	if resp, ok := t.load(path, req); ok {
+		return resp, nil
+	}
+
+	resp, err := t.base.RoundTrip(req)
+	if err != nil || resp.StatusCode != http.StatusOK {
+		return resp, err
+	}
+	ttl := t.ttl
+	if ttl <= 0 {
+		ttl, _ = rest.MaxAge(resp.Header)
+	}
+	if ttl <= 0 {
+		return resp, nil
+	}
+
+	// Read the response so it can be both cached and returned
+	dump, err := httputil.DumpResponse(resp, true)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	t.store(path, time.Now().Add(ttl), dump)
+
+	return http.ReadResponse(bufio.NewReader(bytes.NewReader(dump)), req)
+}
+
+// path returns the file in which the response to the request is cached
+func (t *cacheTransport) path(req *http.Request) string {
+	sum := sha256.Sum256([]byte(req.URL.String()))
+	return filepath.Join(t.dir, hex.EncodeToString(sum[:]))
+}
+
+// load returns the cached response in the file, if it exists and hasn't expired. Each file
+// holds the expiry time on the first line, followed by the response.
+func (t *cacheTransport) load(path string, req *http.Request) (*http.Response, bool) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, false
+	}
+	i := bytes.IndexByte(b, '\n')
+	if i < 0 {
+		return nil, false
+	}
+	expires, err := strconv.ParseInt(string(b[:i]), 10, 64)
+	if err != nil || time.Now().Unix() >= expires {
+		os.Remove(path)
+		return nil, false
+	}
+	resp, err := http.ReadResponse(bufio.NewReader(bytes.NewReader(b[i+1:])), req)
+	if err != nil {
+		return nil, false
+	}
+	// The response is only cached for the remainder of its original lifetime
+	resp.Header.Set("Cache-Control", "max-age="+strconv.FormatInt(expires-time.Now().Unix(), 10))
+	return resp, true
+}
+
+// store caches the response in the file until it expires. Failures are ignored, as the
+// response is simply requested again.
+func (t *cacheTransport) store(path string, expires time.Time, dump []byte) {
+	if err := os.MkdirAll(t.dir, 0700); err != nil {
+		return
+	}
+	f, err := ioutil.TempFile(t.dir, ".tmp-*")
+	if err != nil {
+		return
+	}
+	defer os.Remove(f.Name())
+	_, err = io.WriteString(f, strconv.FormatInt(expires.Unix(), 10)+"\n")
+	if err == nil {
+		_, err = f.Write(dump)
+	}
+	if err := f.Close(); err != nil {
+		return
+	}
+	if err == nil {
+		os.Rename(f.Name(), path)
+	}
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestTokenTransport(t *testing.T) {
+	invalidIP := `{"reason":"accessDenied.invalidIp","message":"Invalid authorization: API key does not allow access from IP 10.0.0.1"}`
+	accessDenied := `{"reason":"accessDenied","message":"Invalid authorization"}`
+	tests := []struct {
+		name       string
+		next       int
+		responses  map[string]string
+		wantTokens []string
+		wantStatus int
+		wantBody   string
+		wantNext   int
+	}{
+		{
+			name:       "first token accepted",
+			responses:  map[string]string{},
+			wantTokens: []string{"a"},
+			wantStatus: http.StatusOK,
+			wantNext:   0,
+		},
+		{
+			name:       "rotates past tokens for other IP addresses",
+			responses:  map[string]string{"a": invalidIP, "b": invalidIP},
+			wantTokens: []string{"a", "b", "c"},
+			wantStatus: http.StatusOK,
+			wantNext:   2,
+		},
+		{
+			name:       "starts with the last accepted token",
+			next:       1,
+			responses:  map[string]string{"a": invalidIP},
+			wantTokens: []string{"b"},
+			wantStatus: http.StatusOK,
+			wantNext:   1,
+		},
+		{
+			name:       "other denials are returned",
+			next:       1,
+			responses:  map[string]string{"b": accessDenied},
+			wantTokens: []string{"b"},
+			wantStatus: http.StatusForbidden,
+			wantBody:   accessDenied,
+			wantNext:   1,
+		},
+		{
+			name:       "no token accepted",
+			next:       1,
+			responses:  map[string]string{"a": invalidIP, "b": invalidIP, "c": invalidIP},
+			wantTokens: []string{"b", "c", "a"},
+			wantStatus: http.StatusForbidden,
+			wantBody:   invalidIP,
+			wantNext:   1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var tokens []string
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+				tokens = append(tokens, token)
+				if body, ok := tt.responses[token]; ok {
+					w.WriteHeader(http.StatusForbidden)
+					w.Write([]byte(body))
+					return
+				}
+				w.Write([]byte(`{}`))
+			}))
+			defer srv.Close()
+
+			transport := &tokenTransport{tokens: []string{"a", "b", "c"}, next: tt.next, base: http.DefaultTransport}
+			client := &http.Client{Transport: transport}
+			resp, err := client.Get(srv.URL)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer resp.Body.Close()
+			body, err := ioutil.ReadAll(resp.Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if resp.StatusCode != tt.wantStatus {
+				t.Errorf("RoundTrip() status = %d, want %d", resp.StatusCode, tt.wantStatus)
+			}
+			if tt.wantBody != "" && string(body) != tt.wantBody {
+				t.Errorf("RoundTrip() body = %s, want %s", body, tt.wantBody)
+			}
+			if !reflect.DeepEqual(tokens, tt.wantTokens) {
+				t.Errorf("RoundTrip() tried tokens %v, want %v", tokens, tt.wantTokens)
+			}
+			if transport.next != tt.wantNext {
+				t.Errorf("RoundTrip() next token = %d, want %d", transport.next, tt.wantNext)
+			}
+		})
+	}
+}
@@ -5,6 +5,7 @@ go 1.17
 require (
 	github.com/urfave/cli/v2 v2.3.0
 	go.uber.org/zap v1.20.0
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
@@ -12,5 +13,4 @@ require (
 	github.com/russross/blackfriday/v2 v2.1.0 // indirect
 	go.uber.org/atomic v1.9.0 // indirect
 	go.uber.org/multierr v1.7.0 // indirect
-	gopkg.in/yaml.v3 v3.0.1 // indirect
 )
@@ -0,0 +1,212 @@
+package analytics
+
+import (
+	"encoding/json"
+	"sort"
+
+	"github.com/rbrabson/coc/v1"
+)
+
+const (
+	// raidAttackLimit is the number of attacks each clan member may make during a raid weekend,
+	// not including the bonus attack earned by destroying a district
+	raidAttackLimit = 5
+)
+
+// RaidMemberStats are the statistics for a member's attacks during a raid weekend.
+type RaidMemberStats struct {
+	Tag           string  `json:"tag"`
+	Name          string  `json:"name"`
+	Attacks       int     `json:"attacks"`
+	AttackLimit   int     `json:"attackLimit"`
+	UnusedAttacks int     `json:"unusedAttacks"`
+	Looted        int     `json:"looted"`
+	LootPerAttack float64 `json:"lootPerAttack"`
+}
+
+// String returns a string representation of raid member statistics
+func (m RaidMemberStats) String() string {
+	b, _ := json.Marshal(m)
+	return string(b)
+}
+
+// RaidDistrictStats are the statistics for an enemy district attacked during a raid weekend.
+type RaidDistrictStats struct {
+	ClanTag            string `json:"clanTag"`
+	ClanName           string `json:"clanName"`
+	ID                 int    `json:"id"`
+	Name               string `json:"name"`
+	DistrictHallLevel  int    `json:"districtHallLevel"`
+	Attacks            int    `json:"attacks"`
+	DestructionPercent int    `json:"destructionPercent"`
+	Looted             int    `json:"looted"`
+}
+
+// String returns a string representation of raid district statistics
+func (d RaidDistrictStats) String() string {
+	b, _ := json.Marshal(d)
+	return string(b)
+}
+
+// RaidWeekendReport is the analysis of a clan's capital raid weekend.
+type RaidWeekendReport struct {
+	State              string              `json:"state"`
+	StartTime          coc.Time            `json:"startTime"`
+	EndTime            coc.Time            `json:"endTime"`
+	TotalLoot          int                 `json:"totalLoot"`
+	TotalAttacks       int                 `json:"totalAttacks"`
+	RaidsCompleted     int                 `json:"raidsCompleted"`
+	DistrictsDestroyed int                 `json:"districtsDestroyed"`
+	OffensiveReward    int                 `json:"offensiveReward"`
+	DefensiveReward    int                 `json:"defensiveReward"`
+	LootPerAttack      float64             `json:"lootPerAttack"`
+	AttacksAvailable   int                 `json:"attacksAvailable"`
+	UnusedAttacks      int                 `json:"unusedAttacks"`
+	Members            []RaidMemberStats   `json:"members"`
+	NonParticipants    []coc.ClanMember    `json:"nonParticipants,omitempty"`
+	Districts          []RaidDistrictStats `json:"districts"`
+}
+
+// String returns a string representation of a raid weekend report
+func (r RaidWeekendReport) String() string {
+	b, _ := json.Marshal(r)
+	return string(b)
+}
+
+// RaidTrend is a clan's rewards for a raid weekend, along with the change from the previous
+// raid weekend.
+type RaidTrend struct {
+	StartTime             coc.Time `json:"startTime"`
+	Participants          int      `json:"participants"`
+	TotalLoot             int      `json:"totalLoot"`
+	TotalAttacks          int      `json:"totalAttacks"`
+	OffensiveReward       int      `json:"offensiveReward"`
+	DefensiveReward       int      `json:"defensiveReward"`
+	OffensiveRewardChange int      `json:"offensiveRewardChange"`
+	DefensiveRewardChange int      `json:"defensiveRewardChange"`
+	TotalLootChange       int      `json:"totalLootChange"`
+}
+
+// String returns a string representation of a raid trend
+func (t RaidTrend) String() string {
+	b, _ := json.Marshal(t)
+	return string(b)
+}
+
+// AnalyzeRaidWeekend returns the analysis of a raid weekend, such as one returned by
+// coc.Client.ListCapitalRaidSeasons. The members are the clan's roster, such as those returned
+// by coc.Client.GetClanMembers. The server only lists the members who raided, so each member of
+// the roster who didn't is included with no attacks and the base attack limit, and is counted
+// towards the attacks available and unused; if the roster is nil, only those who raided are
+// included. Members are ordered by loot, and districts by the number of attacks needed against
+// them.
+func AnalyzeRaidWeekend(season coc.ClanCapitalRaidSeasion, members []coc.ClanMember) RaidWeekendReport {
+	report := RaidWeekendReport{
+		State:              season.State,
+		StartTime:          season.StartTime,
+		EndTime:            season.EndTime,
+		TotalLoot:          season.CapitalTotalLoot,
+		TotalAttacks:       season.TotalAttacks,
+		RaidsCompleted:     season.RaidsCompleted,
+		DistrictsDestroyed: season.EnemyDistrictsDestroyed,
+		OffensiveReward:    season.OffensiveReward,
+		DefensiveReward:    season.DefensiveReward,
+		Members:            make([]RaidMemberStats, 0, len(season.Members)),
+	}
+	if season.TotalAttacks > 0 {
+		report.LootPerAttack = float64(season.CapitalTotalLoot) / float64(season.TotalAttacks)
+	}
+
+	participants := make(map[string]bool, len(season.Members))
+	for _, m := range season.Members {
+		participants[m.Tag] = true
+		stats := RaidMemberStats{
+			Tag:         m.Tag,
+			Name:        m.Name,
+			Attacks:     m.Attacks,
+			AttackLimit: m.AttackLimit + m.BonusAttackLimit,
+			Looted:      m.CapitalResourcesLooted,
+		}
+		if stats.AttackLimit > stats.Attacks {
+			stats.UnusedAttacks = stats.AttackLimit - stats.Attacks
+		}
+		if m.Attacks > 0 {
+			stats.LootPerAttack = float64(m.CapitalResourcesLooted) / float64(m.Attacks)
+		}
+		report.AttacksAvailable += stats.AttackLimit
+		report.UnusedAttacks += stats.UnusedAttacks
+		report.Members = append(report.Members, stats)
+	}
+	for _, m := range members {
+		if participants[m.Tag] {
+			continue
+		}
+		participants[m.Tag] = true
+		report.NonParticipants = append(report.NonParticipants, m)
+		report.Members = append(report.Members, RaidMemberStats{
+			Tag:           m.Tag,
+			Name:          m.Name,
+			AttackLimit:   raidAttackLimit,
+			UnusedAttacks: raidAttackLimit,
+		})
+		report.AttacksAvailable += raidAttackLimit
+		report.UnusedAttacks += raidAttackLimit
+	}
+
+	sort.Slice(report.Members, func(i, j int) bool {
+		if report.Members[i].Looted != report.Members[j].Looted {
+			return report.Members[i].Looted > report.Members[j].Looted
+		}
+		return report.Members[i].Tag < report.Members[j].Tag
+	})
+
+	for _, entry := range season.AttackLog {
+		for _, d := range entry.Districts {
+			report.Districts = append(report.Districts, RaidDistrictStats{
+				ClanTag:            entry.Defender.Tag,
+				ClanName:           entry.Defender.Name,
+				ID:                 d.ID,
+				Name:               d.Name,
+				DistrictHallLevel:  d.DistrictHallLevel,
+				Attacks:            d.AttackCount,
+				DestructionPercent: d.DestructionPercent,
+				Looted:             d.TotalLooted,
+			})
+		}
+	}
+	sort.SliceStable(report.Districts, func(i, j int) bool {
+		return report.Districts[i].Attacks > report.Districts[j].Attacks
+	})
+
+	return report
+}
+
+// RaidTrends returns the rewards for each raid weekend, ordered from the oldest to the most
+// recent, along with the change in rewards from the previous weekend.
+func RaidTrends(seasons []coc.ClanCapitalRaidSeasion) []RaidTrend {
+	sorted := make([]coc.ClanCapitalRaidSeasion, len(seasons))
+	copy(sorted, seasons)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].StartTime.Before(sorted[j].StartTime)
+	})
+
+	trends := make([]RaidTrend, 0, len(sorted))
+	for i, s := range sorted {
+		t := RaidTrend{
+			StartTime:       s.StartTime,
+			Participants:    len(s.Members),
+			TotalLoot:       s.CapitalTotalLoot,
+			TotalAttacks:    s.TotalAttacks,
+			OffensiveReward: s.OffensiveReward,
+			DefensiveReward: s.DefensiveReward,
+		}
+		if i > 0 {
+			prev := sorted[i-1]
+			t.OffensiveRewardChange = s.OffensiveReward - prev.OffensiveReward
+			t.DefensiveRewardChange = s.DefensiveReward - prev.DefensiveReward
+			t.TotalLootChange = s.CapitalTotalLoot - prev.CapitalTotalLoot
+		}
+		trends = append(trends, t)
+	}
+	return trends
+}
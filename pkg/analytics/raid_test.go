@@ -0,0 +1,124 @@
+package analytics
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/rbrabson/coc/v1"
+)
+
+// raidSeason returns a raid weekend in which #PA used all six of their attacks and #PB used
+// three of their five
+func raidSeason() coc.ClanCapitalRaidSeasion {
+	return coc.ClanCapitalRaidSeasion{
+		State:            "ended",
+		CapitalTotalLoot: 29000,
+		TotalAttacks:     9,
+		Members: []coc.ClanCapitalMember{
+			{Tag: "#PB", Name: "B", Attacks: 3, AttackLimit: 5, CapitalResourcesLooted: 9000},
+			{Tag: "#PA", Name: "A", Attacks: 6, AttackLimit: 5, BonusAttackLimit: 1, CapitalResourcesLooted: 20000},
+		},
+		AttackLog: []coc.ClanCapitalAttackLogEntry{
+			{
+				Defender: coc.ClanCapitalDefender{Tag: "#8QU", Name: "Opponent"},
+				Districts: []coc.ClanCapitalDistrict{
+					{ID: 70000000, Name: "Capital Peak", AttackCount: 4, DestructionPercent: 100, TotalLooted: 15000},
+					{ID: 70000001, Name: "Barbarian Camp", AttackCount: 5, DestructionPercent: 100, TotalLooted: 14000},
+				},
+			},
+		},
+	}
+}
+
+func TestAnalyzeRaidWeekendMembers(t *testing.T) {
+	pa := RaidMemberStats{Tag: "#PA", Name: "A", Attacks: 6, AttackLimit: 6, Looted: 20000, LootPerAttack: 20000.0 / 6}
+	pb := RaidMemberStats{Tag: "#PB", Name: "B", Attacks: 3, AttackLimit: 5, UnusedAttacks: 2, Looted: 9000, LootPerAttack: 3000}
+	pc := RaidMemberStats{Tag: "#PC", Name: "C", AttackLimit: 5, UnusedAttacks: 5}
+	pd := RaidMemberStats{Tag: "#PD", Name: "D", AttackLimit: 5, UnusedAttacks: 5}
+	tests := []struct {
+		name                string
+		roster              []coc.ClanMember
+		wantMembers         []RaidMemberStats
+		wantAvailable       int
+		wantUnused          int
+		wantNonParticipants []string
+	}{
+		{
+			name:          "no roster",
+			wantMembers:   []RaidMemberStats{pa, pb},
+			wantAvailable: 11,
+			wantUnused:    2,
+		},
+		{
+			name:          "everyone raided",
+			roster:        []coc.ClanMember{{Tag: "#PA", Name: "A"}, {Tag: "#PB", Name: "B"}},
+			wantMembers:   []RaidMemberStats{pa, pb},
+			wantAvailable: 11,
+			wantUnused:    2,
+		},
+		{
+			name:                "members who didn't raid",
+			roster:              []coc.ClanMember{{Tag: "#PD", Name: "D"}, {Tag: "#PA", Name: "A"}, {Tag: "#PC", Name: "C"}, {Tag: "#PB", Name: "B"}},
+			wantMembers:         []RaidMemberStats{pa, pb, pc, pd},
+			wantAvailable:       21,
+			wantUnused:          12,
+			wantNonParticipants: []string{"#PD", "#PC"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report := AnalyzeRaidWeekend(raidSeason(), tt.roster)
+			if !reflect.DeepEqual(report.Members, tt.wantMembers) {
+				t.Errorf("AnalyzeRaidWeekend() members = %v, want %v", report.Members, tt.wantMembers)
+			}
+			if report.AttacksAvailable != tt.wantAvailable || report.UnusedAttacks != tt.wantUnused {
+				t.Errorf("AnalyzeRaidWeekend() attacks available = %d, unused = %d, want %d, %d",
+					report.AttacksAvailable, report.UnusedAttacks, tt.wantAvailable, tt.wantUnused)
+			}
+			var nonParticipants []string
+			for _, m := range report.NonParticipants {
+				nonParticipants = append(nonParticipants, m.Tag)
+			}
+			if !reflect.DeepEqual(nonParticipants, tt.wantNonParticipants) {
+				t.Errorf("AnalyzeRaidWeekend() non-participants = %v, want %v", nonParticipants, tt.wantNonParticipants)
+			}
+		})
+	}
+}
+
+func TestAnalyzeRaidWeekendTotals(t *testing.T) {
+	report := AnalyzeRaidWeekend(raidSeason(), nil)
+	if report.TotalLoot != 29000 || report.TotalAttacks != 9 || report.LootPerAttack != 29000.0/9 {
+		t.Errorf("AnalyzeRaidWeekend() loot = %d over %d attacks (%v per attack)", report.TotalLoot, report.TotalAttacks, report.LootPerAttack)
+	}
+	var districts []string
+	for _, d := range report.Districts {
+		districts = append(districts, d.Name)
+	}
+	if want := []string{"Barbarian Camp", "Capital Peak"}; !reflect.DeepEqual(districts, want) {
+		t.Errorf("AnalyzeRaidWeekend() districts = %v, want %v", districts, want)
+	}
+}
+
+func TestRaidTrends(t *testing.T) {
+	start := time.Date(2024, 3, 1, 7, 0, 0, 0, time.UTC)
+	season := func(weeks, loot, offensive, defensive int) coc.ClanCapitalRaidSeasion {
+		return coc.ClanCapitalRaidSeasion{
+			StartTime:        coc.NewTime(start.AddDate(0, 0, 7*weeks)),
+			CapitalTotalLoot: loot,
+			OffensiveReward:  offensive,
+			DefensiveReward:  defensive,
+			Members:          make([]coc.ClanCapitalMember, weeks+1),
+		}
+	}
+	trends := RaidTrends([]coc.ClanCapitalRaidSeasion{season(1, 900, 700, 300), season(0, 1000, 600, 350)})
+	want := []RaidTrend{
+		{StartTime: coc.NewTime(start), Participants: 1, TotalLoot: 1000, OffensiveReward: 600, DefensiveReward: 350},
+		{StartTime: coc.NewTime(start.AddDate(0, 0, 7)), Participants: 2, TotalLoot: 900, OffensiveReward: 700, DefensiveReward: 300,
+			OffensiveRewardChange: 100, DefensiveRewardChange: -50, TotalLootChange: -100},
+	}
+	if !reflect.DeepEqual(trends, want) {
+		t.Errorf("RaidTrends() = %v, want %v", trends, want)
+	}
+}
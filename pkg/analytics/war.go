@@ -0,0 +1,286 @@
+package analytics
+
+import (
+	"encoding/json"
+	"sort"
+
+	"github.com/rbrabson/coc/v1"
+)
+
+const (
+	maxStars = 3
+)
+
+// HitStats are the statistics for a set of attacks made in clan wars.
+type HitStats struct {
+	Attacks             int `json:"attacks"`
+	Triples             int `json:"triples"`
+	Stars               int `json:"stars"`
+	Destruction         int `json:"destruction"`
+	ZeroStarAttacks     int `json:"zeroStarAttacks"`
+	OneStarAttacks      int `json:"oneStarAttacks"`
+	TwoStarAttacks      int `json:"twoStarAttacks"`
+	DurationSeconds     int `json:"durationSeconds"`
+	AttacksWithDuration int `json:"attacksWithDuration"`
+}
+
+// String returns a string representation of hit statistics
+func (h HitStats) String() string {
+	b, _ := json.Marshal(h)
+	return string(b)
+}
+
+// TripleRate returns the fraction of attacks that earned three stars.
+func (h HitStats) TripleRate() float64 {
+	if h.Attacks == 0 {
+		return 0
+	}
+	return float64(h.Triples) / float64(h.Attacks)
+}
+
+// AverageStars returns the average number of stars earned per attack.
+func (h HitStats) AverageStars() float64 {
+	if h.Attacks == 0 {
+		return 0
+	}
+	return float64(h.Stars) / float64(h.Attacks)
+}
+
+// AverageDestruction returns the average destruction percentage per attack.
+func (h HitStats) AverageDestruction() float64 {
+	if h.Attacks == 0 {
+		return 0
+	}
+	return float64(h.Destruction) / float64(h.Attacks)
+}
+
+// AverageDuration returns the average length of an attack, in seconds, for those attacks that
+// include the duration.
+func (h HitStats) AverageDuration() float64 {
+	if h.AttacksWithDuration == 0 {
+		return 0
+	}
+	return float64(h.DurationSeconds) / float64(h.AttacksWithDuration)
+}
+
+// add includes the attack in the statistics
+func (h *HitStats) add(attack coc.ClanWarAttack) {
+	h.Attacks++
+	h.Stars += attack.Stars
+	h.Destruction += attack.DestructionPercentage
+	switch attack.Stars {
+	case 0:
+		h.ZeroStarAttacks++
+	case 1:
+		h.OneStarAttacks++
+	case 2:
+		h.TwoStarAttacks++
+	case maxStars:
+		h.Triples++
+	}
+	if attack.Duration > 0 {
+		h.DurationSeconds += attack.Duration
+		h.AttacksWithDuration++
+	}
+}
+
+// Matchup is an attacker's town hall level against a defender's town hall level.
+type Matchup struct {
+	AttackerTownHall int `json:"attackerTownHall"`
+	DefenderTownHall int `json:"defenderTownHall"`
+}
+
+// MatchupStats are the statistics for all attacks made for a given town hall matchup.
+type MatchupStats struct {
+	Matchup
+	HitStats
+}
+
+// String returns a string representation of matchup statistics
+func (m MatchupStats) String() string {
+	b, _ := json.Marshal(m)
+	return string(b)
+}
+
+// MemberStats are the statistics for all attacks made by a clan member.
+type MemberStats struct {
+	Tag           string   `json:"tag"`
+	Name          string   `json:"name"`
+	TownHallLevel int      `json:"townHallLevel"`
+	Wars          int      `json:"wars"`
+	AttacksMissed int      `json:"attacksMissed"`
+	Overall       HitStats `json:"overall"`
+	FirstHits     HitStats `json:"firstHits"`
+	Cleanups      HitStats `json:"cleanups"`
+}
+
+// String returns a string representation of member statistics
+func (m MemberStats) String() string {
+	b, _ := json.Marshal(m)
+	return string(b)
+}
+
+// WarStats are the attack statistics for a clan across one or more clan wars.
+type WarStats struct {
+	ClanTag   string         `json:"clanTag"`
+	Wars      int            `json:"wars"`
+	Overall   HitStats       `json:"overall"`
+	FirstHits HitStats       `json:"firstHits"`
+	Cleanups  HitStats       `json:"cleanups"`
+	Matchups  []MatchupStats `json:"matchups"`
+	Members   []MemberStats  `json:"members"`
+}
+
+// String returns a string representation of war statistics
+func (s WarStats) String() string {
+	b, _ := json.Marshal(s)
+	return string(b)
+}
+
+// Matchup returns the statistics for the given town hall matchup.
+func (s WarStats) Matchup(attackerTownHall, defenderTownHall int) HitStats {
+	for _, m := range s.Matchups {
+		if m.AttackerTownHall == attackerTownHall && m.DefenderTownHall == defenderTownHall {
+			return m.HitStats
+		}
+	}
+	return HitStats{}
+}
+
+// Analyzer accumulates attack statistics for a clan across a set of clan wars.
+type Analyzer struct {
+	clanTag  string
+	stats    WarStats
+	matchups map[Matchup]*HitStats
+	members  map[string]*MemberStats
+}
+
+// NewAnalyzer creates an analyzer for the attacks made by the clan with the given tag.
+func NewAnalyzer(clanTag string) *Analyzer {
+	clanTag = coc.NormalizeTag(clanTag)
+	return &Analyzer{
+		clanTag:  clanTag,
+		stats:    WarStats{ClanTag: clanTag},
+		matchups: make(map[Matchup]*HitStats),
+		members:  make(map[string]*MemberStats),
+	}
+}
+
+// AddWar includes the attacks made by the clan in the war in the statistics. False is returned
+// if the clan didn't participate in the war. Wars that are still in preparation are ignored.
+func (a *Analyzer) AddWar(war coc.ClanWar) bool {
+	war, ok := war.ForClan(a.clanTag)
+	if !ok {
+		return false
+	}
+	if war.State == string(coc.WarPhasePreparation) {
+		return true
+	}
+	a.stats.Wars++
+
+	// Determine the order in which each defender was first attacked, so that attacks can be
+	// classified as first hits or cleanups.
+	opponents := make(map[string]coc.ClanWarMember, len(war.Opponent.Members))
+	for _, opponent := range war.Opponent.Members {
+		opponents[opponent.Tag] = opponent
+	}
+	firstHit := make(map[string]int)
+	for _, member := range war.Clan.Members {
+		for _, attack := range member.Attacks {
+			if order, ok := firstHit[attack.DefenderTag]; !ok || attack.Order < order {
+				firstHit[attack.DefenderTag] = attack.Order
+			}
+		}
+	}
+
+	for _, member := range war.Clan.Members {
+		ms, ok := a.members[member.Tag]
+		if !ok {
+			ms = &MemberStats{Tag: member.Tag}
+			a.members[member.Tag] = ms
+		}
+		ms.Name = member.Name
+		if member.TownhallLevel > ms.TownHallLevel {
+			ms.TownHallLevel = member.TownhallLevel
+		}
+		ms.Wars++
+		if war.State == string(coc.WarPhaseEnded) {
+			if missed := war.MaxAttacksPerMember() - len(member.Attacks); missed > 0 {
+				ms.AttacksMissed += missed
+			}
+		}
+
+		for _, attack := range member.Attacks {
+			a.stats.Overall.add(attack)
+			ms.Overall.add(attack)
+			if firstHit[attack.DefenderTag] == attack.Order {
+				a.stats.FirstHits.add(attack)
+				ms.FirstHits.add(attack)
+			} else {
+				a.stats.Cleanups.add(attack)
+				ms.Cleanups.add(attack)
+			}
+
+			if defender, ok := opponents[attack.DefenderTag]; ok {
+				m := Matchup{AttackerTownHall: member.TownhallLevel, DefenderTownHall: defender.TownhallLevel}
+				hs, ok := a.matchups[m]
+				if !ok {
+					hs = &HitStats{}
+					a.matchups[m] = hs
+				}
+				hs.add(attack)
+			}
+		}
+	}
+
+	return true
+}
+
+// Stats returns the statistics for all wars added to the analyzer. Matchups are ordered by
+// attacker and then defender town hall level, and members by the number of stars earned.
+func (a *Analyzer) Stats() WarStats {
+	stats := a.stats
+
+	stats.Matchups = make([]MatchupStats, 0, len(a.matchups))
+	for m, hs := range a.matchups {
+		stats.Matchups = append(stats.Matchups, MatchupStats{Matchup: m, HitStats: *hs})
+	}
+	sort.Slice(stats.Matchups, func(i, j int) bool {
+		mi, mj := stats.Matchups[i], stats.Matchups[j]
+		if mi.AttackerTownHall != mj.AttackerTownHall {
+			return mi.AttackerTownHall > mj.AttackerTownHall
+		}
+		return mi.DefenderTownHall > mj.DefenderTownHall
+	})
+
+	stats.Members = make([]MemberStats, 0, len(a.members))
+	for _, ms := range a.members {
+		stats.Members = append(stats.Members, *ms)
+	}
+	sort.Slice(stats.Members, func(i, j int) bool {
+		mi, mj := stats.Members[i], stats.Members[j]
+		if mi.Overall.Stars != mj.Overall.Stars {
+			return mi.Overall.Stars > mj.Overall.Stars
+		}
+		return mi.Tag < mj.Tag
+	})
+
+	return stats
+}
+
+// AnalyzeWar returns the attack statistics for the clan that is the war's Clan.
+func AnalyzeWar(war coc.ClanWar) WarStats {
+	a := NewAnalyzer(war.Clan.Tag)
+	a.AddWar(war)
+	return a.Stats()
+}
+
+// AnalyzeWars returns the attack statistics for the clan with the given tag across the wars.
+// Wars in which the clan didn't participate are ignored.
+func AnalyzeWars(clanTag string, wars []coc.ClanWar) WarStats {
+	a := NewAnalyzer(clanTag)
+	for _, war := range wars {
+		a.AddWar(war)
+	}
+	return a.Stats()
+}
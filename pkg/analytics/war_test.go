@@ -0,0 +1,191 @@
+package analytics
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/rbrabson/coc/v1"
+)
+
+// warAttack returns an attack on the defender
+func warAttack(order int, defenderTag string, stars, destruction, duration int) coc.ClanWarAttack {
+	return coc.ClanWarAttack{Order: order, DefenderTag: defenderTag, Stars: stars, DestructionPercentage: destruction, Duration: duration}
+}
+
+// newWar returns an ended war between #2PP and #8QU. #OA is first hit by #PA and cleaned up by
+// #PB, and #OB is first hit by #PB and cleaned up by #PA. #PC doesn't attack.
+func newWar() coc.ClanWar {
+	return coc.ClanWar{
+		State:            string(coc.WarPhaseEnded),
+		AttacksPerMember: 2,
+		Clan: coc.ClanWarTeam{
+			Tag: "#2PP",
+			Members: []coc.ClanWarMember{
+				{Tag: "#PA", Name: "A", TownhallLevel: 15, Attacks: []coc.ClanWarAttack{
+					warAttack(1, "#OA", 2, 90, 120),
+					warAttack(4, "#OB", 3, 100, 100),
+				}},
+				{Tag: "#PB", Name: "B", TownhallLevel: 14, Attacks: []coc.ClanWarAttack{
+					warAttack(2, "#OB", 1, 60, 0),
+					warAttack(3, "#OA", 3, 100, 150),
+				}},
+				{Tag: "#PC", Name: "C", TownhallLevel: 13},
+			},
+		},
+		Opponent: coc.ClanWarTeam{
+			Tag: "#8QU",
+			Members: []coc.ClanWarMember{
+				{Tag: "#OA", TownhallLevel: 15},
+				{Tag: "#OB", TownhallLevel: 14},
+			},
+		},
+	}
+}
+
+func TestAnalyzeWars(t *testing.T) {
+	firstHits := HitStats{Attacks: 2, Stars: 3, Destruction: 150, OneStarAttacks: 1, TwoStarAttacks: 1, DurationSeconds: 120, AttacksWithDuration: 1}
+	cleanups := HitStats{Attacks: 2, Triples: 2, Stars: 6, Destruction: 200, DurationSeconds: 250, AttacksWithDuration: 2}
+	overall := HitStats{Attacks: 4, Triples: 2, Stars: 9, Destruction: 350, OneStarAttacks: 1, TwoStarAttacks: 1, DurationSeconds: 370, AttacksWithDuration: 3}
+	want := WarStats{
+		ClanTag:   "#2PP",
+		Wars:      1,
+		Overall:   overall,
+		FirstHits: firstHits,
+		Cleanups:  cleanups,
+		Matchups: []MatchupStats{
+			{Matchup{15, 15}, HitStats{Attacks: 1, Stars: 2, Destruction: 90, TwoStarAttacks: 1, DurationSeconds: 120, AttacksWithDuration: 1}},
+			{Matchup{15, 14}, HitStats{Attacks: 1, Triples: 1, Stars: 3, Destruction: 100, DurationSeconds: 100, AttacksWithDuration: 1}},
+			{Matchup{14, 15}, HitStats{Attacks: 1, Triples: 1, Stars: 3, Destruction: 100, DurationSeconds: 150, AttacksWithDuration: 1}},
+			{Matchup{14, 14}, HitStats{Attacks: 1, Stars: 1, Destruction: 60, OneStarAttacks: 1}},
+		},
+		Members: []MemberStats{
+			{
+				Tag: "#PA", Name: "A", TownHallLevel: 15, Wars: 1,
+				Overall:   HitStats{Attacks: 2, Triples: 1, Stars: 5, Destruction: 190, TwoStarAttacks: 1, DurationSeconds: 220, AttacksWithDuration: 2},
+				FirstHits: HitStats{Attacks: 1, Stars: 2, Destruction: 90, TwoStarAttacks: 1, DurationSeconds: 120, AttacksWithDuration: 1},
+				Cleanups:  HitStats{Attacks: 1, Triples: 1, Stars: 3, Destruction: 100, DurationSeconds: 100, AttacksWithDuration: 1},
+			},
+			{
+				Tag: "#PB", Name: "B", TownHallLevel: 14, Wars: 1,
+				Overall:   HitStats{Attacks: 2, Triples: 1, Stars: 4, Destruction: 160, OneStarAttacks: 1, DurationSeconds: 150, AttacksWithDuration: 1},
+				FirstHits: HitStats{Attacks: 1, Stars: 1, Destruction: 60, OneStarAttacks: 1},
+				Cleanups:  HitStats{Attacks: 1, Triples: 1, Stars: 3, Destruction: 100, DurationSeconds: 150, AttacksWithDuration: 1},
+			},
+			{Tag: "#PC", Name: "C", TownHallLevel: 13, Wars: 1, AttacksMissed: 2},
+		},
+	}
+
+	// The same war seen from the opponent's side
+	reversed := newWar()
+	reversed.Clan, reversed.Opponent = reversed.Opponent, reversed.Clan
+
+	preparation := newWar()
+	preparation.State = string(coc.WarPhasePreparation)
+
+	other := newWar()
+	other.Clan.Tag = "#9LL"
+
+	tests := []struct {
+		name string
+		wars []coc.ClanWar
+	}{
+		{"clan", []coc.ClanWar{newWar()}},
+		{"opponent", []coc.ClanWar{reversed}},
+		{"preparation and other clans ignored", []coc.ClanWar{preparation, newWar(), other}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := AnalyzeWars("#2PP", tt.wars)
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("AnalyzeWars() = %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestAnalyzerAddWar(t *testing.T) {
+	inWar := newWar()
+	inWar.State = string(coc.WarPhaseInWar)
+	tests := []struct {
+		name        string
+		war         coc.ClanWar
+		want        bool
+		wantWars    int
+		wantMissed  int
+		wantAttacks int
+	}{
+		{"ended", newWar(), true, 1, 2, 4},
+		{"in war", inWar, true, 1, 0, 4},
+		{"other clan", coc.ClanWar{Clan: coc.ClanWarTeam{Tag: "#9LL"}, Opponent: coc.ClanWarTeam{Tag: "#CYY"}}, false, 0, 0, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := NewAnalyzer("2pp")
+			if got := a.AddWar(tt.war); got != tt.want {
+				t.Errorf("AddWar() = %v, want %v", got, tt.want)
+			}
+			stats := a.Stats()
+			missed := 0
+			for _, m := range stats.Members {
+				missed += m.AttacksMissed
+			}
+			if stats.Wars != tt.wantWars || missed != tt.wantMissed || stats.Overall.Attacks != tt.wantAttacks {
+				t.Errorf("Stats() = %d wars, %d missed, %d attacks, want %d, %d, %d",
+					stats.Wars, missed, stats.Overall.Attacks, tt.wantWars, tt.wantMissed, tt.wantAttacks)
+			}
+		})
+	}
+}
+
+func TestHitStatsRates(t *testing.T) {
+	tests := []struct {
+		name            string
+		stats           HitStats
+		wantTripleRate  float64
+		wantStars       float64
+		wantDestruction float64
+		wantDuration    float64
+	}{
+		{"no attacks", HitStats{}, 0, 0, 0, 0},
+		{
+			name:            "attacks",
+			stats:           HitStats{Attacks: 4, Triples: 2, Stars: 9, Destruction: 350, DurationSeconds: 360, AttacksWithDuration: 3},
+			wantTripleRate:  0.5,
+			wantStars:       2.25,
+			wantDestruction: 87.5,
+			wantDuration:    120,
+		},
+		{
+			name:            "no durations",
+			stats:           HitStats{Attacks: 2, Stars: 2, Destruction: 100},
+			wantStars:       1,
+			wantDestruction: 50,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.stats.TripleRate(); got != tt.wantTripleRate {
+				t.Errorf("TripleRate() = %v, want %v", got, tt.wantTripleRate)
+			}
+			if got := tt.stats.AverageStars(); got != tt.wantStars {
+				t.Errorf("AverageStars() = %v, want %v", got, tt.wantStars)
+			}
+			if got := tt.stats.AverageDestruction(); got != tt.wantDestruction {
+				t.Errorf("AverageDestruction() = %v, want %v", got, tt.wantDestruction)
+			}
+			if got := tt.stats.AverageDuration(); got != tt.wantDuration {
+				t.Errorf("AverageDuration() = %v, want %v", got, tt.wantDuration)
+			}
+		})
+	}
+}
+
+func TestWarStatsMatchup(t *testing.T) {
+	stats := AnalyzeWar(newWar())
+	if got := stats.Matchup(14, 15); got.Triples != 1 || got.Attacks != 1 {
+		t.Errorf("Matchup(14, 15) = %v, want one triple", got)
+	}
+	if got := stats.Matchup(13, 15); !reflect.DeepEqual(got, HitStats{}) {
+		t.Errorf("Matchup(13, 15) = %v, want no attacks", got)
+	}
+}
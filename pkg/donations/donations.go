@@ -0,0 +1,326 @@
+package donations
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/rbrabson/coc/pkg/log"
+	"github.com/rbrabson/coc/v1"
+)
+
+const (
+	seasonLayout = "2006-01"
+)
+
+// Client is the set of Clash of Clans API calls used when polling for donations. It is
+// satisfied by *coc.Client.
+type Client interface {
+	GetClanMembers(clanTag string, qparms ...coc.QParms) ([]coc.ClanMember, *coc.Paging, error)
+	GetGoldPass() (*coc.GoldPass, error)
+}
+
+// Totals are the number of troops donated and received.
+type Totals struct {
+	Donations int `json:"donations"`
+	Received  int `json:"received"`
+}
+
+// String returns a string representation of donation totals
+func (t Totals) String() string {
+	b, _ := json.Marshal(t)
+	return string(b)
+}
+
+// Ratio returns the number of troops donated for each troop received. If no troops have been
+// received, the number of troops donated is returned.
+func (t Totals) Ratio() float64 {
+	if t.Received == 0 {
+		return float64(t.Donations)
+	}
+	return float64(t.Donations) / float64(t.Received)
+}
+
+// add includes the donations in the totals
+func (t *Totals) add(donations, received int) {
+	t.Donations += donations
+	t.Received += received
+}
+
+// Thresholds determine which players are flagged as leechers. A player is a leecher in a
+// season if they received at least MinReceived troops, and either donated fewer than
+// MinDonations troops or have a donation ratio below MinRatio.
+type Thresholds struct {
+	MinReceived  int     `json:"minReceived"`
+	MinDonations int     `json:"minDonations"`
+	MinRatio     float64 `json:"minRatio"`
+}
+
+// DefaultThresholds are the thresholds used if none are provided.
+var DefaultThresholds = Thresholds{
+	MinReceived:  100,
+	MinDonations: 0,
+	MinRatio:     0.5,
+}
+
+// IsLeecher returns an indication as to whether the totals fall below the thresholds.
+func (th Thresholds) IsLeecher(t Totals) bool {
+	if t.Received < th.MinReceived {
+		return false
+	}
+	return t.Donations < th.MinDonations || t.Ratio() < th.MinRatio
+}
+
+// PlayerDonations are the donations made and received by a player, in total and for each season.
+// Seasons are identified by the year and month in which they started, such as "2023-01".
+type PlayerDonations struct {
+	Tag           string            `json:"tag"`
+	Name          string            `json:"name"`
+	Lifetime      Totals            `json:"lifetime"`
+	Seasons       map[string]Totals `json:"seasons"`
+	LastSeen      time.Time         `json:"lastSeen"`
+	LastDonations int               `json:"lastDonations"` // Donation counter at the last update
+	LastReceived  int               `json:"lastReceived"`  // Donations received counter at the last update
+}
+
+// String returns a string representation of a player's donations
+func (p PlayerDonations) String() string {
+	b, _ := json.Marshal(p)
+	return string(b)
+}
+
+// Season returns the player's totals for the season.
+func (p PlayerDonations) Season(season string) Totals {
+	return p.Seasons[season]
+}
+
+// Season is a period of time at the end of which the donation counters are reset.
+type Season struct {
+	ID        string    `json:"id"`
+	StartTime time.Time `json:"startTime"`
+	EndTime   time.Time `json:"endTime"`
+}
+
+// String returns a string representation of a season
+func (s Season) String() string {
+	b, _ := json.Marshal(s)
+	return string(b)
+}
+
+// Tracker accumulates the donations made by the members of a clan across season resets. The
+// in-game donation counters are reset at the end of each season, so a counter that decreases
+// is treated as a reset and the new counter value is counted as the donations made since the
+// reset. The recorded season boundaries don't necessarily match the time at which the game
+// resets the counters, so they are only used to choose the season in which donations are
+// counted, and never to detect a reset.
+type Tracker struct {
+	mu         sync.RWMutex
+	thresholds Thresholds
+	seasons    []Season
+	players    map[string]*PlayerDonations
+}
+
+// NewTracker creates a donation tracker that flags leechers using the thresholds.
+func NewTracker(thresholds Thresholds) *Tracker {
+	return &Tracker{
+		thresholds: thresholds,
+		players:    make(map[string]*PlayerDonations),
+	}
+}
+
+// AddSeason records the boundaries of a season, such as the current gold pass season.
+func (t *Tracker) AddSeason(gp coc.GoldPass) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	season := Season{
+		ID:        gp.StartTime.Time().UTC().Format(seasonLayout),
+		StartTime: gp.StartTime.Time(),
+		EndTime:   gp.EndTime.Time(),
+	}
+	for _, s := range t.seasons {
+		if s.ID == season.ID {
+			return
+		}
+	}
+	t.seasons = append(t.seasons, season)
+	sort.Slice(t.seasons, func(i, j int) bool {
+		return t.seasons[i].StartTime.Before(t.seasons[j].StartTime)
+	})
+}
+
+// SeasonAt returns the identifier of the season that contains the given time. If the season's
+// boundaries haven't been recorded, the year and month of the time are used.
+func (t *Tracker) SeasonAt(at time.Time) string {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return t.seasonAt(at)
+}
+
+// Update includes a snapshot of the clan's members, such as those returned by
+// coc.Client.GetClanMembers, in the donation totals.
+func (t *Tracker) Update(at time.Time, members []coc.ClanMember) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	season := t.seasonAt(at)
+	for _, m := range members {
+		p, ok := t.players[m.Tag]
+		if !ok {
+			p = &PlayerDonations{Tag: m.Tag, Seasons: make(map[string]Totals)}
+			t.players[m.Tag] = p
+		}
+		p.Name = m.Name
+
+		// Determine the donations since the last update. If the counters were reset, all
+		// donations were made since the reset.
+		donations, received := m.Donations-p.LastDonations, m.DonationsReceived-p.LastReceived
+		if m.Donations < p.LastDonations || m.DonationsReceived < p.LastReceived {
+			donations, received = m.Donations, m.DonationsReceived
+		}
+
+		p.Lifetime.add(donations, received)
+		totals := p.Seasons[season]
+		totals.add(donations, received)
+		p.Seasons[season] = totals
+		p.LastDonations = m.Donations
+		p.LastReceived = m.DonationsReceived
+		p.LastSeen = at
+	}
+}
+
+// Player returns the donations for the player.
+func (t *Tracker) Player(playerTag string) (PlayerDonations, bool) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	p, ok := t.players[coc.NormalizeTag(playerTag)]
+	if !ok {
+		return PlayerDonations{}, false
+	}
+	return copyPlayer(p), true
+}
+
+// Players returns the donations for all players, ordered by lifetime donations.
+func (t *Tracker) Players() []PlayerDonations {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	list := make([]PlayerDonations, 0, len(t.players))
+	for _, p := range t.players {
+		list = append(list, copyPlayer(p))
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Lifetime.Donations != list[j].Lifetime.Donations {
+			return list[i].Lifetime.Donations > list[j].Lifetime.Donations
+		}
+		return list[i].Tag < list[j].Tag
+	})
+	return list
+}
+
+// Leechers returns the players whose donations in the season fall below the thresholds,
+// ordered by their donation ratio.
+func (t *Tracker) Leechers(season string) []PlayerDonations {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	var list []PlayerDonations
+	for _, p := range t.players {
+		if totals, ok := p.Seasons[season]; ok && t.thresholds.IsLeecher(totals) {
+			list = append(list, copyPlayer(p))
+		}
+	}
+	sort.Slice(list, func(i, j int) bool {
+		ri, rj := list[i].Seasons[season].Ratio(), list[j].Seasons[season].Ratio()
+		if ri != rj {
+			return ri < rj
+		}
+		return list[i].Tag < list[j].Tag
+	})
+	return list
+}
+
+// Poll updates the tracker with the clan's members at the given interval until the context is
+// cancelled. The current gold pass season is retrieved on each poll so that season boundaries
+// are known. Errors are passed to onError, if provided.
+func (t *Tracker) Poll(ctx context.Context, client Client, clanTag string, interval time.Duration, onError func(error)) error {
+	const M = "Tracker.Poll"
+	l := log.New()
+	defer l.Sync()
+
+	l.Debugf("--> %s", M)
+	defer l.Debugf("<-- %s", M)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if gp, err := client.GetGoldPass(); err == nil {
+			t.AddSeason(*gp)
+		} else if onError != nil {
+			onError(err)
+		}
+		if members, _, err := client.GetClanMembers(clanTag); err == nil {
+			t.Update(time.Now(), members)
+		} else if onError != nil {
+			onError(err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// trackerState is the persisted state of a tracker
+type trackerState struct {
+	Thresholds Thresholds                  `json:"thresholds"`
+	Seasons    []Season                    `json:"seasons"`
+	Players    map[string]*PlayerDonations `json:"players"`
+}
+
+// Save writes the tracker's state to the writer as JSON.
+func (t *Tracker) Save(w io.Writer) error {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	state := trackerState{Thresholds: t.thresholds, Seasons: t.seasons, Players: t.players}
+	return json.NewEncoder(w).Encode(state)
+}
+
+// Load reads a tracker's state, previously written using Save, from the reader.
+func Load(r io.Reader) (*Tracker, error) {
+	var state trackerState
+	if err := json.NewDecoder(r).Decode(&state); err != nil {
+		return nil, err
+	}
+	t := NewTracker(state.Thresholds)
+	t.seasons = state.Seasons
+	for tag, p := range state.Players {
+		if p.Seasons == nil {
+			p.Seasons = make(map[string]Totals)
+		}
+		t.players[tag] = p
+	}
+	return t, nil
+}
+
+// seasonAt returns the identifier of the season that contains the time
+func (t *Tracker) seasonAt(at time.Time) string {
+	for _, s := range t.seasons {
+		if !at.Before(s.StartTime) && at.Before(s.EndTime) {
+			return s.ID
+		}
+	}
+	return at.UTC().Format(seasonLayout)
+}
+
+// copyPlayer returns a copy of the player's donations
+func copyPlayer(p *PlayerDonations) PlayerDonations {
+	c := *p
+	c.Seasons = make(map[string]Totals, len(p.Seasons))
+	for k, v := range p.Seasons {
+		c.Seasons[k] = v
+	}
+	return c
+}
@@ -0,0 +1,118 @@
+package donations
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/rbrabson/coc/v1"
+)
+
+func TestUpdate(t *testing.T) {
+	at := func(month time.Month, day, hour int) time.Time {
+		return time.Date(2024, month, day, hour, 0, 0, 0, time.UTC)
+	}
+	type update struct {
+		at        time.Time
+		donations int
+		received  int
+	}
+	tests := []struct {
+		name         string
+		updates      []update
+		wantLifetime Totals
+		wantSeasons  map[string]Totals
+	}{
+		{
+			name: "no reset",
+			updates: []update{
+				{at(time.March, 10, 0), 10, 5},
+				{at(time.March, 20, 0), 30, 15},
+				{at(time.March, 25, 0), 30, 15},
+			},
+			wantLifetime: Totals{Donations: 30, Received: 15},
+			wantSeasons: map[string]Totals{
+				"2024-03": {Donations: 30, Received: 15},
+			},
+		},
+		{
+			name: "reset after the season boundary",
+			updates: []update{
+				{at(time.March, 31, 22), 100, 40},
+				{at(time.April, 1, 2), 110, 45},
+				{at(time.April, 2, 0), 5, 2},
+				{at(time.April, 3, 0), 9, 2},
+			},
+			wantLifetime: Totals{Donations: 119, Received: 47},
+			wantSeasons: map[string]Totals{
+				"2024-03": {Donations: 100, Received: 40},
+				"2024-04": {Donations: 19, Received: 7},
+			},
+		},
+		{
+			name: "reset before the season boundary",
+			updates: []update{
+				{at(time.March, 29, 0), 100, 40},
+				{at(time.March, 30, 0), 5, 0},
+				{at(time.April, 1, 2), 8, 3},
+			},
+			wantLifetime: Totals{Donations: 108, Received: 43},
+			wantSeasons: map[string]Totals{
+				"2024-03": {Donations: 105, Received: 40},
+				"2024-04": {Donations: 3, Received: 3},
+			},
+		},
+		{
+			name: "only received counter reset",
+			updates: []update{
+				{at(time.March, 29, 0), 0, 40},
+				{at(time.March, 30, 0), 0, 10},
+			},
+			wantLifetime: Totals{Donations: 0, Received: 50},
+			wantSeasons: map[string]Totals{
+				"2024-03": {Donations: 0, Received: 50},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tracker := NewTracker(DefaultThresholds)
+			for _, u := range tt.updates {
+				tracker.Update(u.at, []coc.ClanMember{{Tag: "#P1", Name: "Player", Donations: u.donations, DonationsReceived: u.received}})
+			}
+			p, ok := tracker.Player("#p1")
+			if !ok {
+				t.Fatal("Player() didn't find the player")
+			}
+			if p.Lifetime != tt.wantLifetime {
+				t.Errorf("Lifetime = %v, want %v", p.Lifetime, tt.wantLifetime)
+			}
+			if !reflect.DeepEqual(p.Seasons, tt.wantSeasons) {
+				t.Errorf("Seasons = %v, want %v", p.Seasons, tt.wantSeasons)
+			}
+		})
+	}
+}
+
+func TestUpdateUsesRecordedSeasons(t *testing.T) {
+	tracker := NewTracker(DefaultThresholds)
+	tracker.AddSeason(coc.GoldPass{
+		StartTime: coc.NewTime(time.Date(2024, 3, 1, 8, 0, 0, 0, time.UTC)),
+		EndTime:   coc.NewTime(time.Date(2024, 4, 1, 8, 0, 0, 0, time.UTC)),
+	})
+
+	// The update after midnight on April 1st is still within the recorded March season, and
+	// the season label changing later must not be treated as a reset
+	tracker.Update(time.Date(2024, 3, 31, 23, 0, 0, 0, time.UTC), []coc.ClanMember{{Tag: "#P1", Donations: 50}})
+	tracker.Update(time.Date(2024, 4, 1, 4, 0, 0, 0, time.UTC), []coc.ClanMember{{Tag: "#P1", Donations: 60}})
+	tracker.Update(time.Date(2024, 4, 1, 12, 0, 0, 0, time.UTC), []coc.ClanMember{{Tag: "#P1", Donations: 70}})
+
+	p, _ := tracker.Player("#P1")
+	want := map[string]Totals{
+		"2024-03": {Donations: 60},
+		"2024-04": {Donations: 10},
+	}
+	if !reflect.DeepEqual(p.Seasons, want) {
+		t.Errorf("Seasons = %v, want %v", p.Seasons, want)
+	}
+}
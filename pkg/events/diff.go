@@ -0,0 +1,268 @@
+package events
+
+import (
+	"sort"
+	"time"
+
+	"github.com/rbrabson/coc/v1"
+)
+
+const (
+	raidStateOngoing = "ongoing"
+)
+
+// DiffClan returns the events for the changes between two snapshots of a clan. The member
+// lists of the clans are compared, so the snapshots must be retrieved using coc.Client.GetClan.
+// No events are returned if the previous snapshot is nil.
+func DiffClan(old, new *coc.Clan, at time.Time) []Event {
+	if old == nil || new == nil {
+		return nil
+	}
+
+	var events []Event
+	oldMembers := make(map[string]coc.ClanMember, len(old.MemberList))
+	for _, m := range old.MemberList {
+		oldMembers[m.Tag] = m
+	}
+	newMembers := make(map[string]bool, len(new.MemberList))
+	for _, m := range new.MemberList {
+		newMembers[m.Tag] = true
+		prev, ok := oldMembers[m.Tag]
+		if !ok {
+			events = append(events, MemberJoined{
+				Header:   Header{Type: EventMemberJoined, Time: at},
+				ClanTag:  new.Tag,
+				ClanName: new.Name,
+				Member:   m,
+			})
+			continue
+		}
+		if prev.Role != m.Role {
+			events = append(events, MemberRoleChanged{
+				Header:   Header{Type: EventMemberRoleChanged, Time: at},
+				ClanTag:  new.Tag,
+				ClanName: new.Name,
+				Member:   m,
+				OldRole:  prev.Role,
+				NewRole:  m.Role,
+			})
+		}
+		donations := counterDelta(prev.Donations, m.Donations)
+		received := counterDelta(prev.DonationsReceived, m.DonationsReceived)
+		if donations != 0 || received != 0 {
+			events = append(events, MemberDonations{
+				Header:            Header{Type: EventMemberDonations, Time: at},
+				ClanTag:           new.Tag,
+				ClanName:          new.Name,
+				Member:            m,
+				Donations:         donations,
+				DonationsReceived: received,
+			})
+		}
+		if prev.Trophies != m.Trophies {
+			events = append(events, TrophiesChanged{
+				Header:      Header{Type: EventTrophiesChanged, Time: at},
+				ClanTag:     new.Tag,
+				PlayerTag:   m.Tag,
+				PlayerName:  m.Name,
+				OldTrophies: prev.Trophies,
+				NewTrophies: m.Trophies,
+			})
+		}
+	}
+	for _, m := range old.MemberList {
+		if !newMembers[m.Tag] {
+			events = append(events, MemberLeft{
+				Header:   Header{Type: EventMemberLeft, Time: at},
+				ClanTag:  new.Tag,
+				ClanName: new.Name,
+				Member:   m,
+			})
+		}
+	}
+
+	return events
+}
+
+// DiffPlayer returns the events for the changes between two snapshots of a player. No events
+// are returned if the previous snapshot is nil.
+func DiffPlayer(old, new *coc.Player, at time.Time) []Event {
+	if old == nil || new == nil {
+		return nil
+	}
+
+	var events []Event
+	if old.Trophies != new.Trophies {
+		events = append(events, TrophiesChanged{
+			Header:      Header{Type: EventTrophiesChanged, Time: at},
+			PlayerTag:   new.Tag,
+			PlayerName:  new.Name,
+			OldTrophies: old.Trophies,
+			NewTrophies: new.Trophies,
+		})
+	}
+	if old.TownHallLevel < new.TownHallLevel {
+		events = append(events, TownHallUpgraded{
+			Header:     Header{Type: EventTownHallUpgraded, Time: at},
+			PlayerTag:  new.Tag,
+			PlayerName: new.Name,
+			OldLevel:   old.TownHallLevel,
+			NewLevel:   new.TownHallLevel,
+		})
+	}
+	for _, progress := range coc.DiffAchievements(*old, *new) {
+		events = append(events, AchievementProgressed{
+			Header:              Header{Type: EventAchievementProgressed, Time: at},
+			PlayerTag:           new.Tag,
+			PlayerName:          new.Name,
+			AchievementProgress: progress,
+		})
+	}
+
+	return events
+}
+
+// DiffWar returns the events for the changes between two snapshots of a clan's current war.
+// A nil war indicates the clan isn't in a war. If the war changed between the snapshots, all
+// attacks in the new war are reported. The war is oriented so that the clan with the given tag
+// is the war's Clan.
+func DiffWar(clanTag string, old, new *coc.ClanWar, at time.Time) []Event {
+	old = orientWar(clanTag, old)
+	new = orientWar(clanTag, new)
+
+	var events []Event
+	oldState, newState := warState(old), warState(new)
+	if oldState != newState || (old != nil && new != nil && !sameWar(*old, *new)) {
+		events = append(events, WarStateChanged{
+			Header:   Header{Type: EventWarStateChanged, Time: at},
+			ClanTag:  coc.NormalizeTag(clanTag),
+			OldState: oldState,
+			NewState: newState,
+			War:      new,
+		})
+	}
+	if new == nil {
+		return events
+	}
+
+	// Find the attacks that have already been reported
+	seen := make(map[int]bool)
+	if old != nil && sameWar(*old, *new) {
+		for _, team := range []coc.ClanWarTeam{old.Clan, old.Opponent} {
+			for _, m := range team.Members {
+				for _, a := range m.Attacks {
+					seen[a.Order] = true
+				}
+			}
+		}
+	}
+
+	// Report any new attacks in the order they were made
+	var attacks []WarAttack
+	teams := []struct {
+		attackers coc.ClanWarTeam
+		defenders coc.ClanWarTeam
+		defense   bool
+	}{
+		{attackers: new.Clan, defenders: new.Opponent, defense: false},
+		{attackers: new.Opponent, defenders: new.Clan, defense: true},
+	}
+	for _, t := range teams {
+		defenders := make(map[string]coc.ClanWarMember, len(t.defenders.Members))
+		for _, m := range t.defenders.Members {
+			defenders[m.Tag] = m
+		}
+		for _, m := range t.attackers.Members {
+			for _, a := range m.Attacks {
+				if seen[a.Order] {
+					continue
+				}
+				attacks = append(attacks, WarAttack{
+					Header:       Header{Type: EventWarAttack, Time: at},
+					ClanTag:      new.Clan.Tag,
+					ClanName:     new.Clan.Name,
+					OpponentTag:  new.Opponent.Tag,
+					OpponentName: new.Opponent.Name,
+					WarType:      new.WarType(),
+					Defense:      t.defense,
+					Attacker:     m,
+					Defender:     defenders[a.DefenderTag],
+					Attack:       a,
+				})
+			}
+		}
+	}
+	sort.Slice(attacks, func(i, j int) bool {
+		return attacks[i].Attack.Order < attacks[j].Attack.Order
+	})
+	for _, a := range attacks {
+		events = append(events, a)
+	}
+
+	return events
+}
+
+// DiffRaidSeason returns the events for the changes between two snapshots of a clan's most
+// recent capital raid season. No events are returned if the previous snapshot is nil.
+func DiffRaidSeason(clanTag string, old, new *coc.ClanCapitalRaidSeasion, at time.Time) []Event {
+	if old == nil || new == nil {
+		return nil
+	}
+
+	clanTag = coc.NormalizeTag(clanTag)
+	var events []Event
+	sameSeason := old.StartTime.Equal(new.StartTime)
+	if old.State == raidStateOngoing && (!sameSeason || new.State != raidStateOngoing) {
+		ended := *old
+		if sameSeason {
+			ended = *new
+		}
+		events = append(events, RaidWeekendEnded{
+			Header:  Header{Type: EventRaidWeekendEnded, Time: at},
+			ClanTag: clanTag,
+			Season:  ended,
+		})
+	}
+	if new.State == raidStateOngoing && (!sameSeason || old.State != raidStateOngoing) {
+		events = append(events, RaidWeekendStarted{
+			Header:  Header{Type: EventRaidWeekendStarted, Time: at},
+			ClanTag: clanTag,
+			Season:  *new,
+		})
+	}
+
+	return events
+}
+
+// counterDelta returns the change in a counter that is periodically reset to zero
+func counterDelta(old, new int) int {
+	if new < old {
+		return new
+	}
+	return new - old
+}
+
+// orientWar returns the war from the perspective of the clan
+func orientWar(clanTag string, war *coc.ClanWar) *coc.ClanWar {
+	if war == nil {
+		return nil
+	}
+	if cw, ok := war.ForClan(clanTag); ok {
+		return &cw
+	}
+	return war
+}
+
+// warState returns the state of the war
+func warState(war *coc.ClanWar) string {
+	if war == nil || war.State == "" {
+		return string(coc.WarPhaseNotInWar)
+	}
+	return war.State
+}
+
+// sameWar returns an indication as to whether two snapshots are of the same war
+func sameWar(a, b coc.ClanWar) bool {
+	return coc.NormalizeTag(a.Opponent.Tag) == coc.NormalizeTag(b.Opponent.Tag) &&
+		a.PreparationStartTime.Equal(b.PreparationStartTime)
+}
@@ -0,0 +1,306 @@
+package events
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rbrabson/coc/v1"
+)
+
+// summarize returns a short description of each event, so that the events may be compared
+func summarize(events []Event) []string {
+	var list []string
+	for _, e := range events {
+		var s string
+		switch e := e.(type) {
+		case MemberJoined:
+			s = fmt.Sprintf("%s %s", e.Type, e.Member.Tag)
+		case MemberLeft:
+			s = fmt.Sprintf("%s %s", e.Type, e.Member.Tag)
+		case MemberRoleChanged:
+			s = fmt.Sprintf("%s %s %s->%s", e.Type, e.Member.Tag, e.OldRole, e.NewRole)
+		case MemberDonations:
+			s = fmt.Sprintf("%s %s %d/%d", e.Type, e.Member.Tag, e.Donations, e.DonationsReceived)
+		case TrophiesChanged:
+			s = fmt.Sprintf("%s %s %d->%d", e.Type, e.PlayerTag, e.OldTrophies, e.NewTrophies)
+		case TownHallUpgraded:
+			s = fmt.Sprintf("%s %s %d->%d", e.Type, e.PlayerTag, e.OldLevel, e.NewLevel)
+		case AchievementProgressed:
+			s = fmt.Sprintf("%s %s %s +%d", e.Type, e.PlayerTag, e.ID, e.Delta)
+		case WarStateChanged:
+			s = fmt.Sprintf("%s %s->%s", e.Type, e.OldState, e.NewState)
+		case WarAttack:
+			s = fmt.Sprintf("%s %d %s->%s defense=%v", e.Type, e.Attack.Order, e.Attacker.Tag, e.Defender.Tag, e.Defense)
+		case RaidWeekendStarted:
+			s = fmt.Sprintf("%s %s", e.Type, e.Season.StartTime.Format("2006-01-02"))
+		case RaidWeekendEnded:
+			s = fmt.Sprintf("%s %s %s", e.Type, e.Season.StartTime.Format("2006-01-02"), e.Season.State)
+		default:
+			s = fmt.Sprintf("%T", e)
+		}
+		list = append(list, s)
+	}
+	return list
+}
+
+// now is the time at which the changes in each test are detected
+var now = time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+func TestDiffClan(t *testing.T) {
+	member := func(tag, role string, donations, received, trophies int) coc.ClanMember {
+		return coc.ClanMember{Tag: tag, Name: "Player " + tag, Role: role, Donations: donations, DonationsReceived: received, Trophies: trophies}
+	}
+	clan := func(members ...coc.ClanMember) *coc.Clan {
+		return &coc.Clan{Tag: "#2PP", Name: "Clan", MemberList: members}
+	}
+	tests := []struct {
+		name string
+		old  *coc.Clan
+		new  *coc.Clan
+		want []string
+	}{
+		{
+			name: "first snapshot",
+			new:  clan(member("#PA", "member", 0, 0, 5000)),
+		},
+		{
+			name: "no changes",
+			old:  clan(member("#PA", "member", 10, 5, 5000)),
+			new:  clan(member("#PA", "member", 10, 5, 5000)),
+		},
+		{
+			name: "joined and left",
+			old:  clan(member("#PA", "member", 0, 0, 5000), member("#PB", "member", 0, 0, 4000)),
+			new:  clan(member("#PA", "member", 0, 0, 5000), member("#PC", "member", 0, 0, 3000)),
+			want: []string{"memberJoined #PC", "memberLeft #PB"},
+		},
+		{
+			name: "role changed",
+			old:  clan(member("#PA", "member", 0, 0, 5000)),
+			new:  clan(member("#PA", "admin", 0, 0, 5000)),
+			want: []string{"memberRoleChanged #PA member->admin"},
+		},
+		{
+			name: "donations",
+			old:  clan(member("#PA", "member", 10, 5, 5000)),
+			new:  clan(member("#PA", "member", 25, 5, 5000)),
+			want: []string{"memberDonations #PA 15/0"},
+		},
+		{
+			name: "donations reset",
+			old:  clan(member("#PA", "member", 900, 400, 5000)),
+			new:  clan(member("#PA", "member", 20, 400, 5000)),
+			want: []string{"memberDonations #PA 20/0"},
+		},
+		{
+			name: "trophies",
+			old:  clan(member("#PA", "member", 0, 0, 5000)),
+			new:  clan(member("#PA", "coLeader", 8, 0, 4970)),
+			want: []string{"memberRoleChanged #PA member->coLeader", "memberDonations #PA 8/0", "trophiesChanged #PA 5000->4970"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := summarize(DiffClan(tt.old, tt.new, now))
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("DiffClan() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDiffPlayer(t *testing.T) {
+	player := func(trophies, townHall, stars int) *coc.Player {
+		return &coc.Player{
+			Tag:           "#PA",
+			Name:          "Player",
+			Trophies:      trophies,
+			TownHallLevel: townHall,
+			Achievements: []coc.PlayerAchievement{
+				{Name: string(coc.AchievementConqueror), Stars: 2, Value: stars * 10, Target: 5000, Village: "home"},
+			},
+		}
+	}
+	tests := []struct {
+		name string
+		old  *coc.Player
+		new  *coc.Player
+		want []string
+	}{
+		{"first snapshot", nil, player(5000, 14, 1), nil},
+		{"no changes", player(5000, 14, 1), player(5000, 14, 1), nil},
+		{"trophies", player(5000, 14, 1), player(5030, 14, 1), []string{"trophiesChanged #PA 5000->5030"}},
+		{"town hall upgraded", player(5000, 14, 1), player(5000, 15, 1), []string{"townHallUpgraded #PA 14->15"}},
+		{
+			name: "achievement progress",
+			old:  player(5000, 14, 1),
+			new:  player(5040, 14, 3),
+			want: []string{"trophiesChanged #PA 5000->5040", "achievementProgressed #PA Conqueror +20"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := summarize(DiffPlayer(tt.old, tt.new, now))
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("DiffPlayer() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// newWar returns a war between #2PP and the opponent in the given state. The attacks are made
+// in the order given, with those by #PA and #PB against #OA and #OB, and those by #OA and #OB
+// against #PA and #PB.
+func newWar(opponentTag, state string, attacks ...string) *coc.ClanWar {
+	war := &coc.ClanWar{
+		State:                state,
+		PreparationStartTime: coc.NewTime(now.Add(-24 * time.Hour)),
+		Clan: coc.ClanWarTeam{Tag: "#2PP", Name: "Clan", Members: []coc.ClanWarMember{
+			{Tag: "#PA", MapPosition: 1}, {Tag: "#PB", MapPosition: 2},
+		}},
+		Opponent: coc.ClanWarTeam{Tag: opponentTag, Name: "Opponent", Members: []coc.ClanWarMember{
+			{Tag: "#OA", MapPosition: 1}, {Tag: "#OB", MapPosition: 2},
+		}},
+	}
+	for i, a := range attacks {
+		tags := strings.SplitN(a, "->", 2)
+		attacker, defender := tags[0], tags[1]
+		team := &war.Clan
+		if attacker[1] == 'O' {
+			team = &war.Opponent
+		}
+		for j := range team.Members {
+			if team.Members[j].Tag == attacker {
+				team.Members[j].Attacks = append(team.Members[j].Attacks, coc.ClanWarAttack{
+					Order:       i + 1,
+					AttackerTag: attacker,
+					DefenderTag: defender,
+					Stars:       2,
+				})
+			}
+		}
+	}
+	return war
+}
+
+func TestDiffWar(t *testing.T) {
+	reversed := newWar("#8QU", "inWar", "#PA->#OB", "#OA->#PA", "#PB->#OA")
+	reversed.Clan, reversed.Opponent = reversed.Opponent, reversed.Clan
+
+	tests := []struct {
+		name string
+		old  *coc.ClanWar
+		new  *coc.ClanWar
+		want []string
+	}{
+		{
+			name: "not in war",
+		},
+		{
+			name: "preparation started",
+			new:  newWar("#8QU", "preparation"),
+			want: []string{"warStateChanged notInWar->preparation"},
+		},
+		{
+			name: "battle day started with attacks",
+			old:  newWar("#8QU", "preparation"),
+			new:  newWar("#8QU", "inWar", "#PA->#OB", "#OA->#PA"),
+			want: []string{
+				"warStateChanged preparation->inWar",
+				"warAttack 1 #PA->#OB defense=false",
+				"warAttack 2 #OA->#PA defense=true",
+			},
+		},
+		{
+			name: "only new attacks reported",
+			old:  newWar("#8QU", "inWar", "#PA->#OB", "#OA->#PA"),
+			new:  newWar("#8QU", "inWar", "#PA->#OB", "#OA->#PA", "#PB->#OA", "#OB->#PB"),
+			want: []string{
+				"warAttack 3 #PB->#OA defense=false",
+				"warAttack 4 #OB->#PB defense=true",
+			},
+		},
+		{
+			name: "no new attacks",
+			old:  newWar("#8QU", "inWar", "#PA->#OB"),
+			new:  newWar("#8QU", "inWar", "#PA->#OB"),
+		},
+		{
+			name: "war ended",
+			old:  newWar("#8QU", "inWar", "#PA->#OB"),
+			new:  newWar("#8QU", "warEnded", "#PA->#OB", "#PB->#OA"),
+			want: []string{
+				"warStateChanged inWar->warEnded",
+				"warAttack 2 #PB->#OA defense=false",
+			},
+		},
+		{
+			name: "new war reports all attacks",
+			old:  newWar("#8QU", "inWar", "#PA->#OB"),
+			new:  newWar("#9LL", "inWar", "#PA->#OB"),
+			want: []string{
+				"warStateChanged inWar->inWar",
+				"warAttack 1 #PA->#OB defense=false",
+			},
+		},
+		{
+			name: "clan is the opponent",
+			old:  newWar("#8QU", "inWar", "#PA->#OB"),
+			new:  reversed,
+			want: []string{
+				"warAttack 2 #OA->#PA defense=true",
+				"warAttack 3 #PB->#OA defense=false",
+			},
+		},
+		{
+			name: "war left",
+			old:  newWar("#8QU", "warEnded"),
+			want: []string{"warStateChanged warEnded->notInWar"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := summarize(DiffWar("#2PP", tt.old, tt.new, now))
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("DiffWar() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDiffRaidSeason(t *testing.T) {
+	first := time.Date(2024, 3, 1, 7, 0, 0, 0, time.UTC)
+	second := first.AddDate(0, 0, 7)
+	season := func(start time.Time, state string) *coc.ClanCapitalRaidSeasion {
+		return &coc.ClanCapitalRaidSeasion{State: state, StartTime: coc.NewTime(start), EndTime: coc.NewTime(start.AddDate(0, 0, 3))}
+	}
+	tests := []struct {
+		name string
+		old  *coc.ClanCapitalRaidSeasion
+		new  *coc.ClanCapitalRaidSeasion
+		want []string
+	}{
+		{"first snapshot", nil, season(first, "ongoing"), nil},
+		{"still ongoing", season(first, "ongoing"), season(first, "ongoing"), nil},
+		{"started", season(first, "ended"), season(second, "ongoing"), []string{"raidWeekendStarted 2024-03-08"}},
+		{"ended", season(first, "ongoing"), season(first, "ended"), []string{"raidWeekendEnded 2024-03-01 ended"}},
+		{
+			name: "end missed between polls",
+			old:  season(first, "ongoing"),
+			new:  season(second, "ongoing"),
+			want: []string{"raidWeekendEnded 2024-03-01 ongoing", "raidWeekendStarted 2024-03-08"},
+		},
+		{"between weekends", season(first, "ended"), season(first, "ended"), nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := summarize(DiffRaidSeason("#2PP", tt.old, tt.new, now))
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("DiffRaidSeason() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
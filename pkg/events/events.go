@@ -0,0 +1,221 @@
+package events
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/rbrabson/coc/v1"
+)
+
+// EventType identifies the type of change described by an event.
+type EventType string
+
+const (
+	EventMemberJoined          EventType = "memberJoined"          // A player joined a clan
+	EventMemberLeft            EventType = "memberLeft"            // A player left a clan
+	EventMemberRoleChanged     EventType = "memberRoleChanged"     // A clan member was promoted or demoted
+	EventMemberDonations       EventType = "memberDonations"       // A clan member donated or received troops
+	EventTrophiesChanged       EventType = "trophiesChanged"       // A player's trophy count changed
+	EventTownHallUpgraded      EventType = "townHallUpgraded"      // A player upgraded their town hall
+	EventWarStateChanged       EventType = "warStateChanged"       // A clan's war moved into a new state
+	EventWarAttack             EventType = "warAttack"             // An attack was made in a clan's war
+	EventRaidWeekendStarted    EventType = "raidWeekendStarted"    // A clan capital raid weekend started
+	EventRaidWeekendEnded      EventType = "raidWeekendEnded"      // A clan capital raid weekend ended
+	EventAchievementProgressed EventType = "achievementProgressed" // A player progressed on an achievement
+)
+
+// Event is a change detected between two successive snapshots of a clan, player, war or
+// capital raid season.
+type Event interface {
+	// EventType returns the type of change described by the event
+	EventType() EventType
+	// EventTime returns the time at which the change was detected
+	EventTime() time.Time
+}
+
+// Header is the information common to all events.
+type Header struct {
+	Type EventType `json:"type"`
+	Time time.Time `json:"time"`
+}
+
+// EventType returns the type of change described by the event
+func (h Header) EventType() EventType {
+	return h.Type
+}
+
+// EventTime returns the time at which the change was detected
+func (h Header) EventTime() time.Time {
+	return h.Time
+}
+
+// MemberJoined is sent when a player joins a clan.
+type MemberJoined struct {
+	Header
+	ClanTag  string         `json:"clanTag"`
+	ClanName string         `json:"clanName"`
+	Member   coc.ClanMember `json:"member"`
+}
+
+// String returns a string representation of the event
+func (e MemberJoined) String() string {
+	b, _ := json.Marshal(e)
+	return string(b)
+}
+
+// MemberLeft is sent when a player leaves a clan.
+type MemberLeft struct {
+	Header
+	ClanTag  string         `json:"clanTag"`
+	ClanName string         `json:"clanName"`
+	Member   coc.ClanMember `json:"member"`
+}
+
+// String returns a string representation of the event
+func (e MemberLeft) String() string {
+	b, _ := json.Marshal(e)
+	return string(b)
+}
+
+// MemberRoleChanged is sent when a clan member is promoted or demoted.
+type MemberRoleChanged struct {
+	Header
+	ClanTag  string         `json:"clanTag"`
+	ClanName string         `json:"clanName"`
+	Member   coc.ClanMember `json:"member"`
+	OldRole  string         `json:"oldRole"`
+	NewRole  string         `json:"newRole"`
+}
+
+// String returns a string representation of the event
+func (e MemberRoleChanged) String() string {
+	b, _ := json.Marshal(e)
+	return string(b)
+}
+
+// MemberDonations is sent when a clan member donates or receives troops. The donations are
+// the change since the previous snapshot; when the counters are reset at the end of a season
+// the donations are those made since the reset.
+type MemberDonations struct {
+	Header
+	ClanTag           string         `json:"clanTag"`
+	ClanName          string         `json:"clanName"`
+	Member            coc.ClanMember `json:"member"`
+	Donations         int            `json:"donations"`
+	DonationsReceived int            `json:"donationsReceived"`
+}
+
+// String returns a string representation of the event
+func (e MemberDonations) String() string {
+	b, _ := json.Marshal(e)
+	return string(b)
+}
+
+// TrophiesChanged is sent when a player's trophy count changes. The clan tag is set when the
+// change was detected from a clan's member list.
+type TrophiesChanged struct {
+	Header
+	ClanTag     string `json:"clanTag,omitempty"`
+	PlayerTag   string `json:"playerTag"`
+	PlayerName  string `json:"playerName"`
+	OldTrophies int    `json:"oldTrophies"`
+	NewTrophies int    `json:"newTrophies"`
+}
+
+// String returns a string representation of the event
+func (e TrophiesChanged) String() string {
+	b, _ := json.Marshal(e)
+	return string(b)
+}
+
+// TownHallUpgraded is sent when a player upgrades their town hall.
+type TownHallUpgraded struct {
+	Header
+	PlayerTag  string `json:"playerTag"`
+	PlayerName string `json:"playerName"`
+	OldLevel   int    `json:"oldLevel"`
+	NewLevel   int    `json:"newLevel"`
+}
+
+// String returns a string representation of the event
+func (e TownHallUpgraded) String() string {
+	b, _ := json.Marshal(e)
+	return string(b)
+}
+
+// AchievementProgressed is sent when a player progresses on an achievement.
+type AchievementProgressed struct {
+	Header
+	PlayerTag  string `json:"playerTag"`
+	PlayerName string `json:"playerName"`
+	coc.AchievementProgress
+}
+
+// String returns a string representation of the event
+func (e AchievementProgressed) String() string {
+	b, _ := json.Marshal(e)
+	return string(b)
+}
+
+// WarStateChanged is sent when a clan's war moves into a new state. The war is nil if the clan
+// is no longer in a war.
+type WarStateChanged struct {
+	Header
+	ClanTag  string       `json:"clanTag"`
+	OldState string       `json:"oldState"`
+	NewState string       `json:"newState"`
+	War      *coc.ClanWar `json:"war,omitempty"`
+}
+
+// String returns a string representation of the event
+func (e WarStateChanged) String() string {
+	b, _ := json.Marshal(e)
+	return string(b)
+}
+
+// WarAttack is sent when a new attack is made in a clan's war. Defense is true when the attack
+// was made by the opponent against the clan.
+type WarAttack struct {
+	Header
+	ClanTag      string            `json:"clanTag"`
+	ClanName     string            `json:"clanName"`
+	OpponentTag  string            `json:"opponentTag"`
+	OpponentName string            `json:"opponentName"`
+	WarType      coc.WarType       `json:"warType"`
+	Defense      bool              `json:"defense"`
+	Attacker     coc.ClanWarMember `json:"attacker"`
+	Defender     coc.ClanWarMember `json:"defender"`
+	Attack       coc.ClanWarAttack `json:"attack"`
+}
+
+// String returns a string representation of the event
+func (e WarAttack) String() string {
+	b, _ := json.Marshal(e)
+	return string(b)
+}
+
+// RaidWeekendStarted is sent when a clan capital raid weekend starts.
+type RaidWeekendStarted struct {
+	Header
+	ClanTag string                     `json:"clanTag"`
+	Season  coc.ClanCapitalRaidSeasion `json:"season"`
+}
+
+// String returns a string representation of the event
+func (e RaidWeekendStarted) String() string {
+	b, _ := json.Marshal(e)
+	return string(b)
+}
+
+// RaidWeekendEnded is sent when a clan capital raid weekend ends.
+type RaidWeekendEnded struct {
+	Header
+	ClanTag string                     `json:"clanTag"`
+	Season  coc.ClanCapitalRaidSeasion `json:"season"`
+}
+
+// String returns a string representation of the event
+func (e RaidWeekendEnded) String() string {
+	b, _ := json.Marshal(e)
+	return string(b)
+}
@@ -0,0 +1,342 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/rbrabson/coc/pkg/log"
+	"github.com/rbrabson/coc/v1"
+)
+
+const (
+	defaultPollInterval = 2 * time.Minute
+	defaultMinInterval  = 30 * time.Second
+	defaultBufferSize   = 100
+)
+
+// Client is the set of Clash of Clans API calls used by the poller. It is satisfied by
+// *coc.Client.
+type Client interface {
+	GetClan(clanTag string) (*coc.Clan, error)
+	GetPlayer(playerTag string) (*coc.Player, error)
+	GetClanWarCurrent(clanTag string) (*coc.ClanWar, error)
+	ListCapitalRaidSeasons(clanTag string, qparms ...coc.QParms) ([]coc.ClanCapitalRaidSeasion, *coc.Paging, error)
+}
+
+// cacheExpirer is implemented by clients that report when the server's cached responses
+// expire, such as *coc.Client.
+type cacheExpirer interface {
+	ClanCacheExpiry(clanTag string) time.Time
+	PlayerCacheExpiry(playerTag string) time.Time
+	ClanWarCurrentCacheExpiry(clanTag string) time.Time
+	CapitalRaidSeasonsCacheExpiry(clanTag string) time.Time
+}
+
+// PollerConfig is the configuration for a poller. Zero values are replaced with defaults.
+type PollerConfig struct {
+	PollInterval time.Duration // Interval between polls when the server's cache expiry isn't known
+	MinInterval  time.Duration // Minimum interval between polls of the same clan, player or war
+	BufferSize   int           // Size of the channel returned by Events
+}
+
+// targetKind is the type of information polled for a target
+type targetKind int
+
+const (
+	targetClan targetKind = iota
+	targetPlayer
+	targetWar
+	targetRaid
+)
+
+// target is a clan, player, war or raid season that is polled for changes
+type target struct {
+	kind   targetKind
+	tag    string
+	next   time.Time
+	seen   bool
+	clan   *coc.Clan
+	player *coc.Player
+	war    *coc.ClanWar
+	raid   *coc.ClanCapitalRaidSeasion
+}
+
+// Poller periodically retrieves the registered clans, players, wars and capital raid seasons,
+// compares each snapshot to the previous one and emits events for the changes. Each is polled
+// again when the server's cached response expires, so that no polls return stale information.
+// Events are delivered to subscribed functions and, if Events has been called, to a channel.
+type Poller struct {
+	mu          sync.Mutex
+	client      Client
+	cfg         PollerConfig
+	targets     []*target
+	subscribers []func(Event)
+	onError     func(error)
+	events      chan Event
+	useChannel  bool
+	wake        chan struct{}
+}
+
+// NewPoller creates a poller that uses the client to retrieve information.
+func NewPoller(client Client, cfg PollerConfig) *Poller {
+	if cfg.PollInterval <= 0 {
+		cfg.PollInterval = defaultPollInterval
+	}
+	if cfg.MinInterval <= 0 {
+		cfg.MinInterval = defaultMinInterval
+	}
+	if cfg.BufferSize <= 0 {
+		cfg.BufferSize = defaultBufferSize
+	}
+	return &Poller{
+		client: client,
+		cfg:    cfg,
+		events: make(chan Event, cfg.BufferSize),
+		wake:   make(chan struct{}, 1),
+	}
+}
+
+// AddClan registers a clan whose members are polled for changes.
+func (p *Poller) AddClan(clanTag string) {
+	p.addTarget(targetClan, clanTag)
+}
+
+// AddPlayer registers a player who is polled for changes.
+func (p *Poller) AddPlayer(playerTag string) {
+	p.addTarget(targetPlayer, playerTag)
+}
+
+// AddWar registers a clan whose current war is polled for changes.
+func (p *Poller) AddWar(clanTag string) {
+	p.addTarget(targetWar, clanTag)
+}
+
+// AddRaids registers a clan whose capital raid seasons are polled for changes.
+func (p *Poller) AddRaids(clanTag string) {
+	p.addTarget(targetRaid, clanTag)
+}
+
+// Subscribe registers a function that is called with each event. Functions are called from
+// the goroutine running the poller, so they should not block.
+func (p *Poller) Subscribe(fn func(Event)) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.subscribers = append(p.subscribers, fn)
+}
+
+// OnError registers a function that is called when information can't be retrieved.
+func (p *Poller) OnError(fn func(error)) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.onError = fn
+}
+
+// Events returns a channel on which each event is delivered. Once this has been called the
+// channel must be drained, as the poller waits for room in the channel before continuing.
+// The channel is closed when Run returns.
+func (p *Poller) Events() <-chan Event {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.useChannel = true
+	return p.events
+}
+
+// Run polls the registered targets until the context is cancelled.
+func (p *Poller) Run(ctx context.Context) error {
+	const M = "Poller.Run"
+	l := log.New()
+	defer l.Sync()
+
+	l.Debugf("--> %s", M)
+	defer l.Debugf("<-- %s", M)
+
+	defer close(p.events)
+	for {
+		// Wait until the next target is due to be polled
+		wait := p.cfg.PollInterval
+		if next, ok := p.nextPoll(); ok {
+			wait = time.Until(next)
+		}
+		if wait > 0 {
+			timer := time.NewTimer(wait)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return ctx.Err()
+			case <-p.wake:
+				timer.Stop()
+				continue
+			case <-timer.C:
+			}
+		}
+
+		for _, t := range p.dueTargets() {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			events, err := p.poll(t)
+			if err != nil {
+				l.Debugf("failed to poll %s: %v", t.tag, err)
+				p.reportError(err)
+			}
+			if err := p.deliver(ctx, events); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// addTarget registers a target and wakes the poller so that it is polled immediately
+func (p *Poller) addTarget(kind targetKind, tag string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	tag = coc.NormalizeTag(tag)
+	for _, t := range p.targets {
+		if t.kind == kind && t.tag == tag {
+			return
+		}
+	}
+	p.targets = append(p.targets, &target{kind: kind, tag: tag})
+
+	select {
+	case p.wake <- struct{}{}:
+	default:
+	}
+}
+
+// nextPoll returns the time at which the next target is due to be polled
+func (p *Poller) nextPoll() (time.Time, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	var next time.Time
+	for _, t := range p.targets {
+		if next.IsZero() || t.next.Before(next) {
+			next = t.next
+		}
+	}
+	return next, len(p.targets) > 0
+}
+
+// dueTargets returns the targets that are due to be polled
+func (p *Poller) dueTargets() []*target {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	now := time.Now()
+	var due []*target
+	for _, t := range p.targets {
+		if !t.next.After(now) {
+			due = append(due, t)
+		}
+	}
+	return due
+}
+
+// poll retrieves the latest snapshot for the target and returns the events for any changes
+// since the previous snapshot
+func (p *Poller) poll(t *target) ([]Event, error) {
+	now := time.Now()
+	var events []Event
+	var err error
+	switch t.kind {
+	case targetClan:
+		var clan *coc.Clan
+		if clan, err = p.client.GetClan(t.tag); err == nil {
+			events = DiffClan(t.clan, clan, now)
+			t.clan = clan
+		}
+	case targetPlayer:
+		var player *coc.Player
+		if player, err = p.client.GetPlayer(t.tag); err == nil {
+			events = DiffPlayer(t.player, player, now)
+			t.player = player
+		}
+	case targetWar:
+		var war *coc.ClanWar
+		war, err = p.client.GetClanWarCurrent(t.tag)
+		if errors.Is(err, coc.ErrNotInWar) || errors.Is(err, coc.ErrInCWL) {
+			war, err = nil, nil
+		}
+		if err == nil {
+			if t.seen {
+				events = DiffWar(t.tag, t.war, war, now)
+			}
+			t.war = war
+		}
+	case targetRaid:
+		var seasons []coc.ClanCapitalRaidSeasion
+		seasons, _, err = p.client.ListCapitalRaidSeasons(t.tag, coc.QParms{Limit: 1})
+		if err == nil && len(seasons) > 0 {
+			events = DiffRaidSeason(t.tag, t.raid, &seasons[0], now)
+			t.raid = &seasons[0]
+		}
+	}
+	if err == nil {
+		t.seen = true
+	}
+
+	p.mu.Lock()
+	t.next = p.nextPollTime(t, now, err)
+	p.mu.Unlock()
+
+	return events, err
+}
+
+// nextPollTime returns the time at which the target should next be polled. When available,
+// this is when the server's cached response expires.
+func (p *Poller) nextPollTime(t *target, now time.Time, err error) time.Time {
+	next := now.Add(p.cfg.PollInterval)
+	if ce, ok := p.client.(cacheExpirer); ok && err == nil {
+		var expires time.Time
+		switch t.kind {
+		case targetClan:
+			expires = ce.ClanCacheExpiry(t.tag)
+		case targetPlayer:
+			expires = ce.PlayerCacheExpiry(t.tag)
+		case targetWar:
+			expires = ce.ClanWarCurrentCacheExpiry(t.tag)
+		case targetRaid:
+			expires = ce.CapitalRaidSeasonsCacheExpiry(t.tag)
+		}
+		if !expires.IsZero() {
+			next = expires
+		}
+	}
+	if min := now.Add(p.cfg.MinInterval); next.Before(min) {
+		next = min
+	}
+	return next
+}
+
+// deliver sends the events to the subscribers and, if requested, the events channel
+func (p *Poller) deliver(ctx context.Context, events []Event) error {
+	p.mu.Lock()
+	subscribers := p.subscribers
+	useChannel := p.useChannel
+	p.mu.Unlock()
+
+	for _, e := range events {
+		for _, fn := range subscribers {
+			fn(e)
+		}
+		if useChannel {
+			select {
+			case p.events <- e:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+	}
+	return nil
+}
+
+// reportError passes the error to the error handler, if one has been registered
+func (p *Poller) reportError(err error) {
+	p.mu.Lock()
+	onError := p.onError
+	p.mu.Unlock()
+	if onError != nil {
+		onError(err)
+	}
+}
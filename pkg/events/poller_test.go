@@ -0,0 +1,137 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/rbrabson/coc/v1"
+)
+
+// stubClient is a client that returns successive snapshots of a clan, and reports that each
+// response expires from the server's cache after a set time
+type stubClient struct {
+	mu      sync.Mutex
+	clans   []*coc.Clan
+	polls   int
+	expires time.Duration
+	err     error
+}
+
+func (c *stubClient) GetClan(clanTag string) (*coc.Clan, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.err != nil {
+		return nil, c.err
+	}
+	clan := c.clans[len(c.clans)-1]
+	if c.polls < len(c.clans) {
+		clan = c.clans[c.polls]
+	}
+	c.polls++
+	return clan, nil
+}
+
+func (c *stubClient) GetPlayer(playerTag string) (*coc.Player, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (c *stubClient) GetClanWarCurrent(clanTag string) (*coc.ClanWar, error) {
+	return nil, coc.ErrNotInWar
+}
+
+func (c *stubClient) ListCapitalRaidSeasons(clanTag string, qparms ...coc.QParms) ([]coc.ClanCapitalRaidSeasion, *coc.Paging, error) {
+	return nil, nil, nil
+}
+
+func (c *stubClient) expiry() time.Time {
+	if c.expires == 0 {
+		return time.Time{}
+	}
+	return time.Now().Add(c.expires)
+}
+
+func (c *stubClient) ClanCacheExpiry(clanTag string) time.Time               { return c.expiry() }
+func (c *stubClient) PlayerCacheExpiry(playerTag string) time.Time           { return c.expiry() }
+func (c *stubClient) ClanWarCurrentCacheExpiry(clanTag string) time.Time     { return c.expiry() }
+func (c *stubClient) CapitalRaidSeasonsCacheExpiry(clanTag string) time.Time { return c.expiry() }
+
+// noExpiryClient is a client that doesn't report when responses expire
+type noExpiryClient struct {
+	Client
+}
+
+func TestPollerNextPollTime(t *testing.T) {
+	cfg := PollerConfig{PollInterval: 2 * time.Minute, MinInterval: 30 * time.Second}
+	tests := []struct {
+		name   string
+		client Client
+		err    error
+		want   time.Duration
+	}{
+		{"cache expiry", &stubClient{expires: 90 * time.Second}, nil, 90 * time.Second},
+		{"expiry before the minimum interval", &stubClient{expires: 5 * time.Second}, nil, 30 * time.Second},
+		{"expiry after the poll interval", &stubClient{expires: 10 * time.Minute}, nil, 10 * time.Minute},
+		{"no expiry", &stubClient{}, nil, 2 * time.Minute},
+		{"error", &stubClient{expires: 90 * time.Second}, errors.New("failed"), 2 * time.Minute},
+		{"client without expiries", noExpiryClient{&stubClient{}}, nil, 2 * time.Minute},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := NewPoller(tt.client, cfg)
+			now := time.Now()
+			got := p.nextPollTime(&target{kind: targetClan, tag: "#2PP"}, now, tt.err).Sub(now)
+			if got < tt.want-time.Second || got > tt.want+time.Second {
+				t.Errorf("nextPollTime() = now + %v, want now + %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPollerRun(t *testing.T) {
+	before := &coc.Clan{Tag: "#2PP", MemberList: []coc.ClanMember{{Tag: "#PA"}}}
+	after := &coc.Clan{Tag: "#2PP", MemberList: []coc.ClanMember{{Tag: "#PA"}, {Tag: "#PB"}}}
+	client := &stubClient{clans: []*coc.Clan{before, before, after}, expires: 10 * time.Millisecond}
+	p := NewPoller(client, PollerConfig{PollInterval: time.Hour, MinInterval: time.Millisecond})
+	p.AddClan("#2PP")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	var got []string
+	p.Subscribe(func(e Event) {
+		got = append(got, summarize([]Event{e})...)
+		cancel()
+	})
+	if err := p.Run(ctx); err != context.Canceled {
+		t.Fatalf("Run() error = %v, want %v", err, context.Canceled)
+	}
+
+	// The poll interval is an hour, so the clan can only have been polled three times if each
+	// poll was scheduled from the cache expiry
+	if want := []string{"memberJoined #PB"}; len(got) != 1 || got[0] != want[0] {
+		t.Errorf("Run() delivered %v, want %v", got, want)
+	}
+	if client.polls != 3 {
+		t.Errorf("Run() polled the clan %d times, want 3", client.polls)
+	}
+}
+
+func TestPollerReportsErrors(t *testing.T) {
+	client := &stubClient{err: errors.New("failed")}
+	p := NewPoller(client, PollerConfig{PollInterval: time.Hour})
+	p.AddClan("#2PP")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	var errs []error
+	p.OnError(func(err error) {
+		errs = append(errs, err)
+		cancel()
+	})
+	p.Run(ctx)
+	if len(errs) != 1 || errs[0] != client.err {
+		t.Errorf("Run() reported errors %v, want %v", errs, client.err)
+	}
+}
@@ -0,0 +1,332 @@
+package legends
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/rbrabson/coc/pkg/log"
+	"github.com/rbrabson/coc/v1"
+)
+
+const (
+	// LegendLeagueID is the identifier of the Legend League
+	LegendLeagueID = 29000022
+
+	// legendDayResetHour is the hour, in UTC, at which each legend day starts
+	legendDayResetHour = 5
+	dateLayout         = "2006-01-02"
+
+	// seasonResetTrophies is the number of trophies to which players above it are reset at the
+	// end of each Legend League season
+	seasonResetTrophies = 5000
+	// maxDefenseLoss is the most trophies a player can lose defending during a legend day
+	maxDefenseLoss = 8 * 40
+)
+
+// HitType is the type of trophy change detected between two polls of a player.
+type HitType string
+
+const (
+	HitAttack  HitType = "attack"  // The player attacked
+	HitDefense HitType = "defense" // The player was attacked
+	HitMixed   HitType = "mixed"   // The player attacked and was attacked between polls
+)
+
+// Client is the set of Clash of Clans API calls used when polling players. It is satisfied by
+// *coc.Client.
+type Client interface {
+	GetPlayer(playerTag string) (*coc.Player, error)
+}
+
+// Hit is an attack or defense inferred from the change in a player's trophies and attack and
+// defense win counters. When an attack and a defense occur between two polls only the net
+// change in trophies is known, so the hit is reported as a mixed hit.
+type Hit struct {
+	Time     time.Time `json:"time"`
+	Type     HitType   `json:"type"`
+	Trophies int       `json:"trophies"`
+	Attacks  int       `json:"attacks"`
+	Defenses int       `json:"defenses"`
+}
+
+// String returns a string representation of a hit
+func (h Hit) String() string {
+	b, _ := json.Marshal(h)
+	return string(b)
+}
+
+// DayLog is a player's hits during a single legend day, which starts at 5:00 UTC.
+type DayLog struct {
+	Tag             string    `json:"tag"`
+	Name            string    `json:"name"`
+	Date            string    `json:"date"`
+	StartTime       time.Time `json:"startTime"`
+	StartTrophies   int       `json:"startTrophies"`
+	EndTrophies     int       `json:"endTrophies"`
+	Attacks         int       `json:"attacks"`
+	Defenses        int       `json:"defenses"`
+	AttackTrophies  int       `json:"attackTrophies"`
+	DefenseTrophies int       `json:"defenseTrophies"`
+	MixedTrophies   int       `json:"mixedTrophies"`
+	Hits            []Hit     `json:"hits"`
+}
+
+// String returns a string representation of a day log
+func (d DayLog) String() string {
+	b, _ := json.Marshal(d)
+	return string(b)
+}
+
+// NetTrophies returns the change in the player's trophies during the day.
+func (d DayLog) NetTrophies() int {
+	return d.EndTrophies - d.StartTrophies
+}
+
+// add includes the hit in the day's totals
+func (d *DayLog) add(h Hit) {
+	d.Hits = append(d.Hits, h)
+	d.Attacks += h.Attacks
+	d.Defenses += h.Defenses
+	switch h.Type {
+	case HitAttack:
+		d.AttackTrophies += h.Trophies
+	case HitDefense:
+		d.DefenseTrophies += h.Trophies
+	default:
+		d.MixedTrophies += h.Trophies
+	}
+}
+
+// playerState is the last known state of a player
+type playerState struct {
+	Trophies    int       `json:"trophies"`
+	AttackWins  int       `json:"attackWins"`
+	DefenseWins int       `json:"defenseWins"`
+	Season      string    `json:"season,omitempty"` // Identifier of the previous Legend League season
+	Time        time.Time `json:"time"`
+}
+
+// Tracker infers the attacks and defenses made by Legend League players by polling them at a
+// high cadence, and keeps a log of each player's hits for each legend day.
+type Tracker struct {
+	mu      sync.RWMutex
+	players map[string]*playerState
+	days    map[string][]DayLog
+}
+
+// NewTracker creates a new legend tracker.
+func NewTracker() *Tracker {
+	return &Tracker{
+		players: make(map[string]*playerState),
+		days:    make(map[string][]DayLog),
+	}
+}
+
+// LegendDay returns the start of the legend day that contains the given time.
+func LegendDay(at time.Time) time.Time {
+	at = at.UTC()
+	start := time.Date(at.Year(), at.Month(), at.Day(), legendDayResetHour, 0, 0, 0, time.UTC)
+	if at.Before(start) {
+		start = start.AddDate(0, 0, -1)
+	}
+	return start
+}
+
+// Update includes a snapshot of the player, such as one returned by coc.Client.GetPlayer, in the
+// player's log, and returns the hits inferred since the previous snapshot. Players that aren't
+// in the Legend League are ignored. No hits are inferred across the end of a season, when the
+// player's trophies are reset; the next day's log starts with the trophies after the reset.
+func (t *Tracker) Update(at time.Time, p coc.Player) []Hit {
+	if p.League.ID != LegendLeagueID {
+		return nil
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	tag := coc.NormalizeTag(p.Tag)
+	state := &playerState{
+		Trophies:    p.Trophies,
+		AttackWins:  p.AttackWins,
+		DefenseWins: p.DefenseWins,
+		Season:      p.LegendStatistics.PreviousSeason.ID,
+		Time:        at,
+	}
+	prev, ok := t.players[tag]
+	t.players[tag] = state
+	if ok && seasonEnded(prev, state) {
+		prev = nil
+	}
+
+	// Start a new log for the legend day if required
+	day := t.day(tag, p.Name, at, prev)
+	day.EndTrophies = p.Trophies
+	if prev == nil {
+		return nil
+	}
+
+	hits := inferHits(prev, state)
+	for _, h := range hits {
+		day.add(h)
+	}
+	return hits
+}
+
+// Days returns the player's daily logs, ordered by date.
+func (t *Tracker) Days(playerTag string) []DayLog {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	days := t.days[coc.NormalizeTag(playerTag)]
+	list := make([]DayLog, len(days))
+	copy(list, days)
+	return list
+}
+
+// Day returns the player's log for the legend day containing the given time.
+func (t *Tracker) Day(playerTag string, at time.Time) (DayLog, bool) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	date := LegendDay(at).Format(dateLayout)
+	for _, d := range t.days[coc.NormalizeTag(playerTag)] {
+		if d.Date == date {
+			return d, true
+		}
+	}
+	return DayLog{}, false
+}
+
+// Poll updates the tracker with each of the players at the given interval until the context is
+// cancelled. Errors are passed to onError, if provided.
+func (t *Tracker) Poll(ctx context.Context, client Client, playerTags []string, interval time.Duration, onError func(error)) error {
+	const M = "Tracker.Poll"
+	l := log.New()
+	defer l.Sync()
+
+	l.Debugf("--> %s", M)
+	defer l.Debugf("<-- %s", M)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		for _, tag := range playerTags {
+			p, err := client.GetPlayer(tag)
+			if err != nil {
+				if onError != nil {
+					onError(err)
+				}
+				continue
+			}
+			t.Update(time.Now(), *p)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// trackerState is the persisted state of a tracker
+type trackerState struct {
+	Players map[string]*playerState `json:"players"`
+	Days    map[string][]DayLog     `json:"days"`
+}
+
+// Save writes the tracker's state to the writer as JSON.
+func (t *Tracker) Save(w io.Writer) error {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return json.NewEncoder(w).Encode(trackerState{Players: t.players, Days: t.days})
+}
+
+// Load reads a tracker's state, previously written using Save, from the reader.
+func Load(r io.Reader) (*Tracker, error) {
+	var state trackerState
+	if err := json.NewDecoder(r).Decode(&state); err != nil {
+		return nil, err
+	}
+	t := NewTracker()
+	for k, v := range state.Players {
+		t.players[k] = v
+	}
+	for k, v := range state.Days {
+		t.days[k] = v
+	}
+	return t, nil
+}
+
+// day returns the player's log for the legend day containing the time, creating it if needed.
+// A new day starts with the trophies the player had at the previous poll.
+func (t *Tracker) day(tag string, name string, at time.Time, prev *playerState) *DayLog {
+	start := LegendDay(at)
+	date := start.Format(dateLayout)
+	days := t.days[tag]
+	if n := len(days); n > 0 && days[n-1].Date == date {
+		days[n-1].Name = name
+		return &days[n-1]
+	}
+
+	trophies := t.players[tag].Trophies
+	if prev != nil {
+		trophies = prev.Trophies
+	}
+	t.days[tag] = append(days, DayLog{
+		Tag:           tag,
+		Name:          name,
+		Date:          date,
+		StartTime:     start,
+		StartTrophies: trophies,
+		EndTrophies:   trophies,
+	})
+	return &t.days[tag][len(t.days[tag])-1]
+}
+
+// seasonEnded returns an indication as to whether a Legend League season ended between two polls
+// of a player. This is the case if the identifier of the previous season changed, the attack
+// or defense win counters were reset, or the player's trophies dropped to the reset level by
+// more than could be lost defending.
+func seasonEnded(prev, cur *playerState) bool {
+	switch {
+	case prev.Season != "" && cur.Season != "" && prev.Season != cur.Season:
+		return true
+	case cur.AttackWins < prev.AttackWins || cur.DefenseWins < prev.DefenseWins:
+		return true
+	default:
+		return cur.Trophies == seasonResetTrophies && prev.Trophies-cur.Trophies > maxDefenseLoss
+	}
+}
+
+// inferHits infers the hits made between two polls of a player. In the Legend League attacks
+// gain trophies and defenses lose them. The attack win counter increases for each attack that
+// earns a star, and the defense win counter for each defense where the attacker earned none.
+// If both an attack and a defense were made, only the net change in trophies is known, so a
+// single mixed hit is reported.
+func inferHits(prev, cur *playerState) []Hit {
+	delta := cur.Trophies - prev.Trophies
+	attackWins := cur.AttackWins - prev.AttackWins
+	defenseWins := cur.DefenseWins - prev.DefenseWins
+
+	var hits []Hit
+	switch {
+	case attackWins > 0 && defenseWins > 0:
+		// The counters show both an attack and a defense
+		return []Hit{{Time: cur.Time, Type: HitMixed, Trophies: delta, Attacks: attackWins, Defenses: defenseWins}}
+	case attackWins > 0 && delta <= 0:
+		// An attack gained trophies, but a defense lost at least as many
+		return []Hit{{Time: cur.Time, Type: HitMixed, Trophies: delta, Attacks: attackWins, Defenses: 1}}
+	case attackWins > 0:
+		hits = append(hits, Hit{Time: cur.Time, Type: HitAttack, Trophies: delta, Attacks: attackWins})
+	case delta > 0:
+		hits = append(hits, Hit{Time: cur.Time, Type: HitAttack, Trophies: delta, Attacks: 1})
+	case delta < 0:
+		hits = append(hits, Hit{Time: cur.Time, Type: HitDefense, Trophies: delta, Defenses: 1})
+	}
+	if defenseWins > 0 {
+		hits = append(hits, Hit{Time: cur.Time, Type: HitDefense, Trophies: 0, Defenses: defenseWins})
+	}
+	return hits
+}
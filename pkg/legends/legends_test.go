@@ -0,0 +1,172 @@
+package legends
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/rbrabson/coc/v1"
+)
+
+// player returns a Legend League player with the given trophies, win counters and previous season
+func player(trophies, attackWins, defenseWins int, season string) coc.Player {
+	p := coc.Player{
+		Tag:         "#P1",
+		Name:        "Player",
+		Trophies:    trophies,
+		AttackWins:  attackWins,
+		DefenseWins: defenseWins,
+	}
+	p.League.ID = LegendLeagueID
+	p.LegendStatistics.PreviousSeason.ID = season
+	return p
+}
+
+func TestUpdate(t *testing.T) {
+	// The season ends at the start of the legend day on the last Monday of the month
+	end := time.Date(2024, 3, 25, legendDayResetHour, 0, 0, 0, time.UTC)
+	type poll struct {
+		at     time.Time
+		player coc.Player
+	}
+	tests := []struct {
+		name      string
+		polls     []poll
+		wantHits  []HitType
+		wantStart int
+		wantEnd   int
+	}{
+		{
+			name: "attack and defense",
+			polls: []poll{
+				{end.Add(time.Hour), player(5300, 10, 2, "2024-02")},
+				{end.Add(2 * time.Hour), player(5340, 11, 2, "2024-02")},
+				{end.Add(3 * time.Hour), player(5310, 11, 2, "2024-02")},
+			},
+			wantHits:  []HitType{HitAttack, HitDefense},
+			wantStart: 5300,
+			wantEnd:   5310,
+		},
+		{
+			name: "attack and defense win between polls",
+			polls: []poll{
+				{end.Add(time.Hour), player(5300, 10, 2, "2024-02")},
+				{end.Add(2 * time.Hour), player(5335, 11, 3, "2024-02")},
+			},
+			wantHits:  []HitType{HitMixed},
+			wantStart: 5300,
+			wantEnd:   5335,
+		},
+		{
+			name: "attack and defense loss between polls",
+			polls: []poll{
+				{end.Add(time.Hour), player(5300, 10, 2, "2024-02")},
+				{end.Add(2 * time.Hour), player(5290, 11, 2, "2024-02")},
+			},
+			wantHits:  []HitType{HitMixed},
+			wantStart: 5300,
+			wantEnd:   5290,
+		},
+		{
+			name: "defense win",
+			polls: []poll{
+				{end.Add(time.Hour), player(5300, 10, 2, "2024-02")},
+				{end.Add(2 * time.Hour), player(5300, 10, 3, "2024-02")},
+			},
+			wantHits:  []HitType{HitDefense},
+			wantStart: 5300,
+			wantEnd:   5300,
+		},
+		{
+			name: "season identifier changed",
+			polls: []poll{
+				{end.Add(-time.Hour), player(5600, 80, 3, "2024-02")},
+				{end.Add(time.Hour), player(5000, 80, 3, "2024-03")},
+				{end.Add(2 * time.Hour), player(5040, 81, 3, "2024-03")},
+			},
+			wantHits:  []HitType{HitAttack},
+			wantStart: 5000,
+			wantEnd:   5040,
+		},
+		{
+			name: "win counters reset",
+			polls: []poll{
+				{end.Add(-time.Hour), player(5600, 80, 3, "")},
+				{end.Add(time.Hour), player(5000, 0, 0, "")},
+				{end.Add(2 * time.Hour), player(4970, 0, 0, "")},
+			},
+			wantHits:  []HitType{HitDefense},
+			wantStart: 5000,
+			wantEnd:   4970,
+		},
+		{
+			name: "trophies reset",
+			polls: []poll{
+				{end.Add(-time.Hour), player(5600, 80, 3, "")},
+				{end.Add(time.Hour), player(5000, 80, 3, "")},
+			},
+			wantHits:  nil,
+			wantStart: 5000,
+			wantEnd:   5000,
+		},
+		{
+			name: "defense down to the reset level",
+			polls: []poll{
+				{end.Add(time.Hour), player(5030, 10, 2, "")},
+				{end.Add(2 * time.Hour), player(5000, 10, 2, "")},
+			},
+			wantHits:  []HitType{HitDefense},
+			wantStart: 5030,
+			wantEnd:   5000,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tracker := NewTracker()
+			var hits []HitType
+			for _, p := range tt.polls {
+				for _, h := range tracker.Update(p.at, p.player) {
+					hits = append(hits, h.Type)
+				}
+			}
+			if !reflect.DeepEqual(hits, tt.wantHits) {
+				t.Errorf("Update() hits = %v, want %v", hits, tt.wantHits)
+			}
+
+			day, ok := tracker.Day("#P1", end.Add(time.Hour))
+			if !ok {
+				t.Fatal("Day() didn't find the legend day")
+			}
+			if day.StartTrophies != tt.wantStart || day.EndTrophies != tt.wantEnd {
+				t.Errorf("Day() trophies = %d to %d, want %d to %d", day.StartTrophies, day.EndTrophies, tt.wantStart, tt.wantEnd)
+			}
+		})
+	}
+}
+
+func TestInferHits(t *testing.T) {
+	state := func(trophies, attackWins, defenseWins int) *playerState {
+		return &playerState{Trophies: trophies, AttackWins: attackWins, DefenseWins: defenseWins}
+	}
+	tests := []struct {
+		name string
+		cur  *playerState
+		want []Hit
+	}{
+		{"no change", state(5300, 10, 2), nil},
+		{"attack", state(5340, 11, 2), []Hit{{Type: HitAttack, Trophies: 40, Attacks: 1}}},
+		{"attacks", state(5370, 12, 2), []Hit{{Type: HitAttack, Trophies: 70, Attacks: 2}}},
+		{"defense loss", state(5270, 10, 2), []Hit{{Type: HitDefense, Trophies: -30, Defenses: 1}}},
+		{"defense win", state(5300, 10, 3), []Hit{{Type: HitDefense, Defenses: 1}}},
+		{"attack and defense win", state(5335, 11, 3), []Hit{{Type: HitMixed, Trophies: 35, Attacks: 1, Defenses: 1}}},
+		{"attack and defense loss", state(5290, 11, 2), []Hit{{Type: HitMixed, Trophies: -10, Attacks: 1, Defenses: 1}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := inferHits(state(5300, 10, 2), tt.cur)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("inferHits() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,263 @@
+// Package progress calculates how far a player's account has progressed towards maxing its
+// troops, spells, heroes, pets and hero equipment, and how "rushed" the account is. An account
+// is rushed when it upgraded its town hall well before maxing the units for the previous town
+// hall.
+package progress
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/rbrabson/coc/pkg/staticdata"
+	"github.com/rbrabson/coc/v1"
+)
+
+// Group is a group of units for which progress is calculated.
+type Group string
+
+const (
+	GroupHeroes    Group = "heroes"
+	GroupTroops    Group = "troops"
+	GroupSpells    Group = "spells"
+	GroupPets      Group = "pets"
+	GroupEquipment Group = "equipment"
+)
+
+var (
+	// DefaultRushedThreshold is the rushed score above which an account is considered rushed.
+	// An account that has yet to complete more than 10 percent of the levels available at the
+	// previous town hall is rushed, while one that is missing a few levels is not.
+	DefaultRushedThreshold = 10.0
+
+	// groups are the unit categories in each group
+	groups = []struct {
+		group      Group
+		categories []staticdata.Category
+	}{
+		{GroupHeroes, []staticdata.Category{staticdata.CategoryHero}},
+		{GroupTroops, []staticdata.Category{staticdata.CategoryTroop, staticdata.CategorySiegeMachine}},
+		{GroupSpells, []staticdata.Category{staticdata.CategorySpell}},
+		{GroupPets, []staticdata.Category{staticdata.CategoryPet}},
+		{GroupEquipment, []staticdata.Category{staticdata.CategoryEquipment}},
+	}
+
+	// rushedGroups are the groups used when calculating the rushed score. Equipment isn't
+	// included, as pieces of equipment are acquired independently of the town hall level.
+	rushedGroups = map[Group]bool{
+		GroupHeroes: true,
+		GroupTroops: true,
+		GroupSpells: true,
+		GroupPets:   true,
+	}
+)
+
+// UnitProgress is a unit's level compared to its maximum level at a town hall level.
+type UnitProgress struct {
+	Name     string `json:"name"`
+	Level    int    `json:"level"`
+	MaxLevel int    `json:"maxLevel"`
+}
+
+// String returns a string representation of unit progress
+func (u UnitProgress) String() string {
+	b, _ := json.Marshal(u)
+	return string(b)
+}
+
+// GroupProgress is the progress of a group of units towards their maximum levels at a town hall
+// level. Levels above the maximum for the town hall level aren't counted.
+type GroupProgress struct {
+	Group     Group          `json:"group"`
+	Levels    int            `json:"levels"`
+	MaxLevels int            `json:"maxLevels"`
+	Percent   float64        `json:"percent"`
+	Units     []UnitProgress `json:"units"`
+}
+
+// String returns a string representation of group progress
+func (g GroupProgress) String() string {
+	b, _ := json.Marshal(g)
+	return string(b)
+}
+
+// TownHallProgress is the progress of each group of units at a town hall level.
+type TownHallProgress struct {
+	TownHallLevel int             `json:"townHallLevel"`
+	Groups        []GroupProgress `json:"groups"`
+	Levels        int             `json:"levels"`
+	MaxLevels     int             `json:"maxLevels"`
+	Percent       float64         `json:"percent"`
+}
+
+// String returns a string representation of town hall progress
+func (t TownHallProgress) String() string {
+	b, _ := json.Marshal(t)
+	return string(b)
+}
+
+// Group returns the progress for the group.
+func (t TownHallProgress) Group(group Group) GroupProgress {
+	for _, g := range t.Groups {
+		if g.Group == group {
+			return g
+		}
+	}
+	return GroupProgress{Group: group}
+}
+
+// Remaining is the estimated cost and time to max all units at the player's town hall level.
+// Complete is false if the static data doesn't include the upgrade cost for every remaining
+// upgrade, in which case the estimate only includes the upgrades whose costs are known.
+type Remaining struct {
+	Resources map[string]int `json:"resources"`
+	Time      time.Duration  `json:"time"`
+	Upgrades  int            `json:"upgrades"`
+	Complete  bool           `json:"complete"`
+}
+
+// String returns a string representation of the remaining upgrades
+func (r Remaining) String() string {
+	b, _ := json.Marshal(r)
+	return string(b)
+}
+
+// Report is a player's upgrade progress at their current and previous town hall levels.
+// RushedScore is the percentage of the previous town hall's hero, troop, spell and pet levels
+// that the player has yet to complete, so a score of zero means every unit is maxed for the
+// previous town hall. Rushed is set if the score is above DefaultRushedThreshold; IsRushed may
+// be used to apply a different threshold.
+type Report struct {
+	Tag         string           `json:"tag"`
+	Name        string           `json:"name"`
+	Current     TownHallProgress `json:"current"`
+	Previous    TownHallProgress `json:"previous"`
+	RushedScore float64          `json:"rushedScore"`
+	Rushed      bool             `json:"rushed"`
+	Remaining   Remaining        `json:"remaining"`
+}
+
+// String returns a string representation of a progress report
+func (r Report) String() string {
+	b, _ := json.Marshal(r)
+	return string(b)
+}
+
+// IsRushed returns an indication as to whether the account's rushed score is above the
+// threshold, which is a percentage of the previous town hall's levels.
+func (r Report) IsRushed(threshold float64) bool {
+	return r.RushedScore > threshold
+}
+
+// Calculate returns the upgrade progress for the player, such as one returned by
+// coc.Client.GetPlayer. If data is nil, the bundled static data is used. Units that are
+// available at a town hall level but haven't been unlocked by the player count as level zero,
+// except for hero equipment where only the pieces the player owns are included.
+func Calculate(p coc.Player, data *staticdata.Data) Report {
+	if data == nil {
+		data = staticdata.Default()
+	}
+
+	levels := make(map[string]int)
+	for _, list := range [][]coc.Troop{p.Troops, p.Spells, p.Heroes, p.HeroEquipment} {
+		for _, t := range list {
+			if t.Village == "" || t.Village == staticdata.VillageHome {
+				levels[t.Name] = t.Level
+			}
+		}
+	}
+
+	report := Report{
+		Tag:      p.Tag,
+		Name:     p.Name,
+		Current:  townHallProgress(data, levels, p.TownHallLevel, false),
+		Previous: townHallProgress(data, levels, p.TownHallLevel-1, false),
+	}
+
+	rushed := townHallProgress(data, levels, p.TownHallLevel-1, true)
+	if rushed.MaxLevels > 0 {
+		report.RushedScore = 100 * float64(rushed.MaxLevels-rushed.Levels) / float64(rushed.MaxLevels)
+	}
+	report.Rushed = report.IsRushed(DefaultRushedThreshold)
+	report.Remaining = remaining(data, levels, p.TownHallLevel)
+
+	return report
+}
+
+// townHallProgress calculates the progress of each group at the town hall level. If rushedOnly
+// is set, only the groups used for the rushed score are included.
+func townHallProgress(data *staticdata.Data, levels map[string]int, townHallLevel int, rushedOnly bool) TownHallProgress {
+	thp := TownHallProgress{TownHallLevel: townHallLevel}
+	if townHallLevel < 1 {
+		return thp
+	}
+
+	for _, g := range groups {
+		if rushedOnly && !rushedGroups[g.group] {
+			continue
+		}
+		gp := GroupProgress{Group: g.group}
+		for _, category := range g.categories {
+			for _, u := range data.UnitsAt(category, townHallLevel) {
+				if u.Village != staticdata.VillageHome {
+					continue
+				}
+				level, owned := levels[u.Name]
+				if category == staticdata.CategoryEquipment && !owned {
+					continue
+				}
+				max := u.MaxLevelAt(townHallLevel)
+				if level > max {
+					level = max
+				}
+				gp.Levels += level
+				gp.MaxLevels += max
+				gp.Units = append(gp.Units, UnitProgress{Name: u.Name, Level: levels[u.Name], MaxLevel: max})
+			}
+		}
+		gp.Percent = percent(gp.Levels, gp.MaxLevels)
+		thp.Levels += gp.Levels
+		thp.MaxLevels += gp.MaxLevels
+		thp.Groups = append(thp.Groups, gp)
+	}
+	thp.Percent = percent(thp.Levels, thp.MaxLevels)
+
+	return thp
+}
+
+// remaining estimates the cost and time to max all home village units at the town hall level
+func remaining(data *staticdata.Data, levels map[string]int, townHallLevel int) Remaining {
+	r := Remaining{Resources: make(map[string]int), Complete: true}
+	for _, g := range groups {
+		for _, category := range g.categories {
+			for _, u := range data.UnitsAt(category, townHallLevel) {
+				if u.Village != staticdata.VillageHome {
+					continue
+				}
+				level, owned := levels[u.Name]
+				if category == staticdata.CategoryEquipment && !owned {
+					continue
+				}
+				max := u.MaxLevelAt(townHallLevel)
+				if level >= max {
+					continue
+				}
+				r.Upgrades += max - level
+				cost, d, ok := u.UpgradeCost(level, max)
+				r.Resources[u.Resource] += cost
+				r.Time += d
+				if !ok {
+					r.Complete = false
+				}
+			}
+		}
+	}
+	return r
+}
+
+// percent returns the percentage of levels that have been completed
+func percent(levels, maxLevels int) float64 {
+	if maxLevels == 0 {
+		return 100
+	}
+	return 100 * float64(levels) / float64(maxLevels)
+}
@@ -0,0 +1,200 @@
+package progress
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rbrabson/coc/pkg/staticdata"
+	"github.com/rbrabson/coc/v1"
+)
+
+// newPlayer returns a player at the town hall level with each hero, troop, spell and pet
+// available at that level set to the level returned by the function
+func newPlayer(data *staticdata.Data, townHallLevel int, level func(u staticdata.Unit) int) coc.Player {
+	p := coc.Player{Tag: "#P1", Name: "Player", TownHallLevel: townHallLevel}
+	categories := []staticdata.Category{
+		staticdata.CategoryHero,
+		staticdata.CategoryTroop,
+		staticdata.CategorySiegeMachine,
+		staticdata.CategorySpell,
+		staticdata.CategoryPet,
+	}
+	for _, category := range categories {
+		for _, u := range data.UnitsAt(category, townHallLevel) {
+			if u.Village != staticdata.VillageHome {
+				continue
+			}
+			p.Troops = append(p.Troops, coc.Troop{Name: u.Name, Level: level(u), Village: u.Village})
+		}
+	}
+	return p
+}
+
+func TestCalculateRushed(t *testing.T) {
+	data := staticdata.Default()
+	tests := []struct {
+		name       string
+		level      func(u staticdata.Unit) int
+		wantScore  bool
+		wantRushed bool
+	}{
+		{
+			name:  "maxed for the previous town hall",
+			level: func(u staticdata.Unit) int { return u.MaxLevelAt(11) },
+		},
+		{
+			name:  "maxed for the current town hall",
+			level: func(u staticdata.Unit) int { return u.MaxLevelAt(12) },
+		},
+		{
+			name: "a few levels short",
+			level: func(u staticdata.Unit) int {
+				if u.Category == staticdata.CategoryHero {
+					return u.MaxLevelAt(11) - 2
+				}
+				return u.MaxLevelAt(11)
+			},
+			wantScore: true,
+		},
+		{
+			name: "heroes not upgraded",
+			level: func(u staticdata.Unit) int {
+				if u.Category == staticdata.CategoryHero {
+					return 1
+				}
+				return u.MaxLevelAt(11)
+			},
+			wantScore:  true,
+			wantRushed: true,
+		},
+		{
+			name:       "maxed two town halls ago",
+			level:      func(u staticdata.Unit) int { return u.MaxLevelAt(10) },
+			wantScore:  true,
+			wantRushed: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report := Calculate(newPlayer(data, 12, tt.level), data)
+			if (report.RushedScore > 0) != tt.wantScore {
+				t.Errorf("Calculate() rushed score = %.2f, want a score %v", report.RushedScore, tt.wantScore)
+			}
+			if report.Rushed != tt.wantRushed {
+				t.Errorf("Calculate() rushed = %v with score %.2f, want %v", report.Rushed, report.RushedScore, tt.wantRushed)
+			}
+			if report.Rushed != report.IsRushed(DefaultRushedThreshold) {
+				t.Errorf("Calculate() rushed = %v, IsRushed() = %v", report.Rushed, report.IsRushed(DefaultRushedThreshold))
+			}
+		})
+	}
+}
+
+func TestIsRushed(t *testing.T) {
+	tests := []struct {
+		name      string
+		score     float64
+		threshold float64
+		want      bool
+	}{
+		{"not rushed", 0, DefaultRushedThreshold, false},
+		{"at the threshold", 10, 10, false},
+		{"above the threshold", 10.5, 10, true},
+		{"strict threshold", 2, 1, true},
+		{"lenient threshold", 20, 25, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := (Report{RushedScore: tt.score}).IsRushed(tt.threshold); got != tt.want {
+				t.Errorf("IsRushed(%v) = %v, want %v", tt.threshold, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCalculateRemaining(t *testing.T) {
+	data := staticdata.Default()
+	tests := []struct {
+		name         string
+		level        func(u staticdata.Unit) int
+		wantUpgrades bool
+	}{
+		{
+			name:         "maxed for the previous town hall",
+			level:        func(u staticdata.Unit) int { return u.MaxLevelAt(11) },
+			wantUpgrades: true,
+		},
+		{
+			name:         "nothing unlocked",
+			level:        func(u staticdata.Unit) int { return 0 },
+			wantUpgrades: true,
+		},
+		{
+			name:  "maxed for the current town hall",
+			level: func(u staticdata.Unit) int { return u.MaxLevelAt(12) },
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := Calculate(newPlayer(data, 12, tt.level), data).Remaining
+			if !r.Complete {
+				t.Errorf("Calculate() remaining = %v, want a complete estimate", r)
+			}
+			if !tt.wantUpgrades {
+				if r.Upgrades != 0 || r.Time != 0 {
+					t.Errorf("Calculate() remaining = %v, want no upgrades", r)
+				}
+				return
+			}
+			if r.Upgrades == 0 || r.Time <= 0 {
+				t.Errorf("Calculate() remaining = %v, want upgrades", r)
+			}
+			for _, resource := range []string{"elixir", "darkElixir"} {
+				if r.Resources[resource] <= 0 {
+					t.Errorf("Calculate() remaining %s = %d, want a cost", resource, r.Resources[resource])
+				}
+			}
+		})
+	}
+}
+
+func TestCalculateHomeVillageOnly(t *testing.T) {
+	data, err := staticdata.Load(strings.NewReader(`{
+		"version": "test",
+		"maxTownHall": 2,
+		"units": [
+			{"name": "Barbarian", "category": "troop", "village": "home", "unlockTownHall": 1, "resource": "elixir", "maxLevels": [1, 2],
+				"levels": [{"level": 1, "townHall": 1}, {"level": 2, "cost": 100, "time": 60, "townHall": 2}]},
+			{"name": "Barbarian", "category": "troop", "village": "builderBase", "unlockTownHall": 1, "resource": "builderElixir", "maxLevels": [4, 8],
+				"levels": [{"level": 1, "townHall": 1}, {"level": 2, "cost": 1000, "time": 600, "townHall": 1}]},
+			{"name": "Battle Machine", "category": "hero", "village": "builderBase", "unlockTownHall": 1, "resource": "builderElixir", "maxLevels": [5, 10]}
+		]
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := coc.Player{
+		TownHallLevel: 2,
+		Troops: []coc.Troop{
+			{Name: "Barbarian", Level: 1, Village: staticdata.VillageHome},
+			{Name: "Barbarian", Level: 3, Village: staticdata.VillageBuilderBase},
+		},
+		Heroes: []coc.Troop{
+			{Name: "Battle Machine", Level: 2, Village: staticdata.VillageBuilderBase},
+		},
+	}
+
+	report := Calculate(p, data)
+	want := Remaining{Resources: map[string]int{"elixir": 100}, Time: time.Minute, Upgrades: 1, Complete: true}
+	if !reflect.DeepEqual(report.Remaining, want) {
+		t.Errorf("Calculate() remaining = %v, want %v", report.Remaining, want)
+	}
+	if report.Current.Levels != 1 || report.Current.MaxLevels != 2 {
+		t.Errorf("Calculate() current levels = %d of %d, want 1 of 2", report.Current.Levels, report.Current.MaxLevels)
+	}
+	if report.RushedScore != 0 {
+		t.Errorf("Calculate() rushed score = %v, want 0", report.RushedScore)
+	}
+}
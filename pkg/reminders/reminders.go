@@ -0,0 +1,229 @@
+package reminders
+
+import (
+	"encoding/json"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/rbrabson/coc/v1"
+)
+
+var (
+	// DefaultOffsets are the times before the end of a war at which reminders are sent.
+	DefaultOffsets = []time.Duration{4 * time.Hour, 1 * time.Hour, 15 * time.Minute}
+)
+
+// UnusedAttacks are the attacks a member has yet to make in a clan war.
+type UnusedAttacks struct {
+	Tag         string `json:"tag"`
+	Name        string `json:"name"`
+	MapPosition int    `json:"mapPosition"`
+	Used        int    `json:"used"`
+	Remaining   int    `json:"remaining"`
+}
+
+// String returns a string representation of a member's unused attacks
+func (u UnusedAttacks) String() string {
+	b, _ := json.Marshal(u)
+	return string(b)
+}
+
+// Reminder is a reminder, sent a period of time before a clan war ends, listing the members
+// who have yet to use all their attacks.
+type Reminder struct {
+	ClanTag     string          `json:"clanTag"`
+	ClanName    string          `json:"clanName"`
+	OpponentTag string          `json:"opponentTag"`
+	WarType     coc.WarType     `json:"warType"`
+	Before      time.Duration   `json:"before"`
+	Time        coc.Time        `json:"time"`
+	EndTime     coc.Time        `json:"endTime"`
+	Members     []UnusedAttacks `json:"members,omitempty"`
+}
+
+// String returns a string representation of a reminder
+func (r Reminder) String() string {
+	b, _ := json.Marshal(r)
+	return string(b)
+}
+
+// FindUnusedAttacks returns the members of the war's Clan that have not used all of their
+// attacks, ordered by map position. The number of attacks each member may make depends
+// on the type of war.
+func FindUnusedAttacks(war coc.ClanWar) []UnusedAttacks {
+	maxAttacks := war.MaxAttacksPerMember()
+	unused := make([]UnusedAttacks, 0, len(war.Clan.Members))
+	for _, member := range war.Clan.Members {
+		used := len(member.Attacks)
+		if used >= maxAttacks {
+			continue
+		}
+		unused = append(unused, UnusedAttacks{
+			Tag:         member.Tag,
+			Name:        member.Name,
+			MapPosition: member.MapPosition,
+			Used:        used,
+			Remaining:   maxAttacks - used,
+		})
+	}
+	sort.Slice(unused, func(i, j int) bool {
+		return unused[i].MapPosition < unused[j].MapPosition
+	})
+	return unused
+}
+
+// warKey uniquely identifies a war
+type warKey struct {
+	clanTag          string
+	opponentTag      string
+	preparationStart int64
+	end              int64
+}
+
+// Scheduler determines when reminders are due for clan wars. Snapshots of each war, such as
+// those retrieved by repeatedly calling coc.Client.GetClanWarCurrent, are passed to Update,
+// which sends any reminders that have become due to the subscribers. Each reminder is sent
+// once per war, and only includes those members who still have attacks remaining when the
+// reminder is sent. The reminders sent for a war are forgotten once the war ends, even if the
+// war is never seen ending.
+type Scheduler struct {
+	mu          sync.Mutex
+	offsets     []time.Duration
+	clock       coc.Clock
+	sent        map[warKey]map[time.Duration]bool
+	subscribers []func(Reminder)
+}
+
+// NewScheduler creates a scheduler that sends reminders at the given times before the end of
+// each war. If no offsets are provided, DefaultOffsets are used. If the clock is nil, the
+// system clock is used.
+func NewScheduler(clock coc.Clock, offsets ...time.Duration) *Scheduler {
+	if len(offsets) == 0 {
+		offsets = DefaultOffsets
+	}
+	if clock == nil {
+		clock = coc.SystemClock
+	}
+
+	// Order the offsets from the earliest reminder to the last one
+	sorted := make([]time.Duration, len(offsets))
+	copy(sorted, offsets)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i] > sorted[j]
+	})
+
+	return &Scheduler{
+		offsets: sorted,
+		clock:   clock,
+		sent:    make(map[warKey]map[time.Duration]bool),
+	}
+}
+
+// Subscribe registers a function that is called with each reminder when it becomes due.
+func (s *Scheduler) Subscribe(fn func(Reminder)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.subscribers = append(s.subscribers, fn)
+}
+
+// Schedule returns the reminders for the war that have yet to be sent, in the order they will
+// be sent. The members in each reminder are those with attacks remaining in the war snapshot.
+func (s *Scheduler) Schedule(war coc.ClanWar) []Reminder {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if war.EndTime.IsZero() {
+		return nil
+	}
+	now := s.clock.Now()
+	sent := s.sent[keyOf(war)]
+	members := FindUnusedAttacks(war)
+	reminders := make([]Reminder, 0, len(s.offsets))
+	for _, offset := range s.offsets {
+		at := war.EndTime.Add(-offset)
+		if sent[offset] || !at.Time().After(now) {
+			continue
+		}
+		reminders = append(reminders, newReminder(war, offset, members))
+	}
+	return reminders
+}
+
+// Update processes a new snapshot of the war, and sends any reminders that are due to the
+// subscribers. The reminders that were sent are returned. If several reminders are due, such
+// as when the war is first seen shortly before it ends, only the last of them is sent. No
+// reminders are sent once all members have used their attacks.
+func (s *Scheduler) Update(war coc.ClanWar) []Reminder {
+	s.mu.Lock()
+	now := s.clock.Now()
+	s.prune(now)
+	if war.Phase(s.clock) != coc.WarPhaseInWar {
+		s.mu.Unlock()
+		return nil
+	}
+
+	key := keyOf(war)
+	sent, ok := s.sent[key]
+	if !ok {
+		sent = make(map[time.Duration]bool)
+		s.sent[key] = sent
+	}
+	var due []time.Duration
+	for _, offset := range s.offsets {
+		if sent[offset] || war.EndTime.Add(-offset).Time().After(now) {
+			continue
+		}
+		sent[offset] = true
+		due = append(due, offset)
+	}
+	subscribers := s.subscribers
+	s.mu.Unlock()
+
+	if len(due) == 0 {
+		return nil
+	}
+	members := FindUnusedAttacks(war)
+	if len(members) == 0 {
+		return nil
+	}
+	reminder := newReminder(war, due[len(due)-1], members)
+	for _, fn := range subscribers {
+		fn(reminder)
+	}
+	return []Reminder{reminder}
+}
+
+// prune removes the record of the reminders sent for wars that have ended. The caller must
+// hold the lock.
+func (s *Scheduler) prune(now time.Time) {
+	for key := range s.sent {
+		if key.end <= now.Unix() {
+			delete(s.sent, key)
+		}
+	}
+}
+
+// newReminder creates a reminder for the war
+func newReminder(war coc.ClanWar, offset time.Duration, members []UnusedAttacks) Reminder {
+	return Reminder{
+		ClanTag:     war.Clan.Tag,
+		ClanName:    war.Clan.Name,
+		OpponentTag: war.Opponent.Tag,
+		WarType:     war.WarType(),
+		Before:      offset,
+		Time:        war.EndTime.Add(-offset),
+		EndTime:     war.EndTime,
+		Members:     members,
+	}
+}
+
+// keyOf returns the key that identifies the war
+func keyOf(war coc.ClanWar) warKey {
+	return warKey{
+		clanTag:          coc.NormalizeTag(war.Clan.Tag),
+		opponentTag:      coc.NormalizeTag(war.Opponent.Tag),
+		preparationStart: war.PreparationStartTime.Time().Unix(),
+		end:              war.EndTime.Time().Unix(),
+	}
+}
@@ -0,0 +1,169 @@
+package reminders
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/rbrabson/coc/v1"
+)
+
+// fakeClock is a clock whose time is set by the test
+type fakeClock struct {
+	now time.Time
+}
+
+// Now returns the current time of the clock
+func (c *fakeClock) Now() time.Time {
+	return c.now
+}
+
+// newWar returns a war on battle day with members that have made the given numbers of attacks
+func newWar(end time.Time, attacksPerMember int, attacks ...int) coc.ClanWar {
+	war := coc.ClanWar{
+		State:                "inWar",
+		AttacksPerMember:     attacksPerMember,
+		PreparationStartTime: coc.NewTime(end.Add(-47 * time.Hour)),
+		StartTime:            coc.NewTime(end.Add(-24 * time.Hour)),
+		EndTime:              coc.NewTime(end),
+		Clan:                 coc.ClanWarTeam{Tag: "#CLAN", Name: "Clan"},
+		Opponent:             coc.ClanWarTeam{Tag: "#OPPONENT", Name: "Opponent"},
+	}
+	for i, n := range attacks {
+		member := coc.ClanWarMember{
+			Tag:         "#P" + string(rune('A'+i)),
+			Name:        "Player " + string(rune('A'+i)),
+			MapPosition: len(attacks) - i,
+		}
+		for j := 0; j < n; j++ {
+			member.Attacks = append(member.Attacks, coc.ClanWarAttack{AttackerTag: member.Tag})
+		}
+		war.Clan.Members = append(war.Clan.Members, member)
+	}
+	return war
+}
+
+func TestFindUnusedAttacks(t *testing.T) {
+	end := time.Date(2024, 3, 2, 7, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name string
+		war  coc.ClanWar
+		want []UnusedAttacks
+	}{
+		{
+			name: "regular war",
+			war:  newWar(end, 2, 2, 1, 0),
+			want: []UnusedAttacks{
+				{Tag: "#PC", Name: "Player C", MapPosition: 1, Used: 0, Remaining: 2},
+				{Tag: "#PB", Name: "Player B", MapPosition: 2, Used: 1, Remaining: 1},
+			},
+		},
+		{
+			name: "clan war league",
+			war:  newWar(end, 1, 1, 0),
+			want: []UnusedAttacks{
+				{Tag: "#PB", Name: "Player B", MapPosition: 1, Used: 0, Remaining: 1},
+			},
+		},
+		{
+			name: "all attacks used",
+			war:  newWar(end, 2, 2, 2),
+			want: []UnusedAttacks{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := FindUnusedAttacks(tt.war)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("FindUnusedAttacks() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSchedulerUpdate(t *testing.T) {
+	end := time.Date(2024, 3, 2, 7, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name    string
+		offsets []time.Duration
+		times   []time.Time
+		attacks []int
+		want    []time.Duration
+	}{
+		{
+			name:    "each reminder once",
+			times:   []time.Time{end.Add(-5 * time.Hour), end.Add(-4 * time.Hour), end.Add(-3 * time.Hour), end.Add(-time.Hour), end.Add(-10 * time.Minute)},
+			attacks: []int{1, 0},
+			want:    []time.Duration{4 * time.Hour, time.Hour, 15 * time.Minute},
+		},
+		{
+			name:    "late start sends the last due reminder",
+			times:   []time.Time{end.Add(-30 * time.Minute), end.Add(-20 * time.Minute)},
+			attacks: []int{0},
+			want:    []time.Duration{time.Hour},
+		},
+		{
+			name:    "custom offsets",
+			offsets: []time.Duration{2 * time.Hour},
+			times:   []time.Time{end.Add(-3 * time.Hour), end.Add(-90 * time.Minute), end.Add(-time.Minute)},
+			attacks: []int{0},
+			want:    []time.Duration{2 * time.Hour},
+		},
+		{
+			name:    "all attacks used",
+			times:   []time.Time{end.Add(-4 * time.Hour), end.Add(-time.Hour)},
+			attacks: []int{2, 2},
+			want:    nil,
+		},
+		{
+			name:    "war ended",
+			times:   []time.Time{end, end.Add(time.Hour)},
+			attacks: []int{0},
+			want:    nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clock := &fakeClock{}
+			s := NewScheduler(clock, tt.offsets...)
+			war := newWar(end, 2, tt.attacks...)
+
+			var got []time.Duration
+			s.Subscribe(func(r Reminder) {
+				got = append(got, r.Before)
+			})
+			for _, now := range tt.times {
+				clock.now = now
+				s.Update(war)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Update() sent reminders %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSchedulerForgetsEndedWars(t *testing.T) {
+	end := time.Date(2024, 3, 2, 7, 0, 0, 0, time.UTC)
+	clock := &fakeClock{now: end.Add(-time.Hour)}
+	s := NewScheduler(clock)
+
+	// The first war is never seen ending, as the clan stops being polled
+	s.Update(newWar(end, 2, 0))
+	if len(s.sent) != 1 {
+		t.Fatalf("scheduler tracks %d wars, want 1", len(s.sent))
+	}
+
+	clock.now = end.Add(2 * time.Hour)
+	next := newWar(end.Add(20*time.Hour), 2, 0)
+	next.Clan.Tag = "#OTHER"
+	s.Update(next)
+	if len(s.sent) != 1 {
+		t.Fatalf("scheduler tracks %d wars, want 1", len(s.sent))
+	}
+	for key := range s.sent {
+		if key.clanTag != coc.NormalizeTag("#OTHER") {
+			t.Errorf("scheduler tracks the war of clan %s, want #OTHER", key.clanTag)
+		}
+	}
+}
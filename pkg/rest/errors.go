@@ -1,15 +1,50 @@
 package rest
 
-import "fmt"
+import (
+	"encoding/json"
+	"fmt"
+)
 
-// ErrHttp is an error from an HTTP request
+const (
+	// ReasonAccessDenied is the reason given when access to the requested resource is denied
+	ReasonAccessDenied = "accessDenied"
+	// ReasonInvalidIP is the reason given when the API token can't be used from the client's IP address
+	ReasonInvalidIP = "accessDenied.invalidIp"
+)
+
+// ErrHttp is an error from an HTTP request. Reason and Message are taken from the body of the
+// response, and are empty if the server didn't include them.
 type ErrHttp struct {
 	URL        string
 	StatusCode int
 	Status     string
+	Reason     string
+	Message    string
 }
 
 // Error returns a formatted HTTP error
 func (err ErrHttp) Error() string {
-	return fmt.Sprintf("HTTP error: url=%s, status=%d, reason=%s", err.URL, err.StatusCode, err.Status)
+	if err.Reason == "" {
+		return fmt.Sprintf("HTTP error: url=%s, status=%d, reason=%s", err.URL, err.StatusCode, err.Status)
+	}
+	return fmt.Sprintf("HTTP error: url=%s, status=%d, reason=%s, message=%s", err.URL, err.StatusCode, err.Reason, err.Message)
+}
+
+// ParseClientError returns the reason and message from the body of an error response, or empty
+// strings if the body doesn't hold them.
+func ParseClientError(body []byte) (reason string, message string) {
+	var clientError struct {
+		Reason  string `json:"reason"`
+		Message string `json:"message"`
+	}
+	if err := json.Unmarshal(body, &clientError); err != nil {
+		return "", ""
+	}
+	return clientError.Reason, clientError.Message
+}
+
+// newErrHttp returns the error for a response with an error status code and the given body
+func newErrHttp(url string, statusCode int, status string, body []byte) ErrHttp {
+	reason, message := ParseClientError(body)
+	return ErrHttp{URL: url, StatusCode: statusCode, Status: status, Reason: reason, Message: message}
 }
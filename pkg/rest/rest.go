@@ -6,7 +6,9 @@ import (
 	"io/ioutil"
 	"net/http"
 	"net/url"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/rbrabson/coc/pkg/log"
 )
@@ -36,6 +38,25 @@ type Client interface {
 	Post(url string, body string) ([]byte, error)
 }
 
+// Expirer is implemented by clients that report how long the server caches its responses.
+type Expirer interface {
+	// Expires retrieves the time at which the most recent response expires from the server's cache
+	Expires() time.Time
+}
+
+// TimeoutSetter is implemented by clients that allow the time limit for requests to be set.
+type TimeoutSetter interface {
+	// SetTimeout sets the time limit for each request sent to the HTTP server
+	SetTimeout(timeout time.Duration)
+}
+
+// TransportSetter is implemented by clients that allow the transport used to send requests to
+// be replaced.
+type TransportSetter interface {
+	// SetTransport sets the transport used to send requests to the HTTP server
+	SetTransport(transport http.RoundTripper)
+}
+
 // NewClient creates a new REST client
 func NewClient(headers Headers, qparms QParms) Client {
 	c := &client{headers: headers, qparms: qparms}
@@ -44,10 +65,20 @@ func NewClient(headers Headers, qparms QParms) Client {
 
 // Client is the HTTP client used to send the request to a server.
 type client struct {
-	headers Headers
-	qparms  QParms
+	headers   Headers
+	qparms    QParms
+	expires   time.Time
+	timeout   time.Duration
+	transport http.RoundTripper
 }
 
+// The client supports each of the optional client interfaces
+var (
+	_ Expirer         = (*client)(nil)
+	_ TimeoutSetter   = (*client)(nil)
+	_ TransportSetter = (*client)(nil)
+)
+
 // Headers retrieves the optional headers to include on the REST request
 func (c *client) Headers() Headers {
 	return c.headers
@@ -58,6 +89,39 @@ func (c *client) QParms() QParms {
 	return c.qparms
 }
 
+// Expires retrieves the time at which the most recent response expires from the server's cache.
+// The zero time is returned if the server didn't indicate how long the response is cached.
+func (c *client) Expires() time.Time {
+	return c.expires
+}
+
+// SetTimeout sets the time limit for each request sent to the HTTP server. A timeout of zero
+// means no timeout.
+func (c *client) SetTimeout(timeout time.Duration) {
+	c.timeout = timeout
+}
+
+// SetTransport sets the transport used to send requests to the HTTP server. A nil transport
+// means the default transport.
+func (c *client) SetTransport(transport http.RoundTripper) {
+	c.transport = transport
+}
+
+// DefaultTransport returns the transport used to send requests when no other transport is set.
+// It may be wrapped by transports that add behavior such as caching.
+func DefaultTransport() http.RoundTripper {
+	return tr
+}
+
+// httpClient returns the HTTP client used to send a request
+func (c *client) httpClient() *http.Client {
+	transport := c.transport
+	if transport == nil {
+		transport = tr
+	}
+	return &http.Client{Transport: transport, Timeout: c.timeout}
+}
+
 // Get sends a GET request to the HTTP server
 func (c *client) Get(url string) ([]byte, error) {
 	const M = "rest.Client.Get"
@@ -102,7 +166,7 @@ func (c *client) Get(url string) ([]byte, error) {
 	}
 
 	// Send the request to Clash of Clans and get the response
-	client := &http.Client{Transport: tr}
+	client := c.httpClient()
 	resp, err := client.Do(req)
 	if err != nil {
 		l.Error("failed to send the request to CoC")
@@ -113,10 +177,14 @@ func (c *client) Get(url string) ([]byte, error) {
 	// If an error status code was returned by the server, pass the error back to the invoker
 	if resp.StatusCode != http.StatusOK {
 		l.Error("failed to send the request to CoC, url=", url, ", statusCode=", resp.StatusCode, ", status=", resp.Status)
-		err := ErrHttp{URL: url, StatusCode: resp.StatusCode, Status: resp.Status}
+		body, _ := ioutil.ReadAll(resp.Body)
+		err := newErrHttp(url, resp.StatusCode, resp.Status, body)
 		return nil, err
 	}
 
+	// Determine how long the response is cached by the server
+	c.expires = cacheExpiry(resp.Header)
+
 	// Read the body
 	body, err := ioutil.ReadAll(resp.Body)
 	if err != nil {
@@ -174,7 +242,7 @@ func (c *client) Post(url string, body string) ([]byte, error) {
 	}
 
 	// Send the request to Clash of Clans and get the response
-	client := &http.Client{Transport: tr}
+	client := c.httpClient()
 	resp, err := client.Do(req)
 	if err != nil {
 		l.Error("failed to send the request to CoC")
@@ -185,7 +253,8 @@ func (c *client) Post(url string, body string) ([]byte, error) {
 	// If an error status code was returned by the server, pass the error back to the invoker
 	if resp.StatusCode != http.StatusOK {
 		l.Error("failed to send the request to CoC, url=", url, "statusCode=", resp.StatusCode, ", status=", resp.Status)
-		err := ErrHttp{URL: url, StatusCode: resp.StatusCode, Status: resp.Status}
+		body, _ := ioutil.ReadAll(resp.Body)
+		err := newErrHttp(url, resp.StatusCode, resp.Status, body)
 		return nil, err
 	}
 
@@ -211,3 +280,30 @@ func escapeString(value interface{}) interface{} {
 		return v
 	}
 }
+
+// MaxAge returns how long a response may be cached, based on the max-age directive in the
+// Cache-Control header. False is returned if the header doesn't include a valid max-age.
+func MaxAge(header http.Header) (time.Duration, bool) {
+	for _, directive := range strings.Split(header.Get("Cache-Control"), ",") {
+		directive = strings.TrimSpace(directive)
+		if !strings.HasPrefix(directive, "max-age=") {
+			continue
+		}
+		seconds, err := strconv.Atoi(strings.TrimPrefix(directive, "max-age="))
+		if err != nil {
+			return 0, false
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+	return 0, false
+}
+
+// cacheExpiry returns the time at which a response expires from the server's cache, based on the
+// max-age directive in the Cache-Control header. The zero time is returned if the header isn't set.
+func cacheExpiry(header http.Header) time.Time {
+	maxAge, ok := MaxAge(header)
+	if !ok {
+		return time.Time{}
+	}
+	return time.Now().Add(maxAge)
+}
@@ -0,0 +1,38 @@
+package rest
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestMaxAge(t *testing.T) {
+	tests := []struct {
+		name         string
+		cacheControl string
+		want         time.Duration
+		wantOK       bool
+	}{
+		{name: "missing"},
+		{name: "max-age", cacheControl: "max-age=120", want: 2 * time.Minute, wantOK: true},
+		{name: "zero", cacheControl: "max-age=0", wantOK: true},
+		{name: "other directives", cacheControl: "public, max-age=30 , must-revalidate", want: 30 * time.Second, wantOK: true},
+		{name: "no max-age", cacheControl: "no-cache"},
+		{name: "invalid", cacheControl: "max-age=soon"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			header := http.Header{}
+			if tt.cacheControl != "" {
+				header.Set("Cache-Control", tt.cacheControl)
+			}
+			got, ok := MaxAge(header)
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("MaxAge() = %v, %t, want %v, %t", got, ok, tt.want, tt.wantOK)
+			}
+			if expiry := cacheExpiry(header); expiry.IsZero() == tt.wantOK {
+				t.Errorf("cacheExpiry() = %v, want a time only if max-age is set", expiry)
+			}
+		})
+	}
+}
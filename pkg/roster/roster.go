@@ -0,0 +1,374 @@
+package roster
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/rbrabson/coc/pkg/log"
+	"github.com/rbrabson/coc/v1"
+)
+
+// Snapshot is the list of a clan's members at a point in time.
+type Snapshot struct {
+	ClanTag string           `json:"clanTag"`
+	Time    time.Time        `json:"time"`
+	Members []coc.ClanMember `json:"members"`
+}
+
+// String returns a string representation of a roster snapshot
+func (s Snapshot) String() string {
+	b, _ := json.Marshal(s)
+	return string(b)
+}
+
+// Diff is the change in a clan's members between two points in time. Each join, leave and
+// role change seen in the snapshots between the two times is included, so a player who joined
+// and left again appears in both Joined and Left.
+type Diff struct {
+	ClanTag     string         `json:"clanTag"`
+	From        time.Time      `json:"from"`
+	To          time.Time      `json:"to"`
+	Joined      []MemberChange `json:"joined"`
+	Left        []MemberChange `json:"left"`
+	RoleChanges []RoleChange   `json:"roleChanges"`
+}
+
+// String returns a string representation of a roster diff
+func (d Diff) String() string {
+	b, _ := json.Marshal(d)
+	return string(b)
+}
+
+// MemberChange is a player joining or leaving a clan. The time is that of the first snapshot in
+// which the change was seen.
+type MemberChange struct {
+	Tag  string    `json:"tag"`
+	Name string    `json:"name"`
+	Role string    `json:"role"`
+	Time time.Time `json:"time"`
+}
+
+// String returns a string representation of a member change
+func (m MemberChange) String() string {
+	b, _ := json.Marshal(m)
+	return string(b)
+}
+
+// Membership is a player's membership history in a clan. Players that were members when the
+// first snapshot was recorded are treated as having joined at that time.
+type Membership struct {
+	Tag         string        `json:"tag"`
+	Name        string        `json:"name"`
+	Role        string        `json:"role"`
+	Current     bool          `json:"current"`
+	FirstJoined time.Time     `json:"firstJoined"`
+	LastJoined  time.Time     `json:"lastJoined"`
+	LastLeft    time.Time     `json:"lastLeft,omitempty"`
+	Joins       int           `json:"joins"`
+	Rejoins     int           `json:"rejoins"`
+	Tenure      time.Duration `json:"tenure"`      // Total time as a member across all memberships
+	CurrentTerm time.Duration `json:"currentTerm"` // Time as a member since last joining, if a current member
+}
+
+// String returns a string representation of a membership
+func (m Membership) String() string {
+	b, _ := json.Marshal(m)
+	return string(b)
+}
+
+// RoleChange is a change in a member's role within a clan.
+type RoleChange struct {
+	Tag     string    `json:"tag"`
+	Name    string    `json:"name"`
+	Time    time.Time `json:"time"`
+	OldRole string    `json:"oldRole"`
+	NewRole string    `json:"newRole"`
+}
+
+// String returns a string representation of a role change
+func (r RoleChange) String() string {
+	b, _ := json.Marshal(r)
+	return string(b)
+}
+
+// Tracker persists successive snapshots of clan rosters and answers questions about how the
+// rosters changed over time. Snapshots are appended to a file, one JSON snapshot per line.
+type Tracker struct {
+	mu        sync.RWMutex
+	path      string
+	snapshots map[string][]Snapshot
+}
+
+// Open opens the tracker that persists snapshots in the file at the given path, loading any
+// snapshots already in the file. The file is created when the first snapshot is recorded.
+func Open(path string) (*Tracker, error) {
+	const M = "roster.Open"
+	l := log.New()
+	defer l.Sync()
+
+	l.Debugf("--> %s", M)
+	defer l.Debugf("<-- %s", M)
+
+	t := &Tracker{path: path, snapshots: make(map[string][]Snapshot)}
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return t, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var s Snapshot
+		if err := json.Unmarshal(scanner.Bytes(), &s); err != nil {
+			l.Debug("failed to parse a roster snapshot")
+			return nil, err
+		}
+		t.add(s)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return t, nil
+}
+
+// Record persists a snapshot of the clan's members, such as those returned by
+// coc.Client.GetClanMembers.
+func (t *Tracker) Record(clanTag string, at time.Time, members []coc.ClanMember) error {
+	s := Snapshot{ClanTag: coc.NormalizeTag(clanTag), Time: at, Members: members}
+	b, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	f, err := os.OpenFile(t.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if _, err := f.Write(append(b, '\n')); err != nil {
+		return err
+	}
+	t.add(s)
+
+	return nil
+}
+
+// Snapshots returns the snapshots recorded for the clan, ordered by time.
+func (t *Tracker) Snapshots(clanTag string) []Snapshot {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	snapshots := t.snapshots[coc.NormalizeTag(clanTag)]
+	list := make([]Snapshot, len(snapshots))
+	copy(list, snapshots)
+	return list
+}
+
+// Changes returns the players that joined and left the clan, and the role changes of its
+// members, between the two times. Each snapshot recorded after the first time and at or before
+// the second is compared to the one before it, starting from the latest snapshot recorded at or
+// before the first time, so changes that were later reverted are included. The changes are
+// ordered by time.
+func (t *Tracker) Changes(clanTag string, from, to time.Time) Diff {
+	clanTag = coc.NormalizeTag(clanTag)
+	d := Diff{ClanTag: clanTag, From: from, To: to}
+
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	snapshots := t.snapshots[clanTag]
+	var prev []coc.ClanMember
+	if old := snapshotAt(snapshots, from); old != nil {
+		prev = old.Members
+	}
+	for _, s := range snapshots {
+		if !s.Time.After(from) {
+			continue
+		}
+		if s.Time.After(to) {
+			break
+		}
+		joined, left := DiffMembers(prev, s.Members)
+		for _, m := range joined {
+			d.Joined = append(d.Joined, MemberChange{Tag: m.Tag, Name: m.Name, Role: m.Role, Time: s.Time})
+		}
+		for _, m := range left {
+			d.Left = append(d.Left, MemberChange{Tag: m.Tag, Name: m.Name, Role: m.Role, Time: s.Time})
+		}
+		roles := make(map[string]string, len(prev))
+		for _, m := range prev {
+			roles[m.Tag] = m.Role
+		}
+		for _, m := range s.Members {
+			if role, ok := roles[m.Tag]; ok && role != m.Role {
+				d.RoleChanges = append(d.RoleChanges, RoleChange{
+					Tag:     m.Tag,
+					Name:    m.Name,
+					Time:    s.Time,
+					OldRole: role,
+					NewRole: m.Role,
+				})
+			}
+		}
+		prev = s.Members
+	}
+
+	return d
+}
+
+// Memberships returns the membership history of every player who has been a member of the clan,
+// with durations calculated up to the given time. The memberships are ordered by tenure.
+func (t *Tracker) Memberships(clanTag string, at time.Time) []Membership {
+	clanTag = coc.NormalizeTag(clanTag)
+
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	memberships := make(map[string]*Membership)
+	var last *Snapshot
+	for i := range t.snapshots[clanTag] {
+		s := &t.snapshots[clanTag][i]
+		if s.Time.After(at) {
+			break
+		}
+		present := make(map[string]bool, len(s.Members))
+		for _, member := range s.Members {
+			present[member.Tag] = true
+			m, ok := memberships[member.Tag]
+			if !ok {
+				m = &Membership{Tag: member.Tag, FirstJoined: s.Time}
+				memberships[member.Tag] = m
+			}
+			m.Name = member.Name
+			m.Role = member.Role
+			if !m.Current {
+				m.Current = true
+				m.Joins++
+				m.LastJoined = s.Time
+			}
+		}
+		for _, m := range memberships {
+			if m.Current && !present[m.Tag] {
+				m.Current = false
+				m.LastLeft = s.Time
+				m.Tenure += s.Time.Sub(m.LastJoined)
+			}
+		}
+		last = s
+	}
+	if last == nil {
+		return nil
+	}
+
+	list := make([]Membership, 0, len(memberships))
+	for _, m := range memberships {
+		if m.Current {
+			m.CurrentTerm = at.Sub(m.LastJoined)
+			m.Tenure += m.CurrentTerm
+		}
+		if m.Joins > 0 {
+			m.Rejoins = m.Joins - 1
+		}
+		list = append(list, *m)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Tenure != list[j].Tenure {
+			return list[i].Tenure > list[j].Tenure
+		}
+		return list[i].Tag < list[j].Tag
+	})
+
+	return list
+}
+
+// RoleChanges returns the role changes for the player in the clan, ordered by time. If the
+// player tag is empty, the role changes for all members are returned. A player rejoining the
+// clan with a different role is reported as a role change.
+func (t *Tracker) RoleChanges(clanTag string, playerTag string) []RoleChange {
+	clanTag = coc.NormalizeTag(clanTag)
+	if playerTag != "" {
+		playerTag = coc.NormalizeTag(playerTag)
+	}
+
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	roles := make(map[string]string)
+	var changes []RoleChange
+	for _, s := range t.snapshots[clanTag] {
+		for _, member := range s.Members {
+			if playerTag != "" && member.Tag != playerTag {
+				continue
+			}
+			role, ok := roles[member.Tag]
+			if ok && role != member.Role {
+				changes = append(changes, RoleChange{
+					Tag:     member.Tag,
+					Name:    member.Name,
+					Time:    s.Time,
+					OldRole: role,
+					NewRole: member.Role,
+				})
+			}
+			roles[member.Tag] = member.Role
+		}
+	}
+
+	return changes
+}
+
+// DiffMembers returns the members that are in the new list but not the old one, and those that
+// are in the old list but not the new one.
+func DiffMembers(old, new []coc.ClanMember) (joined, left []coc.ClanMember) {
+	oldTags := make(map[string]bool, len(old))
+	for _, m := range old {
+		oldTags[m.Tag] = true
+	}
+	newTags := make(map[string]bool, len(new))
+	for _, m := range new {
+		newTags[m.Tag] = true
+		if !oldTags[m.Tag] {
+			joined = append(joined, m)
+		}
+	}
+	for _, m := range old {
+		if !newTags[m.Tag] {
+			left = append(left, m)
+		}
+	}
+	return joined, left
+}
+
+// add inserts the snapshot into the in-memory list, keeping the snapshots ordered by time
+func (t *Tracker) add(s Snapshot) {
+	clanTag := coc.NormalizeTag(s.ClanTag)
+	snapshots := t.snapshots[clanTag]
+	i := sort.Search(len(snapshots), func(i int) bool {
+		return snapshots[i].Time.After(s.Time)
+	})
+	snapshots = append(snapshots, Snapshot{})
+	copy(snapshots[i+1:], snapshots[i:])
+	snapshots[i] = s
+	t.snapshots[clanTag] = snapshots
+}
+
+// snapshotAt returns the latest snapshot recorded at or before the time
+func snapshotAt(snapshots []Snapshot, at time.Time) *Snapshot {
+	i := sort.Search(len(snapshots), func(i int) bool {
+		return snapshots[i].Time.After(at)
+	})
+	if i == 0 {
+		return nil
+	}
+	return &snapshots[i-1]
+}
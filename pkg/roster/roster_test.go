@@ -0,0 +1,101 @@
+package roster
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/rbrabson/coc/v1"
+)
+
+// member returns a clan member with the given tag and role
+func member(tag, role string) coc.ClanMember {
+	return coc.ClanMember{Tag: tag, Name: "Player " + tag[1:], Role: role}
+}
+
+func TestChanges(t *testing.T) {
+	start := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	at := func(hours int) time.Time {
+		return start.Add(time.Duration(hours) * time.Hour)
+	}
+	snapshots := []struct {
+		at      time.Time
+		members []coc.ClanMember
+	}{
+		{at(0), []coc.ClanMember{member("#A", "leader"), member("#B", "member")}},
+		{at(1), []coc.ClanMember{member("#A", "leader"), member("#B", "admin"), member("#C", "member")}},
+		{at(2), []coc.ClanMember{member("#A", "leader"), member("#B", "member")}},
+		{at(3), []coc.ClanMember{member("#A", "leader"), member("#B", "member"), member("#D", "member")}},
+	}
+
+	tests := []struct {
+		name        string
+		from, to    time.Time
+		joined      []MemberChange
+		left        []MemberChange
+		roleChanges []RoleChange
+	}{
+		{
+			name: "reverted changes",
+			from: at(0),
+			to:   at(2),
+			joined: []MemberChange{
+				{Tag: "#C", Name: "Player C", Role: "member", Time: at(1)},
+			},
+			left: []MemberChange{
+				{Tag: "#C", Name: "Player C", Role: "member", Time: at(2)},
+			},
+			roleChanges: []RoleChange{
+				{Tag: "#B", Name: "Player B", Time: at(1), OldRole: "member", NewRole: "admin"},
+				{Tag: "#B", Name: "Player B", Time: at(2), OldRole: "admin", NewRole: "member"},
+			},
+		},
+		{
+			name: "between snapshots",
+			from: at(2).Add(30 * time.Minute),
+			to:   at(4),
+			joined: []MemberChange{
+				{Tag: "#D", Name: "Player D", Role: "member", Time: at(3)},
+			},
+		},
+		{
+			name: "no changes",
+			from: at(3),
+			to:   at(5),
+		},
+		{
+			name: "before the first snapshot",
+			from: at(-1),
+			to:   at(0),
+			joined: []MemberChange{
+				{Tag: "#A", Name: "Player A", Role: "leader", Time: at(0)},
+				{Tag: "#B", Name: "Player B", Role: "member", Time: at(0)},
+			},
+		},
+	}
+
+	tracker, err := Open(filepath.Join(t.TempDir(), "roster.jsonl"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range snapshots {
+		if err := tracker.Record("#CLAN", s.at, s.members); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := tracker.Changes("#clan", tt.from, tt.to)
+			if !reflect.DeepEqual(d.Joined, tt.joined) {
+				t.Errorf("Joined = %v, want %v", d.Joined, tt.joined)
+			}
+			if !reflect.DeepEqual(d.Left, tt.left) {
+				t.Errorf("Left = %v, want %v", d.Left, tt.left)
+			}
+			if !reflect.DeepEqual(d.RoleChanges, tt.roleChanges) {
+				t.Errorf("RoleChanges = %v, want %v", d.RoleChanges, tt.roleChanges)
+			}
+		})
+	}
+}
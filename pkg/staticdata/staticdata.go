@@ -0,0 +1,322 @@
+// Package staticdata provides static information about the units in Clash of Clans, such as
+// troops, heroes, spells, pets and hero equipment, that isn't returned by the API. The data is
+// bundled with the package and versioned, and may be replaced by loading a newer data file.
+//
+// For each unit the data includes its category, village, housing space, the town hall level at
+// which it is unlocked and its maximum level at each town hall level. For every unit other than
+// super troops, whose levels follow those of their base troops, the data also includes the cost,
+// time and town hall level required for each level.
+package staticdata
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/rbrabson/coc/v1"
+)
+
+// Category is the category of a unit.
+type Category string
+
+const (
+	CategoryTroop        Category = "troop"
+	CategorySuperTroop   Category = "superTroop"
+	CategorySiegeMachine Category = "siegeMachine"
+	CategorySpell        Category = "spell"
+	CategoryHero         Category = "hero"
+	CategoryPet          Category = "pet"
+	CategoryEquipment    Category = "equipment"
+)
+
+const (
+	VillageHome        = "home"
+	VillageBuilderBase = "builderBase"
+)
+
+var (
+	//go:embed data/units.json
+	unitsJSON []byte
+
+	defaultData     *Data
+	defaultDataErr  error
+	defaultDataOnce sync.Once
+)
+
+// Level is the cost and time to upgrade a unit to a given level, and the town hall level at which
+// the upgrade becomes available. Level 1 is obtained by unlocking the unit, which is paid for by
+// constructing or upgrading a building, so it has no cost. Hero equipment is upgraded instantly.
+type Level struct {
+	Level    int `json:"level"`
+	Cost     int `json:"cost"`     // Cost of the upgrade, in the unit's resource
+	Time     int `json:"time"`     // Time to upgrade, in seconds
+	TownHall int `json:"townHall"` // Town hall level required for the upgrade
+}
+
+// String returns a string representation of a level
+func (l Level) String() string {
+	b, _ := json.Marshal(l)
+	return string(b)
+}
+
+// Unit is the static information about a troop, hero, spell, pet or piece of hero equipment.
+// MaxLevels holds the maximum level at each town hall level, starting with town hall 1; a
+// maximum level of zero means the unit isn't available at that town hall level.
+type Unit struct {
+	Name           string   `json:"name"`
+	Category       Category `json:"category"`
+	Village        string   `json:"village"`
+	HousingSpace   int      `json:"housingSpace"`
+	UnlockTownHall int      `json:"unlockTownHall"`
+	Resource       string   `json:"resource"`
+	Hero           string   `json:"hero,omitempty"`      // Hero that uses a piece of equipment
+	Rarity         string   `json:"rarity,omitempty"`    // Rarity of a piece of equipment
+	BaseTroop      string   `json:"baseTroop,omitempty"` // Troop that is boosted to create a super troop
+	MaxLevels      []int    `json:"maxLevels"`
+	Levels         []Level  `json:"levels,omitempty"`
+}
+
+// String returns a string representation of a unit
+func (u Unit) String() string {
+	b, _ := json.Marshal(u)
+	return string(b)
+}
+
+// MaxLevel returns the highest level the unit may be upgraded to.
+func (u Unit) MaxLevel() int {
+	max := 0
+	for _, level := range u.MaxLevels {
+		if level > max {
+			max = level
+		}
+	}
+	return max
+}
+
+// MaxLevelAt returns the highest level the unit may be upgraded to at the town hall level.
+// Zero is returned if the unit isn't available at the town hall level.
+func (u Unit) MaxLevelAt(townHallLevel int) int {
+	if townHallLevel < 1 || len(u.MaxLevels) == 0 {
+		return 0
+	}
+	if townHallLevel > len(u.MaxLevels) {
+		return u.MaxLevels[len(u.MaxLevels)-1]
+	}
+	return u.MaxLevels[townHallLevel-1]
+}
+
+// IsUnlockedAt returns an indication as to whether the unit is available at the town hall level.
+func (u Unit) IsUnlockedAt(townHallLevel int) bool {
+	return townHallLevel >= u.UnlockTownHall && u.MaxLevelAt(townHallLevel) > 0
+}
+
+// UpgradeCost returns the total cost and time to upgrade the unit from one level to another.
+// False is returned if the data doesn't include the cost of every upgrade between the levels.
+func (u Unit) UpgradeCost(from, to int) (int, time.Duration, bool) {
+	cost, seconds := 0, 0
+	for level := from + 1; level <= to; level++ {
+		l, ok := u.level(level)
+		if !ok {
+			return cost, time.Duration(seconds) * time.Second, false
+		}
+		cost += l.Cost
+		seconds += l.Time
+	}
+	return cost, time.Duration(seconds) * time.Second, true
+}
+
+// level returns the upgrade information for the level
+func (u Unit) level(level int) (Level, bool) {
+	for _, l := range u.Levels {
+		if l.Level == level {
+			return l, true
+		}
+	}
+	return Level{}, false
+}
+
+// unitKey identifies a unit, as the same name may be used for units in both villages
+type unitKey struct {
+	name    string
+	village string
+}
+
+// Data is a versioned set of static unit information.
+type Data struct {
+	Version     string `json:"version"`
+	MaxTownHall int    `json:"maxTownHall"`
+	Units       []Unit `json:"units"`
+	index       map[unitKey]int
+}
+
+// String returns a string representation of the data's version
+func (d *Data) String() string {
+	return "staticdata " + d.Version
+}
+
+// Default returns the data bundled with the package.
+func Default() *Data {
+	defaultDataOnce.Do(func() {
+		defaultData, defaultDataErr = Load(bytes.NewReader(unitsJSON))
+	})
+	if defaultDataErr != nil {
+		panic("staticdata: the bundled data is invalid: " + defaultDataErr.Error())
+	}
+	return defaultData
+}
+
+// Load reads a data file in the same format as the bundled data, allowing newer data to be
+// used without updating the package.
+func Load(r io.Reader) (*Data, error) {
+	var d Data
+	if err := json.NewDecoder(r).Decode(&d); err != nil {
+		return nil, err
+	}
+	d.index = make(map[unitKey]int, len(d.Units))
+	for i, u := range d.Units {
+		if u.Village == "" {
+			d.Units[i].Village = VillageHome
+		}
+		d.index[unitKey{name: u.Name, village: d.Units[i].Village}] = i
+	}
+	return &d, nil
+}
+
+// Unit returns the unit with the given name in the given village.
+func (d *Data) Unit(name string, village string) (Unit, bool) {
+	if village == "" {
+		village = VillageHome
+	}
+	i, ok := d.index[unitKey{name: name, village: village}]
+	if !ok {
+		return Unit{}, false
+	}
+	return d.Units[i], true
+}
+
+// SuperTroops returns the super troops that are created by boosting the base troop.
+func (d *Data) SuperTroops(baseTroop string) []Unit {
+	var units []Unit
+	for _, u := range d.Units {
+		if u.Category == CategorySuperTroop && u.BaseTroop == baseTroop {
+			units = append(units, u)
+		}
+	}
+	return units
+}
+
+// UnitsByCategory returns the units in the category.
+func (d *Data) UnitsByCategory(category Category) []Unit {
+	var units []Unit
+	for _, u := range d.Units {
+		if u.Category == category {
+			units = append(units, u)
+		}
+	}
+	return units
+}
+
+// UnitsAt returns the units in the category that are available at the town hall level.
+func (d *Data) UnitsAt(category Category, townHallLevel int) []Unit {
+	var units []Unit
+	for _, u := range d.Units {
+		if u.Category == category && u.IsUnlockedAt(townHallLevel) {
+			units = append(units, u)
+		}
+	}
+	return units
+}
+
+// EnrichedUnit is a player's troop, hero, spell, pet or piece of equipment along with its
+// static information. Unit is nil if the unit isn't in the static data.
+type EnrichedUnit struct {
+	coc.Troop
+	Unit *Unit `json:"unit,omitempty"`
+}
+
+// String returns a string representation of an enriched unit
+func (e EnrichedUnit) String() string {
+	b, _ := json.Marshal(e)
+	return string(b)
+}
+
+// Category returns the unit's category, or an empty string if the unit isn't known.
+func (e EnrichedUnit) Category() Category {
+	if e.Unit == nil {
+		return ""
+	}
+	return e.Unit.Category
+}
+
+// EnrichedPlayer is a player's units, along with their static information, grouped by category.
+// Units not found in the static data are placed in Unknown.
+type EnrichedPlayer struct {
+	Tag           string         `json:"tag"`
+	Name          string         `json:"name"`
+	TownHallLevel int            `json:"townHallLevel"`
+	Troops        []EnrichedUnit `json:"troops"`
+	SuperTroops   []EnrichedUnit `json:"superTroops"`
+	SiegeMachines []EnrichedUnit `json:"siegeMachines"`
+	Spells        []EnrichedUnit `json:"spells"`
+	Heroes        []EnrichedUnit `json:"heroes"`
+	Pets          []EnrichedUnit `json:"pets"`
+	Equipment     []EnrichedUnit `json:"equipment"`
+	Unknown       []EnrichedUnit `json:"unknown,omitempty"`
+}
+
+// String returns a string representation of an enriched player
+func (p EnrichedPlayer) String() string {
+	b, _ := json.Marshal(p)
+	return string(b)
+}
+
+// Enrich returns the units along with their static information.
+func (d *Data) Enrich(units []coc.Troop) []EnrichedUnit {
+	enriched := make([]EnrichedUnit, 0, len(units))
+	for _, t := range units {
+		e := EnrichedUnit{Troop: t}
+		if u, ok := d.Unit(t.Name, t.Village); ok {
+			e.Unit = &u
+		}
+		enriched = append(enriched, e)
+	}
+	return enriched
+}
+
+// EnrichPlayer returns the player's home village units, such as those returned by
+// coc.Client.GetPlayer, along with their static information.
+func (d *Data) EnrichPlayer(p coc.Player) EnrichedPlayer {
+	ep := EnrichedPlayer{Tag: p.Tag, Name: p.Name, TownHallLevel: p.TownHallLevel}
+	var units []coc.Troop
+	units = append(units, p.Troops...)
+	units = append(units, p.Spells...)
+	units = append(units, p.Heroes...)
+	units = append(units, p.HeroEquipment...)
+	for _, e := range d.Enrich(units) {
+		if e.Village != "" && e.Village != VillageHome {
+			continue
+		}
+		switch e.Category() {
+		case CategoryTroop:
+			ep.Troops = append(ep.Troops, e)
+		case CategorySuperTroop:
+			ep.SuperTroops = append(ep.SuperTroops, e)
+		case CategorySiegeMachine:
+			ep.SiegeMachines = append(ep.SiegeMachines, e)
+		case CategorySpell:
+			ep.Spells = append(ep.Spells, e)
+		case CategoryHero:
+			ep.Heroes = append(ep.Heroes, e)
+		case CategoryPet:
+			ep.Pets = append(ep.Pets, e)
+		case CategoryEquipment:
+			ep.Equipment = append(ep.Equipment, e)
+		default:
+			ep.Unknown = append(ep.Unknown, e)
+		}
+	}
+	return ep
+}
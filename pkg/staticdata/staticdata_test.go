@@ -0,0 +1,64 @@
+package staticdata
+
+import (
+	"testing"
+	"time"
+)
+
+func TestUpgradeCost(t *testing.T) {
+	tests := []struct {
+		name     string
+		unit     string
+		from, to int
+		wantCost int
+		wantTime time.Duration
+		wantOK   bool
+	}{
+		{"single level", "Wizard", 6, 7, 1800000, 5 * 24 * time.Hour, true},
+		{"several levels", "Hog Rider", 2, 5, 158000, 178 * time.Hour, true},
+		{"from unlocked", "Wizard", 0, 2, 60000, 12 * time.Hour, true},
+		{"hero", "Archer Queen", 30, 33, 243000, 158 * time.Hour, true},
+		{"equipment", "Giant Gauntlet", 1, 4, 760, 0, true},
+		{"no upgrade", "Wizard", 7, 7, 0, 0, true},
+		{"beyond max level", "Wizard", 11, 13, 13000000, 11 * 24 * time.Hour, false},
+		{"super troop", "Super Wizard", 9, 10, 0, 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u, ok := Default().Unit(tt.unit, VillageHome)
+			if !ok {
+				t.Fatalf("Unit(%q) not found", tt.unit)
+			}
+			cost, d, ok := u.UpgradeCost(tt.from, tt.to)
+			if cost != tt.wantCost || d != tt.wantTime || ok != tt.wantOK {
+				t.Errorf("UpgradeCost(%d, %d) = %d, %v, %v, want %d, %v, %v", tt.from, tt.to, cost, d, ok, tt.wantCost, tt.wantTime, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestBundledLevels(t *testing.T) {
+	for _, u := range Default().Units {
+		if u.Category == CategorySuperTroop {
+			if len(u.Levels) != 0 {
+				t.Errorf("%s: super troops have no upgrades, but %d levels are listed", u.Name, len(u.Levels))
+			}
+			continue
+		}
+		if len(u.Levels) != u.MaxLevel() {
+			t.Errorf("%s: %d levels are listed, want %d", u.Name, len(u.Levels), u.MaxLevel())
+			continue
+		}
+		for i, l := range u.Levels {
+			if l.Level != i+1 {
+				t.Errorf("%s: level %d is listed at position %d", u.Name, l.Level, i+1)
+			}
+			if u.MaxLevelAt(l.TownHall) < l.Level || u.MaxLevelAt(l.TownHall-1) >= l.Level {
+				t.Errorf("%s: level %d requires town hall %d, which doesn't match the maximum levels", u.Name, l.Level, l.TownHall)
+			}
+			if l.Level > 1 && u.Category != CategoryEquipment && (l.Cost <= 0 || l.Time <= 0) {
+				t.Errorf("%s: level %d has no cost or time", u.Name, l.Level)
+			}
+		}
+	}
+}
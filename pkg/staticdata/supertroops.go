@@ -0,0 +1,130 @@
+package staticdata
+
+import (
+	"encoding/json"
+	"sort"
+	"sync"
+
+	"github.com/rbrabson/coc/v1"
+)
+
+const (
+	// maxConcurrentPlayerRequests limits the number of players retrieved at a time
+	maxConcurrentPlayerRequests = 8
+)
+
+// Client is the set of Clash of Clans API calls used to find the active super troops in a clan.
+// It is satisfied by *coc.Client.
+type Client interface {
+	GetClanMembers(clanTag string, qparms ...coc.QParms) ([]coc.ClanMember, *coc.Paging, error)
+	GetPlayer(playerTag string) (*coc.Player, error)
+}
+
+// ActiveSuperTroop is a super troop that a player currently has boosted.
+type ActiveSuperTroop struct {
+	Name      string `json:"name"`
+	BaseTroop string `json:"baseTroop"`
+	Level     int    `json:"level"`
+}
+
+// String returns a string representation of an active super troop
+func (a ActiveSuperTroop) String() string {
+	b, _ := json.Marshal(a)
+	return string(b)
+}
+
+// SuperTroopHolder is a clan member who has a super troop boosted.
+type SuperTroopHolder struct {
+	Tag   string `json:"tag"`
+	Name  string `json:"name"`
+	Level int    `json:"level"`
+}
+
+// String returns a string representation of a super troop holder
+func (h SuperTroopHolder) String() string {
+	b, _ := json.Marshal(h)
+	return string(b)
+}
+
+// IsSuperTroop returns an indication as to whether the troop is a super troop.
+func (d *Data) IsSuperTroop(t coc.Troop) bool {
+	u, ok := d.Unit(t.Name, t.Village)
+	return ok && u.Category == CategorySuperTroop
+}
+
+// ActiveSuperTroops returns the super troops the player, such as one returned by
+// coc.Client.GetPlayer, currently has boosted.
+func (d *Data) ActiveSuperTroops(p coc.Player) []ActiveSuperTroop {
+	var active []ActiveSuperTroop
+	for _, t := range p.Troops {
+		if !t.SuperTroopIsActive {
+			continue
+		}
+		st := ActiveSuperTroop{Name: t.Name, Level: t.Level}
+		if u, ok := d.Unit(t.Name, t.Village); ok {
+			st.BaseTroop = u.BaseTroop
+		}
+		active = append(active, st)
+	}
+	return active
+}
+
+// ClanSuperTroops returns, for each super troop, the players who currently have it boosted.
+// The players are ordered by the level of the super troop.
+func (d *Data) ClanSuperTroops(players []coc.Player) map[string][]SuperTroopHolder {
+	holders := make(map[string][]SuperTroopHolder)
+	for _, p := range players {
+		for _, st := range d.ActiveSuperTroops(p) {
+			holders[st.Name] = append(holders[st.Name], SuperTroopHolder{Tag: p.Tag, Name: p.Name, Level: st.Level})
+		}
+	}
+	for _, list := range holders {
+		sort.Slice(list, func(i, j int) bool {
+			if list[i].Level != list[j].Level {
+				return list[i].Level > list[j].Level
+			}
+			return list[i].Tag < list[j].Tag
+		})
+	}
+	return holders
+}
+
+// GetClanSuperTroops retrieves each of the clan's members and returns, for each super troop,
+// the members who currently have it boosted. Members are retrieved concurrently. The first
+// error encountered is returned.
+func (d *Data) GetClanSuperTroops(client Client, clanTag string) (map[string][]SuperTroopHolder, error) {
+	members, _, err := client.GetClanMembers(clanTag)
+	if err != nil {
+		return nil, err
+	}
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	sem := make(chan struct{}, maxConcurrentPlayerRequests)
+	players := make([]coc.Player, 0, len(members))
+	for _, m := range members {
+		wg.Add(1)
+		go func(tag string) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			p, perr := client.GetPlayer(tag)
+
+			mu.Lock()
+			defer mu.Unlock()
+			if perr != nil {
+				if err == nil {
+					err = perr
+				}
+				return
+			}
+			players = append(players, *p)
+		}(m.Tag)
+	}
+	wg.Wait()
+	if err != nil {
+		return nil, err
+	}
+
+	return d.ClanSuperTroops(players), nil
+}
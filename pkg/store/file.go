@@ -0,0 +1,219 @@
+package store
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/rbrabson/coc/pkg/log"
+	"github.com/rbrabson/coc/v1"
+)
+
+// FileStore is a store that appends snapshots to a file, one JSON snapshot per line. All
+// snapshots are held in memory, so the store is best suited to modest amounts of history;
+// use a retention policy to keep the file from growing without bound.
+type FileStore struct {
+	mu        sync.RWMutex
+	path      string
+	snapshots map[storeKey][]Snapshot
+}
+
+// storeKey identifies the snapshots of a kind for a key
+type storeKey struct {
+	kind Kind
+	key  string
+}
+
+// OpenFile opens the store that persists snapshots in the file at the given path, loading any
+// snapshots already in the file. The file is created when the first snapshot is persisted.
+func OpenFile(path string) (*FileStore, error) {
+	const M = "store.OpenFile"
+	l := log.New()
+	defer l.Sync()
+
+	l.Debugf("--> %s", M)
+	defer l.Debugf("<-- %s", M)
+
+	s := &FileStore{path: path, snapshots: make(map[storeKey][]Snapshot)}
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 64*1024*1024)
+	for scanner.Scan() {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var snapshot Snapshot
+		if err := json.Unmarshal(scanner.Bytes(), &snapshot); err != nil {
+			l.Debug("failed to parse a snapshot")
+			return nil, err
+		}
+		s.add(snapshot)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// Put persists a snapshot
+func (s *FileStore) Put(snapshot Snapshot) error {
+	snapshot.Key = coc.NormalizeTag(snapshot.Key)
+	b, err := json.Marshal(snapshot)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	f, err := os.OpenFile(s.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if _, err := f.Write(append(b, '\n')); err != nil {
+		return err
+	}
+	s.add(snapshot)
+
+	return nil
+}
+
+// Latest returns the most recent snapshot of the kind for the key, or ErrNotFound if there are none
+func (s *FileStore) Latest(kind Kind, key string) (Snapshot, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	snapshots := s.snapshots[storeKey{kind, coc.NormalizeTag(key)}]
+	if len(snapshots) == 0 {
+		return Snapshot{}, ErrNotFound
+	}
+	return snapshots[len(snapshots)-1], nil
+}
+
+// Between returns the snapshots of the kind for the key taken between the two times, inclusive,
+// oldest first
+func (s *FileStore) Between(kind Kind, key string, from, to time.Time) ([]Snapshot, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var list []Snapshot
+	for _, snapshot := range s.snapshots[storeKey{kind, coc.NormalizeTag(key)}] {
+		if snapshot.Time.Before(from) {
+			continue
+		}
+		if snapshot.Time.After(to) {
+			break
+		}
+		list = append(list, snapshot)
+	}
+	return list, nil
+}
+
+// Keys returns the keys for which there are snapshots of the kind
+func (s *FileStore) Keys(kind Kind) ([]string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var keys []string
+	for k := range s.snapshots {
+		if k.kind == kind {
+			keys = append(keys, k.key)
+		}
+	}
+	sort.Strings(keys)
+	return keys, nil
+}
+
+// Prune removes the snapshots not retained by the policy, as of the given time, and returns the
+// number removed. The file is rewritten if any snapshots are removed.
+func (s *FileStore) Prune(policy RetentionPolicy, at time.Time) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	pruned := make(map[storeKey][]Snapshot, len(s.snapshots))
+	removed := 0
+	for k, snapshots := range s.snapshots {
+		kept := retained(snapshots, policy, at)
+		removed += len(snapshots) - len(kept)
+		if len(kept) > 0 {
+			pruned[k] = kept
+		}
+	}
+	if removed == 0 {
+		return 0, nil
+	}
+
+	if err := s.rewrite(pruned); err != nil {
+		return 0, err
+	}
+	s.snapshots = pruned
+
+	return removed, nil
+}
+
+// Close releases any resources held by the store
+func (s *FileStore) Close() error {
+	return nil
+}
+
+// rewrite replaces the file with one holding the given snapshots. The snapshots are written to
+// a temporary file that is renamed over the original, so the file is never left partially
+// written.
+func (s *FileStore) rewrite(snapshots map[storeKey][]Snapshot) error {
+	var all []Snapshot
+	for _, list := range snapshots {
+		all = append(all, list...)
+	}
+	sort.SliceStable(all, func(i, j int) bool {
+		return all[i].Time.Before(all[j].Time)
+	})
+
+	f, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+
+	w := bufio.NewWriter(f)
+	for _, snapshot := range all {
+		b, err := json.Marshal(snapshot)
+		if err != nil {
+			f.Close()
+			return err
+		}
+		w.Write(b)
+		w.WriteByte('\n')
+	}
+	if err := w.Flush(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(f.Name(), s.path)
+}
+
+// add adds the snapshot to those held in memory, keeping them ordered by time
+func (s *FileStore) add(snapshot Snapshot) {
+	k := storeKey{snapshot.Kind, snapshot.Key}
+	snapshots := s.snapshots[k]
+	i := sort.Search(len(snapshots), func(i int) bool {
+		return snapshots[i].Time.After(snapshot.Time)
+	})
+	snapshots = append(snapshots, Snapshot{})
+	copy(snapshots[i+1:], snapshots[i:])
+	snapshots[i] = snapshot
+	s.snapshots[k] = snapshots
+}
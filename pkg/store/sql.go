@@ -0,0 +1,198 @@
+package store
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/rbrabson/coc/pkg/log"
+	"github.com/rbrabson/coc/v1"
+)
+
+const (
+	defaultTable = "coc_snapshots"
+)
+
+// SQLStore is a driver-agnostic adapter that keeps snapshots in a table of a SQL database.
+// No driver is included; the application registers one and opens the database, which keeps
+// this package free of any driver dependency. Statements use '?' placeholders and SQLite's
+// dialect. Package github.com/rbrabson/coc/pkg/store/sqlite opens a SQLStore on a SQLite
+// database, and is where the store is tested; other databases may require changes to the
+// statements.
+type SQLStore struct {
+	db    *sql.DB
+	table string
+}
+
+// NewSQLStore returns a store that keeps snapshots in the database, creating the table if it
+// doesn't already exist. If no table name is given, "coc_snapshots" is used.
+func NewSQLStore(db *sql.DB, table ...string) (*SQLStore, error) {
+	const M = "store.NewSQLStore"
+	l := log.New()
+	defer l.Sync()
+
+	l.Debugf("--> %s", M)
+	defer l.Debugf("<-- %s", M)
+
+	s := &SQLStore{db: db, table: defaultTable}
+	if len(table) > 0 && table[0] != "" {
+		s.table = table[0]
+	}
+
+	stmts := []string{
+		fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+			kind VARCHAR(32) NOT NULL,
+			snapshot_key VARCHAR(32) NOT NULL,
+			snapshot_time BIGINT NOT NULL,
+			data TEXT NOT NULL
+		)`, s.table),
+		fmt.Sprintf(`CREATE INDEX IF NOT EXISTS %s_kind_key_time ON %s (kind, snapshot_key, snapshot_time)`, s.table, s.table),
+	}
+	for _, stmt := range stmts {
+		if _, err := db.Exec(stmt); err != nil {
+			l.Error("failed to create the snapshot table, table=", s.table)
+			return nil, err
+		}
+	}
+
+	return s, nil
+}
+
+// Put persists a snapshot
+func (s *SQLStore) Put(snapshot Snapshot) error {
+	query := fmt.Sprintf(`INSERT INTO %s (kind, snapshot_key, snapshot_time, data) VALUES (?, ?, ?, ?)`, s.table)
+	_, err := s.db.Exec(query, string(snapshot.Kind), coc.NormalizeTag(snapshot.Key), snapshot.Time.UnixNano(), string(snapshot.Data))
+	return err
+}
+
+// Latest returns the most recent snapshot of the kind for the key, or ErrNotFound if there are none
+func (s *SQLStore) Latest(kind Kind, key string) (Snapshot, error) {
+	query := fmt.Sprintf(`SELECT kind, snapshot_key, snapshot_time, data FROM %s
+		WHERE kind = ? AND snapshot_key = ? ORDER BY snapshot_time DESC LIMIT 1`, s.table)
+	row := s.db.QueryRow(query, string(kind), coc.NormalizeTag(key))
+	snapshot, err := scanSnapshot(row)
+	if err == sql.ErrNoRows {
+		return Snapshot{}, ErrNotFound
+	}
+	return snapshot, err
+}
+
+// Between returns the snapshots of the kind for the key taken between the two times, inclusive,
+// oldest first
+func (s *SQLStore) Between(kind Kind, key string, from, to time.Time) ([]Snapshot, error) {
+	query := fmt.Sprintf(`SELECT kind, snapshot_key, snapshot_time, data FROM %s
+		WHERE kind = ? AND snapshot_key = ? AND snapshot_time >= ? AND snapshot_time <= ?
+		ORDER BY snapshot_time`, s.table)
+	rows, err := s.db.Query(query, string(kind), coc.NormalizeTag(key), from.UnixNano(), to.UnixNano())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []Snapshot
+	for rows.Next() {
+		snapshot, err := scanSnapshot(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, snapshot)
+	}
+	return list, rows.Err()
+}
+
+// Keys returns the keys for which there are snapshots of the kind
+func (s *SQLStore) Keys(kind Kind) ([]string, error) {
+	query := fmt.Sprintf(`SELECT DISTINCT snapshot_key FROM %s WHERE kind = ? ORDER BY snapshot_key`, s.table)
+	rows, err := s.db.Query(query, string(kind))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var keys []string
+	for rows.Next() {
+		var key string
+		if err := rows.Scan(&key); err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	return keys, rows.Err()
+}
+
+// Prune removes the snapshots not retained by the policy, as of the given time, and returns the
+// number removed
+func (s *SQLStore) Prune(policy RetentionPolicy, at time.Time) (int, error) {
+	removed := 0
+	if policy.MaxAge > 0 {
+		query := fmt.Sprintf(`DELETE FROM %s WHERE snapshot_time < ?`, s.table)
+		result, err := s.db.Exec(query, at.Add(-policy.MaxAge).UnixNano())
+		if err != nil {
+			return removed, err
+		}
+		n, _ := result.RowsAffected()
+		removed += int(n)
+	}
+
+	if policy.MaxSnapshots > 0 {
+		// For each kind and key, remove the snapshots older than the newest MaxSnapshots
+		query := fmt.Sprintf(`SELECT kind, snapshot_key FROM %s GROUP BY kind, snapshot_key HAVING COUNT(*) > ?`, s.table)
+		rows, err := s.db.Query(query, policy.MaxSnapshots)
+		if err != nil {
+			return removed, err
+		}
+		var keys []storeKey
+		for rows.Next() {
+			var k storeKey
+			var kind string
+			if err := rows.Scan(&kind, &k.key); err != nil {
+				rows.Close()
+				return removed, err
+			}
+			k.kind = Kind(kind)
+			keys = append(keys, k)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return removed, err
+		}
+
+		for _, k := range keys {
+			var cutoff int64
+			query := fmt.Sprintf(`SELECT snapshot_time FROM %s WHERE kind = ? AND snapshot_key = ?
+				ORDER BY snapshot_time DESC LIMIT 1 OFFSET ?`, s.table)
+			if err := s.db.QueryRow(query, string(k.kind), k.key, policy.MaxSnapshots-1).Scan(&cutoff); err != nil {
+				return removed, err
+			}
+			query = fmt.Sprintf(`DELETE FROM %s WHERE kind = ? AND snapshot_key = ? AND snapshot_time < ?`, s.table)
+			result, err := s.db.Exec(query, string(k.kind), k.key, cutoff)
+			if err != nil {
+				return removed, err
+			}
+			n, _ := result.RowsAffected()
+			removed += int(n)
+		}
+	}
+
+	return removed, nil
+}
+
+// Close closes the underlying database
+func (s *SQLStore) Close() error {
+	return s.db.Close()
+}
+
+// scanner is implemented by both sql.Row and sql.Rows
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanSnapshot reads a snapshot from a row
+func scanSnapshot(row scanner) (Snapshot, error) {
+	var kind, key, data string
+	var nanos int64
+	if err := row.Scan(&kind, &key, &nanos, &data); err != nil {
+		return Snapshot{}, err
+	}
+	return Snapshot{Kind: Kind(kind), Key: key, Time: time.Unix(0, nanos), Data: []byte(data)}, nil
+}
@@ -0,0 +1,16 @@
+module github.com/rbrabson/coc/pkg/store/sqlite
+
+go 1.17
+
+require (
+	github.com/mattn/go-sqlite3 v1.14.17
+	github.com/rbrabson/coc v0.0.0
+)
+
+require (
+	go.uber.org/atomic v1.9.0 // indirect
+	go.uber.org/multierr v1.7.0 // indirect
+	go.uber.org/zap v1.20.0 // indirect
+)
+
+replace github.com/rbrabson/coc => ../../..
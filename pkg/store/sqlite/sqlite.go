@@ -0,0 +1,37 @@
+// Package sqlite provides a snapshot store that keeps snapshots in a SQLite database file.
+//
+// The package is a separate module so that its SQLite driver, github.com/mattn/go-sqlite3, which
+// requires cgo, isn't a dependency of the rest of the library.
+package sqlite
+
+import (
+	"database/sql"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/rbrabson/coc/pkg/log"
+	"github.com/rbrabson/coc/pkg/store"
+)
+
+// Open returns a store that keeps snapshots in the SQLite database at the path, creating the
+// database and its table if they don't already exist. If no table name is given,
+// "coc_snapshots" is used.
+func Open(path string, table ...string) (*store.SQLStore, error) {
+	const M = "sqlite.Open"
+	l := log.New()
+	defer l.Sync()
+
+	l.Debugf("--> %s", M)
+	defer l.Debugf("<-- %s", M)
+
+	db, err := sql.Open("sqlite3", path)
+	if err != nil {
+		l.Error("failed to open the database, path=", path)
+		return nil, err
+	}
+	s, err := store.NewSQLStore(db, table...)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	return s, nil
+}
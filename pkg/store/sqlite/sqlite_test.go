@@ -0,0 +1,112 @@
+package sqlite
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/rbrabson/coc/pkg/store"
+	"github.com/rbrabson/coc/v1"
+)
+
+// start is the time of the first snapshot in each test
+var start = time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+// open opens the database at the path, closing it when the test ends
+func open(t *testing.T, path string) store.Store {
+	t.Helper()
+	s, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { s.Close() })
+	return s
+}
+
+// putClans persists a snapshot of the clan every hour, starting at start, with the clan's
+// points set to the index of the snapshot
+func putClans(t *testing.T, s store.Store, tag string, n int) {
+	t.Helper()
+	for i := 0; i < n; i++ {
+		clan := coc.Clan{Tag: tag, Name: "Clan", ClanPoints: i}
+		if err := store.PutClan(s, start.Add(time.Duration(i)*time.Hour), &clan); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// points returns the clan points held in each of the snapshots
+func points(t *testing.T, snapshots []store.Snapshot) []int {
+	t.Helper()
+	list := []int{}
+	for _, snapshot := range snapshots {
+		clan, err := snapshot.Clan()
+		if err != nil {
+			t.Fatal(err)
+		}
+		list = append(list, clan.ClanPoints)
+	}
+	return list
+}
+
+func TestRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "snapshots.db")
+	s := open(t, path)
+	if _, err := s.Latest(store.KindClan, "#2PP"); err != store.ErrNotFound {
+		t.Fatalf("Latest() error = %v, want %v", err, store.ErrNotFound)
+	}
+	putClans(t, s, "#2pp", 5)
+	putClans(t, s, "#8QU", 2)
+
+	// The store is checked both as written and after it is reopened
+	for i, s := range []store.Store{s, open(t, path)} {
+		latest, err := s.Latest(store.KindClan, "2PP")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if latest.Key != "#2PP" || !latest.Time.Equal(start.Add(4*time.Hour)) {
+			t.Errorf("%d: Latest() = %v", i, latest)
+		}
+		between, err := s.Between(store.KindClan, "#2PP", start.Add(time.Hour), start.Add(3*time.Hour))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := points(t, between); !reflect.DeepEqual(got, []int{1, 2, 3}) {
+			t.Errorf("%d: Between() points = %v, want [1 2 3]", i, got)
+		}
+		keys, err := s.Keys(store.KindClan)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := []string{"#2PP", "#8QU"}; !reflect.DeepEqual(keys, want) {
+			t.Errorf("%d: Keys() = %v, want %v", i, keys, want)
+		}
+	}
+}
+
+func TestPrune(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "snapshots.db")
+	s := open(t, path)
+	putClans(t, s, "#2PP", 6)
+	putClans(t, s, "#8QU", 2)
+
+	removed, err := s.Prune(store.RetentionPolicy{MaxAge: 4 * time.Hour, MaxSnapshots: 2}, start.Add(5*time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if removed != 5 {
+		t.Errorf("Prune() removed %d, want 5", removed)
+	}
+	for i, s := range []store.Store{s, open(t, path)} {
+		for tag, want := range map[string][]int{"#2PP": {4, 5}, "#8QU": {1}} {
+			snapshots, err := s.Between(store.KindClan, tag, start, start.Add(24*time.Hour))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := points(t, snapshots); !reflect.DeepEqual(got, want) {
+				t.Errorf("%d: %s has points %v, want %v", i, tag, got, want)
+			}
+		}
+	}
+}
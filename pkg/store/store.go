@@ -0,0 +1,194 @@
+// Package store persists timestamped snapshots of the data returned by the Clash of Clans API,
+// such as clans, players and wars, so that their history may be queried later.
+//
+// Two backends are provided. FileStore keeps snapshots in a JSON lines file and has no
+// dependencies. SQLStore is an adapter that keeps snapshots in a table of a SQL database
+// opened by the application. Package github.com/rbrabson/coc/pkg/store/sqlite, a separate
+// module, uses SQLStore to keep snapshots in a SQLite database file.
+package store
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/rbrabson/coc/v1"
+)
+
+// Kind is the kind of data held in a snapshot.
+type Kind string
+
+const (
+	KindClan               Kind = "clan"               // A coc.Clan, keyed by clan tag
+	KindPlayer             Kind = "player"             // A coc.Player, keyed by player tag
+	KindClanWar            Kind = "clanWar"            // A clan's current coc.ClanWar, keyed by clan tag
+	KindClanWarLeagueGroup Kind = "clanWarLeagueGroup" // A clan's coc.ClanWarLeagueGroup, keyed by clan tag
+	KindRaidSeasons        Kind = "raidSeasons"        // A clan's []coc.ClanCapitalRaidSeasion, keyed by clan tag
+	KindArchivedWar        Kind = "archivedWar"        // A war in a clan's war archive, keyed by clan tag
+)
+
+var (
+	ErrNotFound = errors.New("no snapshot found")
+)
+
+// Snapshot is the data for a clan, player or war at a point in time. The data is held as the
+// JSON returned by the API, and may be decoded using Decode or one of the typed accessors.
+type Snapshot struct {
+	Kind Kind            `json:"kind"`
+	Key  string          `json:"key"`
+	Time time.Time       `json:"time"`
+	Data json.RawMessage `json:"data"`
+}
+
+// String returns a string representation of a snapshot
+func (s Snapshot) String() string {
+	b, _ := json.Marshal(s)
+	return string(b)
+}
+
+// NewSnapshot returns a snapshot of the value. Keys are tags, and are normalized so that
+// snapshots may be found regardless of how the tag was entered.
+func NewSnapshot(kind Kind, key string, at time.Time, v interface{}) (Snapshot, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return Snapshot{}, err
+	}
+	return Snapshot{Kind: kind, Key: coc.NormalizeTag(key), Time: at, Data: b}, nil
+}
+
+// Decode decodes the snapshot's data into the value pointed to by v.
+func (s Snapshot) Decode(v interface{}) error {
+	return json.Unmarshal(s.Data, v)
+}
+
+// Clan decodes a snapshot of kind KindClan.
+func (s Snapshot) Clan() (*coc.Clan, error) {
+	var clan coc.Clan
+	if err := s.Decode(&clan); err != nil {
+		return nil, err
+	}
+	return &clan, nil
+}
+
+// Player decodes a snapshot of kind KindPlayer.
+func (s Snapshot) Player() (*coc.Player, error) {
+	var player coc.Player
+	if err := s.Decode(&player); err != nil {
+		return nil, err
+	}
+	return &player, nil
+}
+
+// ClanWar decodes a snapshot of kind KindClanWar.
+func (s Snapshot) ClanWar() (*coc.ClanWar, error) {
+	var war coc.ClanWar
+	if err := s.Decode(&war); err != nil {
+		return nil, err
+	}
+	return &war, nil
+}
+
+// ClanWarLeagueGroup decodes a snapshot of kind KindClanWarLeagueGroup.
+func (s Snapshot) ClanWarLeagueGroup() (*coc.ClanWarLeagueGroup, error) {
+	var group coc.ClanWarLeagueGroup
+	if err := s.Decode(&group); err != nil {
+		return nil, err
+	}
+	return &group, nil
+}
+
+// RaidSeasons decodes a snapshot of kind KindRaidSeasons.
+func (s Snapshot) RaidSeasons() ([]coc.ClanCapitalRaidSeasion, error) {
+	var seasons []coc.ClanCapitalRaidSeasion
+	if err := s.Decode(&seasons); err != nil {
+		return nil, err
+	}
+	return seasons, nil
+}
+
+// RetentionPolicy limits the snapshots kept for each kind and key. Snapshots older than MaxAge
+// are removed, as are all but the MaxSnapshots most recent snapshots. A zero value for either
+// field disables that limit.
+type RetentionPolicy struct {
+	MaxAge       time.Duration `json:"maxAge,omitempty"`
+	MaxSnapshots int           `json:"maxSnapshots,omitempty"`
+}
+
+// String returns a string representation of a retention policy
+func (p RetentionPolicy) String() string {
+	b, _ := json.Marshal(p)
+	return string(b)
+}
+
+// Store persists snapshots and answers queries about them. Implementations are safe for
+// concurrent use.
+type Store interface {
+	// Put persists a snapshot
+	Put(s Snapshot) error
+	// Latest returns the most recent snapshot of the kind for the key, or ErrNotFound if there are none
+	Latest(kind Kind, key string) (Snapshot, error)
+	// Between returns the snapshots of the kind for the key taken between the two times, inclusive,
+	// oldest first
+	Between(kind Kind, key string, from, to time.Time) ([]Snapshot, error)
+	// Keys returns the keys for which there are snapshots of the kind
+	Keys(kind Kind) ([]string, error)
+	// Prune removes the snapshots not retained by the policy, as of the given time, and returns the
+	// number removed
+	Prune(policy RetentionPolicy, at time.Time) (int, error)
+	// Close releases any resources held by the store
+	Close() error
+}
+
+// PutClan persists a snapshot of a clan, such as one returned by coc.Client.GetClan.
+func PutClan(s Store, at time.Time, clan *coc.Clan) error {
+	return put(s, KindClan, clan.Tag, at, clan)
+}
+
+// PutPlayer persists a snapshot of a player, such as one returned by coc.Client.GetPlayer.
+func PutPlayer(s Store, at time.Time, player *coc.Player) error {
+	return put(s, KindPlayer, player.Tag, at, player)
+}
+
+// PutClanWar persists a snapshot of a clan's current war, such as one returned by
+// coc.Client.GetClanWarCurrent.
+func PutClanWar(s Store, clanTag string, at time.Time, war *coc.ClanWar) error {
+	return put(s, KindClanWar, clanTag, at, war)
+}
+
+// PutClanWarLeagueGroup persists a snapshot of a clan's war league group, such as one returned
+// by coc.Client.GetClanWarLeagueGroup.
+func PutClanWarLeagueGroup(s Store, clanTag string, at time.Time, group *coc.ClanWarLeagueGroup) error {
+	return put(s, KindClanWarLeagueGroup, clanTag, at, group)
+}
+
+// PutRaidSeasons persists a snapshot of a clan's capital raid seasons, such as those returned
+// by coc.Client.ListCapitalRaidSeasons.
+func PutRaidSeasons(s Store, clanTag string, at time.Time, seasons []coc.ClanCapitalRaidSeasion) error {
+	return put(s, KindRaidSeasons, clanTag, at, seasons)
+}
+
+// put persists a snapshot of the value
+func put(s Store, kind Kind, key string, at time.Time, v interface{}) error {
+	snapshot, err := NewSnapshot(kind, key, at, v)
+	if err != nil {
+		return err
+	}
+	return s.Put(snapshot)
+}
+
+// retained returns the snapshots kept by the retention policy. The snapshots must be sorted
+// oldest first.
+func retained(snapshots []Snapshot, policy RetentionPolicy, at time.Time) []Snapshot {
+	if policy.MaxAge > 0 {
+		cutoff := at.Add(-policy.MaxAge)
+		i := 0
+		for i < len(snapshots) && snapshots[i].Time.Before(cutoff) {
+			i++
+		}
+		snapshots = snapshots[i:]
+	}
+	if policy.MaxSnapshots > 0 && len(snapshots) > policy.MaxSnapshots {
+		snapshots = snapshots[len(snapshots)-policy.MaxSnapshots:]
+	}
+	return snapshots
+}
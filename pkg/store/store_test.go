@@ -0,0 +1,186 @@
+package store
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/rbrabson/coc/v1"
+)
+
+// backend opens a store for a test, returning the store and a function that reopens it from
+// what was persisted
+type backend struct {
+	name string
+	open func(t *testing.T) (Store, func() Store)
+}
+
+// backends are the store implementations under test. SQLStore is tested by the sqlite package.
+var backends = []backend{
+	{
+		name: "file",
+		open: func(t *testing.T) (Store, func() Store) {
+			path := filepath.Join(t.TempDir(), "snapshots.jsonl")
+			open := func() Store {
+				s, err := OpenFile(path)
+				if err != nil {
+					t.Fatal(err)
+				}
+				t.Cleanup(func() { s.Close() })
+				return s
+			}
+			return open(), open
+		},
+	},
+}
+
+// start is the time of the first snapshot in each test
+var start = time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+// putClans persists a snapshot of the clan every hour, starting at start, with the clan's
+// points set to the index of the snapshot
+func putClans(t *testing.T, s Store, tag string, n int) {
+	t.Helper()
+	for i := 0; i < n; i++ {
+		clan := coc.Clan{Tag: tag, Name: "Clan", ClanPoints: i}
+		if err := PutClan(s, start.Add(time.Duration(i)*time.Hour), &clan); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// points returns the clan points held in each of the snapshots
+func points(t *testing.T, snapshots []Snapshot) []int {
+	t.Helper()
+	list := []int{}
+	for _, snapshot := range snapshots {
+		clan, err := snapshot.Clan()
+		if err != nil {
+			t.Fatal(err)
+		}
+		list = append(list, clan.ClanPoints)
+	}
+	return list
+}
+
+func TestRoundTrip(t *testing.T) {
+	for _, b := range backends {
+		t.Run(b.name, func(t *testing.T) {
+			s, reopen := b.open(t)
+			if _, err := s.Latest(KindClan, "#2PP"); err != ErrNotFound {
+				t.Fatalf("Latest() error = %v, want %v", err, ErrNotFound)
+			}
+			putClans(t, s, "#2pp", 5)
+			putClans(t, s, "#8QU", 2)
+			player := coc.Player{Tag: "#P1", Name: "Player"}
+			if err := PutPlayer(s, start, &player); err != nil {
+				t.Fatal(err)
+			}
+
+			// The store is checked both as written and after it is reopened
+			for i, s := range []Store{s, reopen()} {
+				latest, err := s.Latest(KindClan, "2PP")
+				if err != nil {
+					t.Fatal(err)
+				}
+				if latest.Kind != KindClan || latest.Key != "#2PP" || !latest.Time.Equal(start.Add(4*time.Hour)) {
+					t.Errorf("%d: Latest() = %v", i, latest)
+				}
+				if got := points(t, []Snapshot{latest}); !reflect.DeepEqual(got, []int{4}) {
+					t.Errorf("%d: Latest() points = %v, want [4]", i, got)
+				}
+
+				between, err := s.Between(KindClan, "#2PP", start.Add(time.Hour), start.Add(3*time.Hour))
+				if err != nil {
+					t.Fatal(err)
+				}
+				if got := points(t, between); !reflect.DeepEqual(got, []int{1, 2, 3}) {
+					t.Errorf("%d: Between() points = %v, want [1 2 3]", i, got)
+				}
+
+				keys, err := s.Keys(KindClan)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if want := []string{"#2PP", "#8QU"}; !reflect.DeepEqual(keys, want) {
+					t.Errorf("%d: Keys() = %v, want %v", i, keys, want)
+				}
+
+				snapshot, err := s.Latest(KindPlayer, "#P1")
+				if err != nil {
+					t.Fatal(err)
+				}
+				if got, err := snapshot.Player(); err != nil || got.Name != player.Name {
+					t.Errorf("%d: Latest() player = %v, %v", i, got, err)
+				}
+			}
+		})
+	}
+}
+
+func TestPrune(t *testing.T) {
+	tests := []struct {
+		name        string
+		policy      RetentionPolicy
+		wantRemoved int
+		want        []int
+		wantOther   []int
+	}{
+		{
+			name:      "no limits",
+			want:      []int{0, 1, 2, 3, 4, 5},
+			wantOther: []int{0, 1},
+		},
+		{
+			name:        "max age",
+			policy:      RetentionPolicy{MaxAge: 3 * time.Hour},
+			wantRemoved: 4,
+			want:        []int{2, 3, 4, 5},
+			wantOther:   []int{},
+		},
+		{
+			name:        "max snapshots",
+			policy:      RetentionPolicy{MaxSnapshots: 3},
+			wantRemoved: 3,
+			want:        []int{3, 4, 5},
+			wantOther:   []int{0, 1},
+		},
+		{
+			name:        "max age and snapshots",
+			policy:      RetentionPolicy{MaxAge: 4 * time.Hour, MaxSnapshots: 2},
+			wantRemoved: 5,
+			want:        []int{4, 5},
+			wantOther:   []int{1},
+		},
+	}
+	for _, b := range backends {
+		for _, tt := range tests {
+			t.Run(b.name+"/"+tt.name, func(t *testing.T) {
+				s, reopen := b.open(t)
+				putClans(t, s, "#2PP", 6)
+				putClans(t, s, "#8QU", 2)
+
+				removed, err := s.Prune(tt.policy, start.Add(5*time.Hour))
+				if err != nil {
+					t.Fatal(err)
+				}
+				if removed != tt.wantRemoved {
+					t.Errorf("Prune() removed %d, want %d", removed, tt.wantRemoved)
+				}
+
+				for i, s := range []Store{s, reopen()} {
+					for tag, want := range map[string][]int{"#2PP": tt.want, "#8QU": tt.wantOther} {
+						snapshots, err := s.Between(KindClan, tag, start, start.Add(24*time.Hour))
+						if err != nil {
+							t.Fatal(err)
+						}
+						if got := points(t, snapshots); !reflect.DeepEqual(got, want) {
+							t.Errorf("%d: %s has points %v, want %v", i, tag, got, want)
+						}
+					}
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,466 @@
+// Package wararchive keeps a permanent record of a clan's wars. The full details of a war,
+// including each attack, are only available from the current war until the next war starts,
+// while the war log only holds a summary of each war. The archiver captures the details of
+// each war as it ends, and fills in the wars it didn't see from the war log.
+package wararchive
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/rbrabson/coc/pkg/log"
+	"github.com/rbrabson/coc/pkg/store"
+	"github.com/rbrabson/coc/v1"
+)
+
+var (
+	// Range of times used to load all archived wars from the store
+	minTime = time.Unix(0, 0)
+	maxTime = time.Unix(0, math.MaxInt64)
+)
+
+// Client is the set of Clash of Clans API calls used by the archiver. It is satisfied by
+// *coc.Client.
+type Client interface {
+	GetClanWarCurrent(clanTag string) (*coc.ClanWar, error)
+	GetClanWarLog(clanTag string, qparms ...coc.QParms) ([]coc.ClanWar, *coc.Paging, error)
+}
+
+// Entry is a war in the archive. Entries backfilled from the war log aren't detailed, and
+// have no preparation start time or member attacks. Detailed entries that aren't final were
+// captured before the war ended, so may be missing the last attacks of the war.
+type Entry struct {
+	ClanTag              string      `json:"clanTag"`
+	OpponentTag          string      `json:"opponentTag"`
+	OpponentName         string      `json:"opponentName"`
+	PreparationStartTime coc.Time    `json:"preparationStartTime"`
+	EndTime              coc.Time    `json:"endTime"`
+	Result               string      `json:"result,omitempty"`
+	Detailed             bool        `json:"detailed"`
+	Final                bool        `json:"final"`
+	War                  coc.ClanWar `json:"war"`
+}
+
+// String returns a string representation of an archived war
+func (e Entry) String() string {
+	b, _ := json.Marshal(e)
+	return string(b)
+}
+
+// NewEntry returns the archive entry for the war, from the perspective of the clan. False is
+// returned if the clan didn't participate in the war.
+func NewEntry(clanTag string, war coc.ClanWar) (Entry, bool) {
+	clanTag = coc.NormalizeTag(clanTag)
+	war, ok := war.ForClan(clanTag)
+	if !ok {
+		// War log entries for clan war leagues don't include the opponent, and some don't
+		// include the clan's tag
+		if war.Clan.Tag != "" {
+			return Entry{}, false
+		}
+		war.Clan.Tag = clanTag
+	}
+
+	e := Entry{
+		ClanTag:              clanTag,
+		OpponentTag:          coc.NormalizeTag(war.Opponent.Tag),
+		OpponentName:         war.Opponent.Name,
+		PreparationStartTime: war.PreparationStartTime,
+		EndTime:              war.EndTime,
+		Result:               war.Result,
+		Detailed:             len(war.Clan.Members) > 0,
+		Final:                war.State == string(coc.WarPhaseEnded),
+		War:                  war,
+	}
+	if e.Result == "" && e.Detailed && e.Final {
+		switch winner := war.Winner(); {
+		case winner == nil:
+			e.Result = "tie"
+		case winner.Tag == war.Clan.Tag:
+			e.Result = "win"
+		default:
+			e.Result = "lose"
+		}
+	}
+
+	return e, true
+}
+
+// Same returns an indication as to whether the two entries are for the same war. Wars are the
+// same if they have the same clan and opponent and were prepared at the same time. The war log
+// doesn't include the preparation start time, so entries from it are matched on the end time.
+func (e Entry) Same(other Entry) bool {
+	if e.ClanTag != other.ClanTag || e.OpponentTag != other.OpponentTag {
+		return false
+	}
+	if !e.PreparationStartTime.IsZero() && !other.PreparationStartTime.IsZero() {
+		return e.PreparationStartTime.Equal(other.PreparationStartTime)
+	}
+	return e.EndTime.Equal(other.EndTime)
+}
+
+// rank orders entries by how complete a record of the war they are
+func (e Entry) rank() int {
+	switch {
+	case e.Detailed && e.Final:
+		return 2
+	case e.Detailed:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// supersedes returns an indication as to whether the entry is a more complete record of the
+// war than the other entry
+func (e Entry) supersedes(other Entry) bool {
+	if e.rank() != other.rank() {
+		return e.rank() > other.rank()
+	}
+	return e.rank() == 1 && e.War.Clan.Attacks+e.War.Opponent.Attacks > other.War.Clan.Attacks+other.War.Opponent.Attacks
+}
+
+// Archive is a queryable record of the wars of one or more clans. Entries are persisted in a
+// store as snapshots of kind store.KindArchivedWar. The store is append only, so when a more
+// complete record of a war is added it is persisted alongside the earlier one, and the most
+// complete record is used when the archive is opened. Avoid pruning the store with a retention
+// policy unless archived wars are meant to expire.
+type Archive struct {
+	mu    sync.RWMutex
+	store store.Store
+	wars  map[string][]Entry
+}
+
+// Open opens the archive persisted in the store, loading any wars already archived.
+func Open(s store.Store) (*Archive, error) {
+	const M = "wararchive.Open"
+	l := log.New()
+	defer l.Sync()
+
+	l.Debugf("--> %s", M)
+	defer l.Debugf("<-- %s", M)
+
+	a := &Archive{store: s, wars: make(map[string][]Entry)}
+	clans, err := s.Keys(store.KindArchivedWar)
+	if err != nil {
+		return nil, err
+	}
+	for _, clanTag := range clans {
+		snapshots, err := s.Between(store.KindArchivedWar, clanTag, minTime, maxTime)
+		if err != nil {
+			return nil, err
+		}
+		for _, snapshot := range snapshots {
+			var e Entry
+			if err := snapshot.Decode(&e); err != nil {
+				l.Debug("failed to parse an archived war")
+				return nil, err
+			}
+			a.merge(e)
+		}
+	}
+
+	return a, nil
+}
+
+// Add archives the war for the clan, such as one returned by coc.Client.GetClanWarCurrent or
+// coc.Client.GetClanWarLog. If the war is already archived, it is only added if it is a more
+// complete record of the war. An indication as to whether the war was added is returned.
+func (a *Archive) Add(clanTag string, war coc.ClanWar) (bool, error) {
+	e, ok := NewEntry(clanTag, war)
+	if !ok || e.EndTime.IsZero() {
+		return false, nil
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if existing, ok := a.find(e); ok && !e.supersedes(existing) {
+		return false, nil
+	}
+	snapshot, err := store.NewSnapshot(store.KindArchivedWar, e.ClanTag, e.EndTime.Time(), e)
+	if err != nil {
+		return false, err
+	}
+	if err := a.store.Put(snapshot); err != nil {
+		return false, err
+	}
+	a.merge(e)
+
+	return true, nil
+}
+
+// Clans returns the tags of the clans with archived wars.
+func (a *Archive) Clans() []string {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	clans := make([]string, 0, len(a.wars))
+	for clanTag := range a.wars {
+		clans = append(clans, clanTag)
+	}
+	sort.Strings(clans)
+	return clans
+}
+
+// Wars returns the clan's archived wars, ordered by end time.
+func (a *Archive) Wars(clanTag string) []Entry {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	wars := a.wars[coc.NormalizeTag(clanTag)]
+	list := make([]Entry, len(wars))
+	copy(list, wars)
+	return list
+}
+
+// Between returns the clan's archived wars that ended between the two times, inclusive, ordered
+// by end time.
+func (a *Archive) Between(clanTag string, from, to time.Time) []Entry {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	var list []Entry
+	for _, e := range a.wars[coc.NormalizeTag(clanTag)] {
+		if e.EndTime.Time().Before(from) {
+			continue
+		}
+		if e.EndTime.Time().After(to) {
+			break
+		}
+		list = append(list, e)
+	}
+	return list
+}
+
+// Against returns the clan's archived wars against the opponent, ordered by end time.
+func (a *Archive) Against(clanTag string, opponentTag string) []Entry {
+	opponentTag = coc.NormalizeTag(opponentTag)
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	var list []Entry
+	for _, e := range a.wars[coc.NormalizeTag(clanTag)] {
+		if e.OpponentTag == opponentTag {
+			list = append(list, e)
+		}
+	}
+	return list
+}
+
+// Latest returns the clan's most recently ended archived war. False is returned if the clan
+// has no archived wars.
+func (a *Archive) Latest(clanTag string) (Entry, bool) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	wars := a.wars[coc.NormalizeTag(clanTag)]
+	if len(wars) == 0 {
+		return Entry{}, false
+	}
+	return wars[len(wars)-1], true
+}
+
+// DetailedWars returns the full details of the clan's archived wars, ordered by end time. Wars
+// that were only backfilled from the war log are excluded. The result may be passed to
+// analytics.AnalyzeWars.
+func (a *Archive) DetailedWars(clanTag string) []coc.ClanWar {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	var wars []coc.ClanWar
+	for _, e := range a.wars[coc.NormalizeTag(clanTag)] {
+		if e.Detailed {
+			wars = append(wars, e.War)
+		}
+	}
+	return wars
+}
+
+// find returns the archived entry for the same war as the given entry
+func (a *Archive) find(e Entry) (Entry, bool) {
+	for _, existing := range a.wars[e.ClanTag] {
+		if existing.Same(e) {
+			return existing, true
+		}
+	}
+	return Entry{}, false
+}
+
+// merge adds the entry to those held in memory, replacing any less complete record of the same
+// war and keeping the entries ordered by end time
+func (a *Archive) merge(e Entry) {
+	wars := a.wars[e.ClanTag]
+	for i := range wars {
+		if wars[i].Same(e) {
+			if e.supersedes(wars[i]) {
+				wars[i] = e
+			}
+			return
+		}
+	}
+	i := sort.Search(len(wars), func(i int) bool {
+		return wars[i].EndTime.After(e.EndTime)
+	})
+	wars = append(wars, Entry{})
+	copy(wars[i+1:], wars[i:])
+	wars[i] = e
+	a.wars[e.ClanTag] = wars
+}
+
+// Archiver polls the current wars of one or more clans and archives each war when it ends. If
+// a war ends between polls and the next war has already started, the last snapshot of the war
+// is archived instead, and may be missing the final attacks.
+type Archiver struct {
+	mu      sync.Mutex
+	client  Client
+	archive *Archive
+	clans   []string
+	last    map[string]*coc.ClanWar
+	clock   coc.Clock
+}
+
+// NewArchiver returns an archiver that uses the client to retrieve wars and adds them to the
+// archive. If the clock is nil, the system clock is used.
+func NewArchiver(client Client, archive *Archive, clock coc.Clock) *Archiver {
+	if clock == nil {
+		clock = coc.SystemClock
+	}
+	return &Archiver{
+		client:  client,
+		archive: archive,
+		last:    make(map[string]*coc.ClanWar),
+		clock:   clock,
+	}
+}
+
+// AddClan registers a clan whose wars are archived.
+func (ar *Archiver) AddClan(clanTag string) {
+	ar.mu.Lock()
+	defer ar.mu.Unlock()
+	ar.clans = append(ar.clans, coc.NormalizeTag(clanTag))
+}
+
+// Archive returns the archive to which wars are added.
+func (ar *Archiver) Archive() *Archive {
+	return ar.archive
+}
+
+// Poll retrieves the clan's current war and archives it if it has ended. It returns an
+// indication as to whether a war was archived.
+func (ar *Archiver) Poll(clanTag string) (bool, error) {
+	const M = "Archiver.Poll"
+	l := log.New()
+	defer l.Sync()
+
+	l.Debugf("--> %s", M)
+	defer l.Debugf("<-- %s", M)
+
+	clanTag = coc.NormalizeTag(clanTag)
+	war, err := ar.client.GetClanWarCurrent(clanTag)
+	switch {
+	case errors.Is(err, coc.ErrNotInWar), errors.Is(err, coc.ErrInCWL):
+		war = nil
+	case err != nil:
+		return false, err
+	}
+
+	ar.mu.Lock()
+	last := ar.last[clanTag]
+	ar.last[clanTag] = war
+	ar.mu.Unlock()
+
+	// Archive the previous snapshot if the war it was for ended without being seen in the
+	// ended state
+	archived := false
+	if last != nil && last.State != string(coc.WarPhaseEnded) && !last.EndTime.Time().After(ar.clock.Now()) {
+		if war == nil || !sameWar(clanTag, *last, *war) {
+			added, err := ar.archive.Add(clanTag, *last)
+			if err != nil {
+				return false, err
+			}
+			archived = archived || added
+		}
+	}
+
+	if war != nil && war.State == string(coc.WarPhaseEnded) {
+		added, err := ar.archive.Add(clanTag, *war)
+		if err != nil {
+			return archived, err
+		}
+		archived = archived || added
+	}
+
+	return archived, nil
+}
+
+// Backfill adds the wars in the clan's war log that aren't already archived, and returns the
+// number added. The war log only holds summaries of each war.
+func (ar *Archiver) Backfill(clanTag string) (int, error) {
+	const M = "Archiver.Backfill"
+	l := log.New()
+	defer l.Sync()
+
+	l.Debugf("--> %s", M)
+	defer l.Debugf("<-- %s", M)
+
+	wars, _, err := ar.client.GetClanWarLog(clanTag)
+	if err != nil {
+		return 0, err
+	}
+	count := 0
+	for _, war := range wars {
+		added, err := ar.archive.Add(clanTag, war)
+		if err != nil {
+			return count, err
+		}
+		if added {
+			count++
+		}
+	}
+
+	return count, nil
+}
+
+// Run backfills each clan's war log and then polls each clan's current war at the given
+// interval until the context is cancelled. Errors are passed to onError, if provided.
+func (ar *Archiver) Run(ctx context.Context, interval time.Duration, onError func(clanTag string, err error)) error {
+	const M = "Archiver.Run"
+	l := log.New()
+	defer l.Sync()
+
+	l.Debugf("--> %s", M)
+	defer l.Debugf("<-- %s", M)
+
+	ar.mu.Lock()
+	clans := make([]string, len(ar.clans))
+	copy(clans, ar.clans)
+	ar.mu.Unlock()
+
+	for _, clanTag := range clans {
+		if _, err := ar.Backfill(clanTag); err != nil && onError != nil {
+			onError(clanTag, err)
+		}
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		for _, clanTag := range clans {
+			if _, err := ar.Poll(clanTag); err != nil && onError != nil {
+				onError(clanTag, err)
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// sameWar returns an indication as to whether the two snapshots are of the same war
+func sameWar(clanTag string, a, b coc.ClanWar) bool {
+	ea, okA := NewEntry(clanTag, a)
+	eb, okB := NewEntry(clanTag, b)
+	return okA && okB && ea.Same(eb)
+}
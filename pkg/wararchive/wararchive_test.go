@@ -0,0 +1,362 @@
+package wararchive
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/rbrabson/coc/pkg/store"
+	"github.com/rbrabson/coc/v1"
+)
+
+// memStore is a store that holds its snapshots in memory
+type memStore struct {
+	snapshots []store.Snapshot
+}
+
+func (s *memStore) Put(snapshot store.Snapshot) error {
+	s.snapshots = append(s.snapshots, snapshot)
+	return nil
+}
+
+func (s *memStore) Latest(kind store.Kind, key string) (store.Snapshot, error) {
+	snapshots, _ := s.Between(kind, key, minTime, maxTime)
+	if len(snapshots) == 0 {
+		return store.Snapshot{}, store.ErrNotFound
+	}
+	return snapshots[len(snapshots)-1], nil
+}
+
+func (s *memStore) Between(kind store.Kind, key string, from, to time.Time) ([]store.Snapshot, error) {
+	var list []store.Snapshot
+	for _, snapshot := range s.snapshots {
+		if snapshot.Kind == kind && snapshot.Key == coc.NormalizeTag(key) && !snapshot.Time.Before(from) && !snapshot.Time.After(to) {
+			list = append(list, snapshot)
+		}
+	}
+	sort.SliceStable(list, func(i, j int) bool { return list[i].Time.Before(list[j].Time) })
+	return list, nil
+}
+
+func (s *memStore) Keys(kind store.Kind) ([]string, error) {
+	seen := make(map[string]bool)
+	var keys []string
+	for _, snapshot := range s.snapshots {
+		if snapshot.Kind == kind && !seen[snapshot.Key] {
+			seen[snapshot.Key] = true
+			keys = append(keys, snapshot.Key)
+		}
+	}
+	sort.Strings(keys)
+	return keys, nil
+}
+
+func (s *memStore) Prune(policy store.RetentionPolicy, at time.Time) (int, error) {
+	return 0, nil
+}
+
+func (s *memStore) Close() error {
+	return nil
+}
+
+// fakeClient returns the current wars, or errors, in turn, and the war log
+type fakeClient struct {
+	current []*coc.ClanWar
+	errs    []error
+	log     []coc.ClanWar
+}
+
+func (c *fakeClient) GetClanWarCurrent(clanTag string) (*coc.ClanWar, error) {
+	war, err := c.current[0], c.errs[0]
+	c.current, c.errs = c.current[1:], c.errs[1:]
+	return war, err
+}
+
+func (c *fakeClient) GetClanWarLog(clanTag string, qparms ...coc.QParms) ([]coc.ClanWar, *coc.Paging, error) {
+	return c.log, nil, nil
+}
+
+// fakeClock is a clock set to a fixed time
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	return c.now
+}
+
+// start is the time at which the first war in each test starts its preparation day
+var start = time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+// newWar returns a detailed war between #2PP and the opponent, prepared the given number of
+// days after start, with the clan having made the given number of attacks
+func newWar(opponentTag string, day int, state coc.WarPhase, attacks int) *coc.ClanWar {
+	prep := start.AddDate(0, 0, day)
+	return &coc.ClanWar{
+		State:                string(state),
+		TeamSize:             5,
+		PreparationStartTime: coc.NewTime(prep),
+		StartTime:            coc.NewTime(prep.Add(23 * time.Hour)),
+		EndTime:              coc.NewTime(prep.Add(47 * time.Hour)),
+		Clan: coc.ClanWarTeam{
+			Tag:     "#2PP",
+			Attacks: attacks,
+			Stars:   attacks * 2,
+			Members: []coc.ClanWarMember{{Tag: "#PA", MapPosition: 1}},
+		},
+		Opponent: coc.ClanWarTeam{
+			Tag:     opponentTag,
+			Stars:   3,
+			Members: []coc.ClanWarMember{{Tag: "#OA", MapPosition: 1}},
+		},
+	}
+}
+
+// logEntry returns the war log's summary of the war
+func logEntry(war *coc.ClanWar, result string) coc.ClanWar {
+	return coc.ClanWar{
+		TeamSize: war.TeamSize,
+		EndTime:  war.EndTime,
+		Result:   result,
+		Clan:     coc.ClanWarTeam{Tag: war.Clan.Tag, Attacks: war.Clan.Attacks, Stars: war.Clan.Stars},
+		Opponent: coc.ClanWarTeam{Tag: war.Opponent.Tag, Stars: war.Opponent.Stars},
+	}
+}
+
+// entry returns the archive entry for the war, from the perspective of #2PP
+func entry(t *testing.T, war coc.ClanWar) Entry {
+	t.Helper()
+	e, ok := NewEntry("#2PP", war)
+	if !ok {
+		t.Fatalf("NewEntry() failed for %v", war)
+	}
+	return e
+}
+
+// summarize returns the opponent, end day, result and completeness of each entry
+func summarize(entries []Entry) []string {
+	list := []string{}
+	for _, e := range entries {
+		day := int(e.EndTime.Time().Sub(start).Hours()) / 24
+		list = append(list, fmt.Sprintf("%s day=%d result=%s detailed=%t final=%t attacks=%d",
+			e.OpponentTag, day, e.Result, e.Detailed, e.Final, e.War.Clan.Attacks))
+	}
+	return list
+}
+
+func TestSame(t *testing.T) {
+	war := newWar("#OB", 0, coc.WarPhaseInWar, 2)
+	tests := []struct {
+		name  string
+		other coc.ClanWar
+		want  bool
+	}{
+		{
+			name:  "same war later",
+			other: *newWar("#OB", 0, coc.WarPhaseEnded, 5),
+			want:  true,
+		},
+		{
+			name:  "war log entry",
+			other: logEntry(war, "win"),
+			want:  true,
+		},
+		{
+			name:  "seen from the opponent",
+			other: func() coc.ClanWar { w, _ := war.ForClan("#OB"); return w }(),
+			want:  true,
+		},
+		{
+			name:  "rematch",
+			other: *newWar("#OB", 2, coc.WarPhaseInWar, 2),
+		},
+		{
+			name:  "different opponent",
+			other: *newWar("#OC", 0, coc.WarPhaseInWar, 2),
+		},
+		{
+			name: "same end time with a different preparation time",
+			other: func() coc.ClanWar {
+				w := *newWar("#OB", 0, coc.WarPhaseInWar, 2)
+				w.PreparationStartTime = coc.NewTime(start.Add(time.Hour))
+				return w
+			}(),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e, other := entry(t, *war), entry(t, tt.other)
+			if got := e.Same(other); got != tt.want {
+				t.Errorf("Same() = %t, want %t", got, tt.want)
+			}
+			if got := other.Same(e); got != tt.want {
+				t.Errorf("Same() reversed = %t, want %t", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSupersedes(t *testing.T) {
+	ended := *newWar("#OB", 0, coc.WarPhaseEnded, 5)
+	battle := *newWar("#OB", 0, coc.WarPhaseInWar, 3)
+	battleLater := *newWar("#OB", 0, coc.WarPhaseInWar, 4)
+	summary := logEntry(&ended, "win")
+	tests := []struct {
+		name  string
+		e     coc.ClanWar
+		other coc.ClanWar
+		want  bool
+	}{
+		{name: "ended over battle day", e: ended, other: battle, want: true},
+		{name: "ended over war log", e: ended, other: summary, want: true},
+		{name: "battle day over war log", e: battle, other: summary, want: true},
+		{name: "more attacks", e: battleLater, other: battle, want: true},
+		{name: "fewer attacks", e: battle, other: battleLater},
+		{name: "battle day over ended", e: battleLater, other: ended},
+		{name: "war log over ended", e: summary, other: ended},
+		{name: "war log over battle day", e: summary, other: battle},
+		{name: "ended over ended", e: ended, other: ended},
+		{name: "war log over war log", e: summary, other: summary},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := entry(t, tt.e).supersedes(entry(t, tt.other)); got != tt.want {
+				t.Errorf("supersedes() = %t, want %t", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPollMissedEnd(t *testing.T) {
+	inWar := newWar("#OB", 0, coc.WarPhaseInWar, 3)
+	ended := newWar("#OB", 0, coc.WarPhaseEnded, 5)
+	next := newWar("#OC", 2, coc.WarPhasePreparation, 0)
+	warEnd := inWar.EndTime.Time()
+
+	tests := []struct {
+		name    string
+		current []*coc.ClanWar
+		errs    []error
+		times   []time.Time
+		want    []bool
+		wantLog []string
+	}{
+		{
+			name:    "war end seen",
+			current: []*coc.ClanWar{inWar, ended},
+			errs:    []error{nil, nil},
+			times:   []time.Time{warEnd.Add(-time.Hour), warEnd.Add(time.Hour)},
+			want:    []bool{false, true},
+			wantLog: []string{"#OB day=1 result=win detailed=true final=true attacks=5"},
+		},
+		{
+			name:    "next war started",
+			current: []*coc.ClanWar{inWar, next},
+			errs:    []error{nil, nil},
+			times:   []time.Time{warEnd.Add(-time.Hour), warEnd.Add(24 * time.Hour)},
+			want:    []bool{false, true},
+			wantLog: []string{"#OB day=1 result= detailed=true final=false attacks=3"},
+		},
+		{
+			name:    "not in war",
+			current: []*coc.ClanWar{inWar, nil},
+			errs:    []error{nil, coc.ErrNotInWar},
+			times:   []time.Time{warEnd.Add(-time.Hour), warEnd.Add(24 * time.Hour)},
+			want:    []bool{false, true},
+			wantLog: []string{"#OB day=1 result= detailed=true final=false attacks=3"},
+		},
+		{
+			name:    "war not yet ended",
+			current: []*coc.ClanWar{inWar, nil},
+			errs:    []error{nil, coc.ErrNotInWar},
+			times:   []time.Time{warEnd.Add(-2 * time.Hour), warEnd.Add(-time.Hour)},
+			want:    []bool{false, false},
+			wantLog: []string{},
+		},
+		{
+			name:    "missed end superseded by the ended war",
+			current: []*coc.ClanWar{inWar, nil, ended},
+			errs:    []error{nil, coc.ErrInCWL, nil},
+			times:   []time.Time{warEnd.Add(-time.Hour), warEnd.Add(time.Hour), warEnd.Add(2 * time.Hour)},
+			want:    []bool{false, true, true},
+			wantLog: []string{"#OB day=1 result=win detailed=true final=true attacks=5"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			archive, err := Open(&memStore{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			clock := &fakeClock{}
+			ar := NewArchiver(&fakeClient{current: tt.current, errs: tt.errs}, archive, clock)
+			for i, now := range tt.times {
+				clock.now = now
+				archived, err := ar.Poll("#2pp")
+				if err != nil {
+					t.Fatal(err)
+				}
+				if archived != tt.want[i] {
+					t.Errorf("%d: Poll() = %t, want %t", i, archived, tt.want[i])
+				}
+			}
+			if got := summarize(archive.Wars("#2PP")); !reflect.DeepEqual(got, tt.wantLog) {
+				t.Errorf("Wars() = %v, want %v", got, tt.wantLog)
+			}
+		})
+	}
+}
+
+func TestBackfill(t *testing.T) {
+	first := newWar("#OA", 0, coc.WarPhaseEnded, 5)
+	second := newWar("#OB", 2, coc.WarPhaseEnded, 4)
+	third := newWar("#OC", 4, coc.WarPhaseEnded, 5)
+	// Clan war league wars in the war log don't include the opponent or the clan's tag
+	league := coc.ClanWar{TeamSize: 15, EndTime: coc.NewTime(start.AddDate(0, 0, 8)), Result: "lose"}
+
+	s := &memStore{}
+	archive, err := Open(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := archive.Add("#2PP", *second); err != nil {
+		t.Fatal(err)
+	}
+
+	client := &fakeClient{log: []coc.ClanWar{league, logEntry(third, "win"), logEntry(second, "win"), logEntry(first, "lose")}}
+	ar := NewArchiver(client, archive, &fakeClock{now: start.AddDate(0, 0, 10)})
+	added, err := ar.Backfill("#2PP")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if added != 3 {
+		t.Errorf("Backfill() added %d, want 3", added)
+	}
+	if added, err := ar.Backfill("#2PP"); err != nil || added != 0 {
+		t.Errorf("Backfill() again added %d, %v, want 0", added, err)
+	}
+
+	want := []string{
+		"#OA day=1 result=lose detailed=false final=false attacks=5",
+		"#OB day=3 result=win detailed=true final=true attacks=4",
+		"#OC day=5 result=win detailed=false final=false attacks=5",
+		" day=8 result=lose detailed=false final=false attacks=0",
+	}
+	if got := summarize(archive.Wars("#2PP")); !reflect.DeepEqual(got, want) {
+		t.Errorf("Wars() = %v, want %v", got, want)
+	}
+
+	// The detailed war is kept over its war log entry when the archive is reopened
+	reopened, err := Open(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := summarize(reopened.Wars("#2PP")); !reflect.DeepEqual(got, want) {
+		t.Errorf("reopened Wars() = %v, want %v", got, want)
+	}
+	if got := len(reopened.DetailedWars("#2PP")); got != 1 {
+		t.Errorf("DetailedWars() returned %d wars, want 1", got)
+	}
+}
@@ -0,0 +1,367 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/rbrabson/coc/pkg/events"
+	"github.com/rbrabson/coc/pkg/log"
+	"github.com/rbrabson/coc/v1"
+)
+
+const (
+	// SignatureHeader is the header containing the HMAC-SHA256 signature of the request body
+	SignatureHeader = "X-Coc-Signature-256"
+	// EventHeader is the header containing the type of event being delivered
+	EventHeader = "X-Coc-Event"
+	// TimestampHeader is the header containing the time the request was sent, in Unix seconds
+	TimestampHeader = "X-Coc-Timestamp"
+
+	defaultMaxAttempts    = 5
+	defaultInitialBackoff = time.Second
+	defaultMaxBackoff     = 30 * time.Second
+	defaultTimeout        = 10 * time.Second
+)
+
+var (
+	ErrNoEndpoints = errors.New("no webhook endpoints configured")
+)
+
+// Endpoint is a URL to which events are delivered. Templates are Go text templates executed
+// with the event, and must produce JSON. Values such as clan and player names may contain
+// characters that must be escaped in JSON, so they should be inserted using the json function,
+// which produces a complete JSON value, or within a JSON string using the escape function:
+//
+//	{"clan": {{json .ClanName}}, "text": "{{escape .Member.Name}} joined the clan"}
+type Endpoint struct {
+	URL       string                      `json:"url"`
+	Secret    string                      `json:"secret,omitempty"`    // Secret used to sign requests; requests aren't signed if empty
+	Events    []events.EventType          `json:"events,omitempty"`    // Types of events delivered; all events are delivered if empty
+	Templates map[events.EventType]string `json:"templates,omitempty"` // Templates used to create the JSON body for each type of event
+}
+
+// Config is the configuration for a webhook dispatcher. Zero values are replaced with defaults.
+type Config struct {
+	Endpoints      []Endpoint    `json:"endpoints"`
+	MaxAttempts    int           `json:"maxAttempts,omitempty"`    // Maximum number of attempts to deliver each event
+	InitialBackoff time.Duration `json:"initialBackoff,omitempty"` // Time to wait before the first retry; doubled on each retry
+	MaxBackoff     time.Duration `json:"maxBackoff,omitempty"`     // Maximum time to wait between retries
+	Timeout        time.Duration `json:"timeout,omitempty"`        // Timeout for each request
+	DeadLetterFile string        `json:"deadLetterFile,omitempty"` // File to which undeliverable events are appended
+	HTTPClient     *http.Client  `json:"-"`                        // Client used to send requests
+}
+
+// DeadLetter is an event that couldn't be delivered. Dead letters are written to the dead-letter
+// file as JSON, one per line. If the endpoint's template failed, the payload is the event
+// marshalled as JSON and the number of attempts is zero.
+type DeadLetter struct {
+	Time      time.Time        `json:"time"`
+	URL       string           `json:"url"`
+	EventType events.EventType `json:"eventType"`
+	Payload   json.RawMessage  `json:"payload"`
+	Attempts  int              `json:"attempts"`
+	Error     string           `json:"error"`
+}
+
+// String returns a string representation of a dead letter
+func (d DeadLetter) String() string {
+	b, _ := json.Marshal(d)
+	return string(b)
+}
+
+// endpoint is a configured endpoint along with its parsed templates
+type endpoint struct {
+	Endpoint
+	events    map[events.EventType]bool
+	templates map[events.EventType]*template.Template
+}
+
+// Dispatcher delivers events to webhook endpoints.
+type Dispatcher struct {
+	cfg       Config
+	endpoints []endpoint
+	mu        sync.Mutex // serializes writes to the dead-letter file
+}
+
+// NewDispatcher creates a dispatcher for the configured endpoints. An error is returned if any
+// of the templates can't be parsed.
+func NewDispatcher(cfg Config) (*Dispatcher, error) {
+	if len(cfg.Endpoints) == 0 {
+		return nil, ErrNoEndpoints
+	}
+	if cfg.MaxAttempts <= 0 {
+		cfg.MaxAttempts = defaultMaxAttempts
+	}
+	if cfg.InitialBackoff <= 0 {
+		cfg.InitialBackoff = defaultInitialBackoff
+	}
+	if cfg.MaxBackoff <= 0 {
+		cfg.MaxBackoff = defaultMaxBackoff
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = defaultTimeout
+	}
+	if cfg.HTTPClient == nil {
+		cfg.HTTPClient = &http.Client{Timeout: cfg.Timeout}
+	}
+
+	d := &Dispatcher{cfg: cfg}
+	for _, ep := range cfg.Endpoints {
+		e := endpoint{
+			Endpoint:  ep,
+			events:    make(map[events.EventType]bool, len(ep.Events)),
+			templates: make(map[events.EventType]*template.Template, len(ep.Templates)),
+		}
+		for _, et := range ep.Events {
+			e.events[et] = true
+		}
+		for et, text := range ep.Templates {
+			tmpl, err := template.New(string(et)).Funcs(templateFuncs).Parse(text)
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse the %s template for %s: %w", et, ep.URL, err)
+			}
+			e.templates[et] = tmpl
+		}
+		d.endpoints = append(d.endpoints, e)
+	}
+
+	return d, nil
+}
+
+// Run delivers the events received on the channel until the channel is closed or the context
+// is cancelled. This allows the dispatcher to consume the events from an events.Poller.
+func (d *Dispatcher) Run(ctx context.Context, ch <-chan events.Event) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case e, ok := <-ch:
+			if !ok {
+				return nil
+			}
+			d.Dispatch(ctx, e)
+		}
+	}
+}
+
+// DispatchAll delivers each of the events, such as those returned by the events.Diff functions.
+// The first delivery error is returned, after attempting to deliver all of the events.
+func (d *Dispatcher) DispatchAll(ctx context.Context, evs []events.Event) error {
+	var firstErr error
+	for _, e := range evs {
+		if err := d.Dispatch(ctx, e); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// Dispatch delivers the event to each endpoint that accepts the event's type. Failed requests
+// are retried with an exponential backoff. Events that can't be delivered, including those for
+// which the endpoint's template fails, are written to the dead-letter file, if one is
+// configured, and the first delivery error is returned.
+func (d *Dispatcher) Dispatch(ctx context.Context, e events.Event) error {
+	const M = "Dispatcher.Dispatch"
+	l := log.New()
+	defer l.Sync()
+
+	l.Debugf("--> %s", M)
+	defer l.Debugf("<-- %s", M)
+
+	var firstErr error
+	for _, ep := range d.endpoints {
+		if len(ep.events) > 0 && !ep.events[e.EventType()] {
+			continue
+		}
+		payload, err := ep.render(e)
+		if err != nil {
+			// Keep the event itself, so it isn't lost because of a faulty template
+			raw, _ := json.Marshal(e)
+			d.deadLetter(ep.URL, e.EventType(), raw, 0, err)
+		} else {
+			var attempts int
+			attempts, err = d.send(ctx, ep, e.EventType(), payload)
+			if err != nil {
+				d.deadLetter(ep.URL, e.EventType(), payload, attempts, err)
+			}
+		}
+		if err != nil {
+			l.Errorf("failed to deliver the %s event to %s: %v", e.EventType(), ep.URL, err)
+			if firstErr == nil {
+				firstErr = err
+			}
+		}
+	}
+	return firstErr
+}
+
+// render creates the JSON body for the event. If the endpoint has a template for the type of
+// event it is used, otherwise the event is marshalled as JSON.
+func (ep endpoint) render(e events.Event) ([]byte, error) {
+	tmpl, ok := ep.templates[e.EventType()]
+	if !ok {
+		return json.Marshal(e)
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, e); err != nil {
+		return nil, fmt.Errorf("failed to execute the %s template for %s: %w", e.EventType(), ep.URL, err)
+	}
+	if !json.Valid(buf.Bytes()) {
+		return nil, fmt.Errorf("the %s template for %s produced invalid JSON", e.EventType(), ep.URL)
+	}
+	return buf.Bytes(), nil
+}
+
+// send posts the payload to the endpoint, retrying until it succeeds, a non-retryable error is
+// returned or the maximum number of attempts is reached. The number of attempts is returned.
+func (d *Dispatcher) send(ctx context.Context, ep endpoint, et events.EventType, payload []byte) (int, error) {
+	backoff := d.cfg.InitialBackoff
+	var err error
+	attempt := 0
+	for attempt < d.cfg.MaxAttempts {
+		attempt++
+		var retry bool
+		retry, err = d.post(ctx, ep, et, payload)
+		if err == nil || !retry || attempt >= d.cfg.MaxAttempts {
+			break
+		}
+
+		timer := time.NewTimer(backoff)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return attempt, ctx.Err()
+		case <-timer.C:
+		}
+		backoff *= 2
+		if backoff > d.cfg.MaxBackoff {
+			backoff = d.cfg.MaxBackoff
+		}
+	}
+	return attempt, err
+}
+
+// post sends a single request to the endpoint. It returns whether a failed request may be retried.
+func (d *Dispatcher) post(ctx context.Context, ep endpoint, et events.EventType, payload []byte) (bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, ep.URL, bytes.NewReader(payload))
+	if err != nil {
+		return false, err
+	}
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(EventHeader, string(et))
+	req.Header.Set(TimestampHeader, timestamp)
+	if ep.Secret != "" {
+		req.Header.Set(SignatureHeader, Sign(ep.Secret, timestamp, payload))
+	}
+
+	resp, err := d.cfg.HTTPClient.Do(req)
+	if err != nil {
+		return ctx.Err() == nil, err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(ioutil.Discard, resp.Body)
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return false, nil
+	}
+	err = fmt.Errorf("webhook error: url=%s, status=%d, reason=%s", ep.URL, resp.StatusCode, resp.Status)
+	retry := resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= http.StatusInternalServerError
+	return retry, err
+}
+
+// deadLetter appends an undeliverable event to the dead-letter file
+func (d *Dispatcher) deadLetter(url string, et events.EventType, payload []byte, attempts int, deliveryErr error) {
+	if d.cfg.DeadLetterFile == "" {
+		return
+	}
+	l := log.New()
+	defer l.Sync()
+
+	letter := DeadLetter{
+		Time:      time.Now(),
+		URL:       url,
+		EventType: et,
+		Payload:   json.RawMessage(payload),
+		Attempts:  attempts,
+		Error:     deliveryErr.Error(),
+	}
+	b, err := json.Marshal(letter)
+	if err != nil {
+		l.Errorf("failed to marshal the dead letter: %v", err)
+		return
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	f, err := os.OpenFile(d.cfg.DeadLetterFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		l.Errorf("failed to open the dead-letter file: %v", err)
+		return
+	}
+	defer f.Close()
+	if _, err := f.Write(append(b, '\n')); err != nil {
+		l.Errorf("failed to write to the dead-letter file: %v", err)
+	}
+}
+
+// Sign returns the signature for a request, which is the hex-encoded HMAC-SHA256 of the
+// timestamp, a '.' character and the request body, prefixed by "sha256=". Receivers can
+// compute the same value to verify that a request was sent by the dispatcher.
+func Sign(secret string, timestamp string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(payload)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify reports whether the signature is valid for the request.
+func Verify(secret string, timestamp string, payload []byte, signature string) bool {
+	return hmac.Equal([]byte(Sign(secret, timestamp, payload)), []byte(signature))
+}
+
+// templateFuncs are the functions available to templates
+var templateFuncs = template.FuncMap{
+	"json": func(v interface{}) (string, error) {
+		b, err := json.Marshal(v)
+		return string(b), err
+	},
+	"escape": func(s string) string {
+		b, _ := json.Marshal(s)
+		return string(b[1 : len(b)-1])
+	},
+	"warResult": warResult,
+}
+
+// warResult returns the result of the war for the war's Clan, which is "win", "lose" or "tie".
+func warResult(war *coc.ClanWar) string {
+	if war == nil {
+		return ""
+	}
+	if war.Result != "" {
+		return war.Result
+	}
+	switch winner := war.Winner(); {
+	case winner == nil:
+		return "tie"
+	case winner.Tag == war.Clan.Tag:
+		return "win"
+	default:
+		return "lose"
+	}
+}
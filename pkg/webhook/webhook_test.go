@@ -0,0 +1,255 @@
+package webhook
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/rbrabson/coc/pkg/events"
+	"github.com/rbrabson/coc/v1"
+)
+
+// request is a request received by the test server
+type request struct {
+	header http.Header
+	body   []byte
+}
+
+// newServer returns a server that responds to successive requests with the given status codes,
+// and records the requests it receives. Once the status codes are used up, it responds with
+// the last one.
+func newServer(t *testing.T, statuses ...int) (*httptest.Server, func() []request) {
+	t.Helper()
+	var mu sync.Mutex
+	var requests []request
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		mu.Lock()
+		requests = append(requests, request{header: r.Header.Clone(), body: body})
+		status := statuses[len(statuses)-1]
+		if len(requests) <= len(statuses) {
+			status = statuses[len(requests)-1]
+		}
+		mu.Unlock()
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, func() []request {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]request(nil), requests...)
+	}
+}
+
+// readDeadLetters returns the dead letters in the file
+func readDeadLetters(t *testing.T, path string) []DeadLetter {
+	t.Helper()
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	var letters []DeadLetter
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var letter DeadLetter
+		if err := json.Unmarshal(scanner.Bytes(), &letter); err != nil {
+			t.Fatal(err)
+		}
+		letters = append(letters, letter)
+	}
+	return letters
+}
+
+// memberJoined returns an event for a member with the given name joining a clan
+func memberJoined(name string) events.Event {
+	return events.MemberJoined{
+		Header:   events.Header{Type: events.EventMemberJoined, Time: time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)},
+		ClanTag:  "#2PP",
+		ClanName: `The "Best" Clan`,
+		Member:   coc.ClanMember{Tag: "#P1", Name: name},
+	}
+}
+
+func TestDispatchRetries(t *testing.T) {
+	tests := []struct {
+		name            string
+		statuses        []int
+		wantRequests    int
+		wantErr         bool
+		wantDeadLetters int
+	}{
+		{"delivered", []int{http.StatusOK}, 1, false, 0},
+		{"retried until delivered", []int{http.StatusInternalServerError, http.StatusTooManyRequests, http.StatusNoContent}, 3, false, 0},
+		{"attempts exhausted", []int{http.StatusBadGateway}, 3, true, 1},
+		{"not retryable", []int{http.StatusBadRequest}, 1, true, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv, requests := newServer(t, tt.statuses...)
+			deadLetterFile := filepath.Join(t.TempDir(), "dead.jsonl")
+			d, err := NewDispatcher(Config{
+				Endpoints:      []Endpoint{{URL: srv.URL}},
+				MaxAttempts:    3,
+				InitialBackoff: time.Millisecond,
+				MaxBackoff:     2 * time.Millisecond,
+				DeadLetterFile: deadLetterFile,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			err = d.Dispatch(context.Background(), memberJoined("Player"))
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Dispatch() error = %v, want error %v", err, tt.wantErr)
+			}
+			if got := len(requests()); got != tt.wantRequests {
+				t.Errorf("Dispatch() sent %d requests, want %d", got, tt.wantRequests)
+			}
+			letters := readDeadLetters(t, deadLetterFile)
+			if len(letters) != tt.wantDeadLetters {
+				t.Fatalf("Dispatch() wrote %d dead letters, want %d", len(letters), tt.wantDeadLetters)
+			}
+			for _, letter := range letters {
+				if letter.Attempts != tt.wantRequests || letter.EventType != events.EventMemberJoined || letter.URL != srv.URL {
+					t.Errorf("Dispatch() wrote dead letter %v", letter)
+				}
+			}
+		})
+	}
+}
+
+func TestDispatchSignature(t *testing.T) {
+	tests := []struct {
+		name   string
+		secret string
+	}{
+		{"signed", "s3cret"},
+		{"unsigned", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv, requests := newServer(t, http.StatusOK)
+			d, err := NewDispatcher(Config{Endpoints: []Endpoint{{URL: srv.URL, Secret: tt.secret}}})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := d.Dispatch(context.Background(), memberJoined("Player")); err != nil {
+				t.Fatal(err)
+			}
+
+			req := requests()[0]
+			if got := req.header.Get(EventHeader); got != string(events.EventMemberJoined) {
+				t.Errorf("%s = %q, want %q", EventHeader, got, events.EventMemberJoined)
+			}
+			signature := req.header.Get(SignatureHeader)
+			timestamp := req.header.Get(TimestampHeader)
+			if tt.secret == "" {
+				if signature != "" {
+					t.Errorf("unsigned request has signature %q", signature)
+				}
+				return
+			}
+			if !Verify(tt.secret, timestamp, req.body, signature) {
+				t.Errorf("Verify() = false for signature %q", signature)
+			}
+			if Verify("wrong", timestamp, req.body, signature) {
+				t.Error("Verify() = true using the wrong secret")
+			}
+			if Verify(tt.secret, timestamp, append(req.body, ' '), signature) {
+				t.Error("Verify() = true for a modified body")
+			}
+		})
+	}
+}
+
+func TestDispatchTemplates(t *testing.T) {
+	tests := []struct {
+		name           string
+		template       string
+		memberName     string
+		want           map[string]interface{}
+		wantDeadLetter bool
+	}{
+		{
+			name:       "escaped values",
+			template:   `{"clan": {{json .ClanName}}, "text": "{{escape .Member.Name}} joined"}`,
+			memberName: `Bob "the \ Builder"`,
+			want:       map[string]interface{}{"clan": `The "Best" Clan`, "text": `Bob "the \ Builder" joined`},
+		},
+		{
+			name:           "invalid JSON",
+			template:       `{"text": "{{.Member.Name}} joined"}`,
+			memberName:     `Bob "the Builder"`,
+			wantDeadLetter: true,
+		},
+		{
+			name:           "execution failure",
+			template:       `{"text": {{json .Missing}}}`,
+			memberName:     "Bob",
+			wantDeadLetter: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv, requests := newServer(t, http.StatusOK)
+			deadLetterFile := filepath.Join(t.TempDir(), "dead.jsonl")
+			d, err := NewDispatcher(Config{
+				Endpoints: []Endpoint{{
+					URL:       srv.URL,
+					Templates: map[events.EventType]string{events.EventMemberJoined: tt.template},
+				}},
+				DeadLetterFile: deadLetterFile,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			err = d.Dispatch(context.Background(), memberJoined(tt.memberName))
+			letters := readDeadLetters(t, deadLetterFile)
+			if tt.wantDeadLetter {
+				if err == nil {
+					t.Error("Dispatch() error = nil, want an error")
+				}
+				if len(requests()) != 0 {
+					t.Errorf("Dispatch() sent %d requests, want 0", len(requests()))
+				}
+				if len(letters) != 1 {
+					t.Fatalf("Dispatch() wrote %d dead letters, want 1", len(letters))
+				}
+				var e events.MemberJoined
+				if err := json.Unmarshal(letters[0].Payload, &e); err != nil || e.Member.Name != tt.memberName {
+					t.Errorf("dead letter payload = %s, want the event", letters[0].Payload)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got map[string]interface{}
+			if err := json.Unmarshal(requests()[0].body, &got); err != nil {
+				t.Fatalf("request body is invalid JSON: %v", err)
+			}
+			for k, v := range tt.want {
+				if got[k] != v {
+					t.Errorf("body[%q] = %q, want %q", k, got[k], v)
+				}
+			}
+			if len(letters) != 0 {
+				t.Errorf("Dispatch() wrote %d dead letters, want 0", len(letters))
+			}
+		})
+	}
+}
@@ -0,0 +1,216 @@
+package coc
+
+import (
+	"encoding/json"
+)
+
+// AchievementID identifies an achievement. The identifier is the achievement's name.
+type AchievementID string
+
+const (
+	AchievementBiggerCoffers          AchievementID = "Bigger Coffers"
+	AchievementGetThoseGoblins        AchievementID = "Get those Goblins!"
+	AchievementBiggerAndBetter        AchievementID = "Bigger & Better"
+	AchievementNiceAndTidy            AchievementID = "Nice and Tidy"
+	AchievementDiscoverNewTroops      AchievementID = "Discover New Troops"
+	AchievementGoldGrab               AchievementID = "Gold Grab"
+	AchievementElixirEscapade         AchievementID = "Elixir Escapade"
+	AchievementSweetVictory           AchievementID = "Sweet Victory!"
+	AchievementEmpireBuilder          AchievementID = "Empire Builder"
+	AchievementWallBuster             AchievementID = "Wall Buster"
+	AchievementHumiliator             AchievementID = "Humiliator"
+	AchievementUnionBuster            AchievementID = "Union Buster"
+	AchievementConqueror              AchievementID = "Conqueror"
+	AchievementUnbreakable            AchievementID = "Unbreakable"
+	AchievementFriendInNeed           AchievementID = "Friend in Need"
+	AchievementMortarMauler           AchievementID = "Mortar Mauler"
+	AchievementHeroicHeist            AchievementID = "Heroic Heist"
+	AchievementLeagueAllStar          AchievementID = "League All-Star"
+	AchievementXBowExterminator       AchievementID = "X-Bow Exterminator"
+	AchievementFirefighter            AchievementID = "Firefighter"
+	AchievementWarHero                AchievementID = "War Hero"
+	AchievementClanWarWealth          AchievementID = "Clan War Wealth"
+	AchievementAntiArtillery          AchievementID = "Anti-Artillery"
+	AchievementSharingIsCaring        AchievementID = "Sharing is caring"
+	AchievementKeepYourAccountSafe    AchievementID = "Keep Your Account Safe!"
+	AchievementMasterEngineering      AchievementID = "Master Engineering"
+	AchievementNextGenerationModel    AchievementID = "Next Generation Model"
+	AchievementUnBuildIt              AchievementID = "Un-Build It"
+	AchievementChampionBuilder        AchievementID = "Champion Builder"
+	AchievementHighGear               AchievementID = "High Gear"
+	AchievementHiddenTreasures        AchievementID = "Hidden Treasures"
+	AchievementGamesChampion          AchievementID = "Games Champion"
+	AchievementDragonSlayer           AchievementID = "Dragon Slayer"
+	AchievementWarLeagueLegend        AchievementID = "War League Legend"
+	AchievementWellSeasoned           AchievementID = "Well Seasoned"
+	AchievementShatteredAndScattered  AchievementID = "Shattered and Scattered"
+	AchievementNotSoEasyThisTime      AchievementID = "Not So Easy This Time"
+	AchievementBustThis               AchievementID = "Bust This!"
+	AchievementSuperbWork             AchievementID = "Superb Work"
+	AchievementSiegeSharer            AchievementID = "Siege Sharer"
+	AchievementAggressiveCapitalism   AchievementID = "Aggressive Capitalism"
+	AchievementMostValuableClanmate   AchievementID = "Most Valuable Clanmate"
+	AchievementCounterspell           AchievementID = "Counterspell"
+	AchievementMonolithMasher         AchievementID = "Monolith Masher"
+	AchievementUngratefulChild        AchievementID = "Ungrateful Child"
+	AchievementSupercharger           AchievementID = "Supercharger"
+	AchievementMultiArcherTowerTerror AchievementID = "Multi-Archer Tower Terror"
+	AchievementRicochetCannonCrusher  AchievementID = "Ricochet Cannon Crusher"
+	AchievementFirespitterFinisher    AchievementID = "Firespitter Finisher"
+	AchievementMultiGearTowerTrampler AchievementID = "Multi-Gear Tower Trampler"
+	AchievementCraftingConnoisseur    AchievementID = "Crafting Connoisseur"
+	AchievementCraftersNightmare      AchievementID = "Crafter's Nightmare"
+)
+
+const (
+	maxAchievementStars = 3
+)
+
+// ID returns the identifier of the achievement.
+func (a PlayerAchievement) ID() AchievementID {
+	return AchievementID(a.Name)
+}
+
+// IsComplete returns an indication as to whether all the stars for the achievement have
+// been earned.
+func (a PlayerAchievement) IsComplete() bool {
+	return a.Stars >= maxAchievementStars
+}
+
+// Progress returns the fraction of the way the player is towards the target for the next star.
+// One is returned if the achievement is complete.
+func (a PlayerAchievement) Progress() float64 {
+	if a.Target <= 0 || a.Value >= a.Target {
+		return 1
+	}
+	return float64(a.Value) / float64(a.Target)
+}
+
+// Achievement returns the player's progress for the achievement.
+func (p Player) Achievement(id AchievementID) (PlayerAchievement, bool) {
+	for _, a := range p.Achievements {
+		if a.ID() == id {
+			return a, true
+		}
+	}
+	return PlayerAchievement{}, false
+}
+
+// achievementValue returns the value for the achievement, or zero if the player doesn't have it
+func (p Player) achievementValue(id AchievementID) int {
+	a, _ := p.Achievement(id)
+	return a.Value
+}
+
+// LifetimeStats are statistics about a player across the life of their account, derived from
+// the player's achievements.
+type LifetimeStats struct {
+	TroopsDonated          int `json:"troopsDonated"`
+	SpellsDonated          int `json:"spellsDonated"`
+	SiegeMachinesDonated   int `json:"siegeMachinesDonated"`
+	WarStars               int `json:"warStars"`
+	CWLStars               int `json:"cwlStars"`
+	GoldLooted             int `json:"goldLooted"`
+	ElixirLooted           int `json:"elixirLooted"`
+	DarkElixirLooted       int `json:"darkElixirLooted"`
+	ClanWarLoot            int `json:"clanWarLoot"`
+	CapitalGoldContributed int `json:"capitalGoldContributed"`
+	CapitalGoldLooted      int `json:"capitalGoldLooted"`
+	ClanGamesPoints        int `json:"clanGamesPoints"`
+	MultiplayerBattlesWon  int `json:"multiplayerBattlesWon"`
+	DefensesWon            int `json:"defensesWon"`
+	ObstaclesRemoved       int `json:"obstaclesRemoved"`
+	WallsDestroyed         int `json:"wallsDestroyed"`
+	TownHallsDestroyed     int `json:"townHallsDestroyed"`
+	BuilderHallsDestroyed  int `json:"builderHallsDestroyed"`
+	SeasonChallengesPoints int `json:"seasonChallengesPoints"`
+	SuperchargesCompleted  int `json:"superchargesCompleted"`
+	WeaponizedTownHalls    int `json:"weaponizedTownHallsDestroyed"`
+	WeaponizedBuilderHalls int `json:"weaponizedBuilderHallsDestroyed"`
+	HighestTrophies        int `json:"highestTrophies"`
+	HighestBuilderTrophies int `json:"highestBuilderTrophies"`
+}
+
+// String returns a string representation of lifetime statistics
+func (s LifetimeStats) String() string {
+	b, _ := json.Marshal(s)
+	return string(b)
+}
+
+// LifetimeStats returns the player's lifetime statistics, derived from their achievements.
+func (p Player) LifetimeStats() LifetimeStats {
+	return LifetimeStats{
+		TroopsDonated:          p.achievementValue(AchievementFriendInNeed),
+		SpellsDonated:          p.achievementValue(AchievementSharingIsCaring),
+		SiegeMachinesDonated:   p.achievementValue(AchievementSiegeSharer),
+		WarStars:               p.achievementValue(AchievementWarHero),
+		CWLStars:               p.achievementValue(AchievementWarLeagueLegend),
+		GoldLooted:             p.achievementValue(AchievementGoldGrab),
+		ElixirLooted:           p.achievementValue(AchievementElixirEscapade),
+		DarkElixirLooted:       p.achievementValue(AchievementHeroicHeist),
+		ClanWarLoot:            p.achievementValue(AchievementClanWarWealth),
+		CapitalGoldContributed: p.achievementValue(AchievementMostValuableClanmate),
+		CapitalGoldLooted:      p.achievementValue(AchievementAggressiveCapitalism),
+		ClanGamesPoints:        p.achievementValue(AchievementGamesChampion),
+		MultiplayerBattlesWon:  p.achievementValue(AchievementConqueror),
+		DefensesWon:            p.achievementValue(AchievementUnbreakable),
+		ObstaclesRemoved:       p.achievementValue(AchievementNiceAndTidy),
+		WallsDestroyed:         p.achievementValue(AchievementWallBuster),
+		TownHallsDestroyed:     p.achievementValue(AchievementHumiliator),
+		BuilderHallsDestroyed:  p.achievementValue(AchievementUnBuildIt),
+		SeasonChallengesPoints: p.achievementValue(AchievementWellSeasoned),
+		SuperchargesCompleted:  p.achievementValue(AchievementSupercharger),
+		WeaponizedTownHalls:    p.achievementValue(AchievementNotSoEasyThisTime),
+		WeaponizedBuilderHalls: p.achievementValue(AchievementBustThis),
+		HighestTrophies:        p.achievementValue(AchievementSweetVictory),
+		HighestBuilderTrophies: p.achievementValue(AchievementChampionBuilder),
+	}
+}
+
+// AchievementProgress is the progress a player made on an achievement between two snapshots.
+type AchievementProgress struct {
+	ID         AchievementID `json:"id"`
+	Village    string        `json:"village"`
+	OldValue   int           `json:"oldValue"`
+	NewValue   int           `json:"newValue"`
+	Delta      int           `json:"delta"`
+	OldStars   int           `json:"oldStars"`
+	NewStars   int           `json:"newStars"`
+	StarEarned bool          `json:"starEarned"`
+	Completed  bool          `json:"completed"`
+}
+
+// String returns a string representation of achievement progress
+func (a AchievementProgress) String() string {
+	b, _ := json.Marshal(a)
+	return string(b)
+}
+
+// DiffAchievements returns the achievements on which the player progressed between two
+// snapshots of the player, such as those returned by successive calls to Client.GetPlayer.
+func DiffAchievements(old, new Player) []AchievementProgress {
+	oldAchievements := make(map[AchievementID]PlayerAchievement, len(old.Achievements))
+	for _, a := range old.Achievements {
+		oldAchievements[a.ID()] = a
+	}
+
+	var progress []AchievementProgress
+	for _, a := range new.Achievements {
+		prev := oldAchievements[a.ID()]
+		if a.Value == prev.Value && a.Stars == prev.Stars {
+			continue
+		}
+		progress = append(progress, AchievementProgress{
+			ID:         a.ID(),
+			Village:    a.Village,
+			OldValue:   prev.Value,
+			NewValue:   a.Value,
+			Delta:      a.Value - prev.Value,
+			OldStars:   prev.Stars,
+			NewStars:   a.Stars,
+			StarEarned: a.Stars > prev.Stars,
+			Completed:  a.IsComplete() && !prev.IsComplete(),
+		})
+	}
+	return progress
+}
@@ -0,0 +1,98 @@
+package coc
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestLifetimeStats(t *testing.T) {
+	p := Player{
+		Achievements: []PlayerAchievement{
+			{Name: string(AchievementFriendInNeed), Value: 125000, Village: "home"},
+			{Name: string(AchievementWarHero), Value: 1450, Village: "home"},
+			{Name: string(AchievementWarLeagueLegend), Value: 610, Village: "home"},
+			{Name: string(AchievementSweetVictory), Value: 5820, Village: "home"},
+			{Name: string(AchievementLeagueAllStar), Value: 22, Village: "home"},
+			{Name: string(AchievementChampionBuilder), Value: 4900, Village: "builderBase"},
+		},
+	}
+	want := LifetimeStats{
+		TroopsDonated:          125000,
+		WarStars:               1450,
+		CWLStars:               610,
+		HighestTrophies:        5820,
+		HighestBuilderTrophies: 4900,
+	}
+	if got := p.LifetimeStats(); !reflect.DeepEqual(got, want) {
+		t.Errorf("LifetimeStats() = %v, want %v", got, want)
+	}
+}
+
+func TestDiffAchievements(t *testing.T) {
+	friendInNeed := func(stars, value, target int) PlayerAchievement {
+		return PlayerAchievement{Name: string(AchievementFriendInNeed), Stars: stars, Value: value, Target: target, Village: "home"}
+	}
+	warHero := func(stars, value, target int) PlayerAchievement {
+		return PlayerAchievement{Name: string(AchievementWarHero), Stars: stars, Value: value, Target: target, Village: "home"}
+	}
+	tests := []struct {
+		name string
+		old  []PlayerAchievement
+		new  []PlayerAchievement
+		want []AchievementProgress
+	}{
+		{
+			name: "no change",
+			old:  []PlayerAchievement{friendInNeed(2, 4000, 25000)},
+			new:  []PlayerAchievement{friendInNeed(2, 4000, 25000)},
+		},
+		{
+			name: "progress",
+			old:  []PlayerAchievement{friendInNeed(2, 4000, 25000), warHero(1, 20, 150)},
+			new:  []PlayerAchievement{friendInNeed(2, 4500, 25000), warHero(1, 20, 150)},
+			want: []AchievementProgress{
+				{ID: AchievementFriendInNeed, Village: "home", OldValue: 4000, NewValue: 4500, Delta: 500, OldStars: 2, NewStars: 2},
+			},
+		},
+		{
+			name: "star earned",
+			old:  []PlayerAchievement{warHero(1, 148, 150)},
+			new:  []PlayerAchievement{warHero(2, 152, 1000)},
+			want: []AchievementProgress{
+				{ID: AchievementWarHero, Village: "home", OldValue: 148, NewValue: 152, Delta: 4, OldStars: 1, NewStars: 2, StarEarned: true},
+			},
+		},
+		{
+			name: "completed",
+			old:  []PlayerAchievement{friendInNeed(2, 24990, 25000)},
+			new:  []PlayerAchievement{friendInNeed(3, 25010, 25000)},
+			want: []AchievementProgress{
+				{ID: AchievementFriendInNeed, Village: "home", OldValue: 24990, NewValue: 25010, Delta: 20, OldStars: 2, NewStars: 3, StarEarned: true, Completed: true},
+			},
+		},
+		{
+			name: "already completed",
+			old:  []PlayerAchievement{friendInNeed(3, 25010, 25000)},
+			new:  []PlayerAchievement{friendInNeed(3, 26000, 25000)},
+			want: []AchievementProgress{
+				{ID: AchievementFriendInNeed, Village: "home", OldValue: 25010, NewValue: 26000, Delta: 990, OldStars: 3, NewStars: 3},
+			},
+		},
+		{
+			name: "new achievement",
+			old:  []PlayerAchievement{friendInNeed(2, 4000, 25000)},
+			new:  []PlayerAchievement{friendInNeed(2, 4000, 25000), warHero(1, 12, 150)},
+			want: []AchievementProgress{
+				{ID: AchievementWarHero, Village: "home", OldValue: 0, NewValue: 12, Delta: 12, OldStars: 0, NewStars: 1, StarEarned: true},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := DiffAchievements(Player{Achievements: tt.old}, Player{Achievements: tt.new})
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("DiffAchievements() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,73 @@
+package coc
+
+import (
+	"sync"
+	"time"
+)
+
+// cacheExpiry tracks when the responses returned by the Clash of Clans API server expire from
+// the server's cache. Requesting the same information before then returns the cached response.
+type cacheExpiry struct {
+	mu      sync.Mutex
+	expires map[string]time.Time
+}
+
+// newCacheExpiry creates a new cache expiry tracker
+func newCacheExpiry() *cacheExpiry {
+	return &cacheExpiry{expires: make(map[string]time.Time)}
+}
+
+// set records the time at which the response for the URL expires
+func (ce *cacheExpiry) set(url string, expires time.Time) {
+	if ce == nil {
+		return
+	}
+	ce.mu.Lock()
+	defer ce.mu.Unlock()
+
+	// Remove any entries that have already expired, so the map doesn't continue to grow
+	now := time.Now()
+	for k, v := range ce.expires {
+		if v.Before(now) {
+			delete(ce.expires, k)
+		}
+	}
+	if !expires.IsZero() {
+		ce.expires[url] = expires
+	}
+}
+
+// get returns the time at which the response for the URL expires
+func (ce *cacheExpiry) get(url string) time.Time {
+	if ce == nil {
+		return time.Time{}
+	}
+	ce.mu.Lock()
+	defer ce.mu.Unlock()
+	return ce.expires[url]
+}
+
+// ClanCacheExpiry returns the time at which the clan information retrieved by GetClan expires
+// from the server's cache. Retrieving the clan before then returns the same information. The
+// zero time is returned if the clan hasn't been retrieved or the cached information has expired.
+func (c *Client) ClanCacheExpiry(clanTag string) time.Time {
+	return c.expiry.get(c.baseURL + "/clans/" + fmtTag(clanTag))
+}
+
+// PlayerCacheExpiry returns the time at which the player information retrieved by GetPlayer
+// expires from the server's cache.
+func (c *Client) PlayerCacheExpiry(playerTag string) time.Time {
+	return c.expiry.get(c.baseURL + "/players/" + fmtTag(playerTag))
+}
+
+// ClanWarCurrentCacheExpiry returns the time at which the war information retrieved by
+// GetClanWarCurrent expires from the server's cache.
+func (c *Client) ClanWarCurrentCacheExpiry(clanTag string) time.Time {
+	return c.expiry.get(c.baseURL + "/clans/" + fmtTag(clanTag) + "/currentwar")
+}
+
+// CapitalRaidSeasonsCacheExpiry returns the time at which the raid seasons retrieved by
+// ListCapitalRaidSeasons expire from the server's cache.
+func (c *Client) CapitalRaidSeasonsCacheExpiry(clanTag string) time.Time {
+	return c.expiry.get(c.baseURL + "/clans/" + fmtTag(clanTag) + "/capitalraidseasons")
+}
@@ -6,27 +6,28 @@ import (
 
 // Clan is a clan in Clash of Clans.
 type Clan struct {
-	BadgeUrls        BadgeUrls     `json:"badgeUrls"`
-	ChatLanguage     ChatLanguage  `json:"chatLanguage"`
-	ClanLevel        int           `json:"clanLevel"`
-	ClanPoints       int           `json:"clanPoints"`
-	ClanVersusPoints int           `json:"clanVersusPoints"`
-	Description      string        `json:"description"`
-	IsWarLogPublic   bool          `json:"isWarLogPublic"`
-	Labels           []Label       `json:"labels"`
-	Location         Location      `json:"location"`
-	Members          int           `json:"members"`
-	MemberList       []ClanMember  `json:"memberList"`
-	Name             string        `json:"name"`
-	RequiredTrophies int           `json:"requiredTrophies"`
-	Tag              string        `json:"tag"`
-	Type             string        `json:"type"`
-	WarFrequency     string        `json:"warFrequency"`
-	WarLeague        ClanWarLeague `json:"warLeague"`
-	WarLosses        int           `json:"warLosses"`
-	WarTies          int           `json:"warTies"`
-	WarWins          int           `json:"warWins"`
-	WarWinStreak     int           `json:"warWinStreak"`
+	BadgeUrls         BadgeUrls     `json:"badgeUrls"`
+	ChatLanguage      ChatLanguage  `json:"chatLanguage"`
+	ClanCapitalPoints int           `json:"clanCapitalPoints"`
+	ClanLevel         int           `json:"clanLevel"`
+	ClanPoints        int           `json:"clanPoints"`
+	ClanVersusPoints  int           `json:"clanVersusPoints"`
+	Description       string        `json:"description"`
+	IsWarLogPublic    bool          `json:"isWarLogPublic"`
+	Labels            []Label       `json:"labels"`
+	Location          Location      `json:"location"`
+	Members           int           `json:"members"`
+	MemberList        []ClanMember  `json:"memberList"`
+	Name              string        `json:"name"`
+	RequiredTrophies  int           `json:"requiredTrophies"`
+	Tag               string        `json:"tag"`
+	Type              string        `json:"type"`
+	WarFrequency      string        `json:"warFrequency"`
+	WarLeague         ClanWarLeague `json:"warLeague"`
+	WarLosses         int           `json:"warLosses"`
+	WarTies           int           `json:"warTies"`
+	WarWins           int           `json:"warWins"`
+	WarWinStreak      int           `json:"warWinStreak"`
 }
 
 // String returns a string representation of a clan
@@ -2,18 +2,58 @@ package coc
 
 import (
 	"encoding/json"
+	"time"
+)
+
+// WarType is the type of a clan war.
+type WarType string
+
+const (
+	WarTypeRegular  WarType = "regular"  // A random, matchmade war
+	WarTypeFriendly WarType = "friendly" // A friendly war between two clans
+	WarTypeCWL      WarType = "cwl"      // A war that is part of a clan war league
+	WarTypeUnknown  WarType = "unknown"  // The type of war couldn't be determined
+)
+
+const (
+	regularAttacksPerMember = 2
+	cwlAttacksPerMember     = 1
+	regularPreparationTime  = 23 * time.Hour
+)
+
+var (
+	// friendlyPreparationTimes are the preparation times that may be selected for a friendly war
+	friendlyPreparationTimes = []time.Duration{
+		5 * time.Minute,
+		15 * time.Minute,
+		30 * time.Minute,
+		1 * time.Hour,
+		2 * time.Hour,
+		4 * time.Hour,
+		6 * time.Hour,
+		8 * time.Hour,
+		12 * time.Hour,
+		16 * time.Hour,
+		20 * time.Hour,
+		24 * time.Hour,
+	}
 )
 
 // ClanWar is a given war in a clan's war log.
 type ClanWar struct {
 	State                string      `json:"state,omitempty"`
 	TeamSize             int         `json:"teamSize"`
+	AttacksPerMember     int         `json:"attacksPerMember,omitempty"`
+	BattleModifier       string      `json:"battleModifier,omitempty"`
 	PreparationStartTime Time        `json:"preparationStartTime,omitempty"`
 	StartTime            Time        `json:"startTime,omitempty"`
+	WarStartTime         Time        `json:"warStartTime,omitempty"`
 	EndTime              Time        `json:"endTime,omitempty"`
 	Result               string      `json:"result,omitempty"`
 	Clan                 ClanWarTeam `json:"clan"`
 	Opponent             ClanWarTeam `json:"opponent"`
+	Type                 WarType     `json:"type,omitempty"`
+	WarTag               string      `json:"warTag,omitempty"`
 }
 
 // String returns a string representation of a clan war
@@ -22,6 +62,92 @@ func (cw ClanWar) String() string {
 	return string(b)
 }
 
+// WarType returns the type of the war. If the type was set when the war was retrieved it is
+// returned. Otherwise the type is inferred, first from the war tag and number of attacks per
+// member, and then from the length of the preparation day. The server doesn't report the type
+// of a war, so the inferred type is a heuristic; WarTypeUnknown is returned when the war
+// doesn't match any of the known patterns rather than guessing.
+func (cw ClanWar) WarType() WarType {
+	if cw.Type != "" {
+		return cw.Type
+	}
+
+	// Only clan war league wars are identified by a war tag, and only they limit each member
+	// to a single attack
+	if cw.WarTag != "" || cw.AttacksPerMember == cwlAttacksPerMember {
+		return WarTypeCWL
+	}
+
+	// Regular wars always have a 23 hour preparation day, while friendly wars use one of a set
+	// of selectable preparation times
+	start := time.Time(cw.StartTime)
+	prepStart := time.Time(cw.PreparationStartTime)
+	if start.IsZero() || prepStart.IsZero() {
+		return WarTypeUnknown
+	}
+	prep := start.Sub(prepStart)
+	if prep == regularPreparationTime {
+		return WarTypeRegular
+	}
+	for _, d := range friendlyPreparationTimes {
+		if prep == d {
+			return WarTypeFriendly
+		}
+	}
+	return WarTypeUnknown
+}
+
+// MaxAttacksPerMember returns the number of attacks each member may make in the war. If the
+// server didn't return the value, the default for the type of war is returned.
+func (cw ClanWar) MaxAttacksPerMember() int {
+	if cw.AttacksPerMember != 0 {
+		return cw.AttacksPerMember
+	}
+	if cw.WarType() == WarTypeCWL {
+		return cwlAttacksPerMember
+	}
+	return regularAttacksPerMember
+}
+
+// ForClan returns the war from the perspective of the clan with the given tag, so that the
+// clan is the war's Clan and the other clan is the Opponent. False is returned if the clan
+// isn't participating in the war.
+func (cw ClanWar) ForClan(clanTag string) (ClanWar, bool) {
+	clanTag = NormalizeTag(clanTag)
+	switch clanTag {
+	case NormalizeTag(cw.Clan.Tag):
+		return cw, true
+	case NormalizeTag(cw.Opponent.Tag):
+		cw.Clan, cw.Opponent = cw.Opponent, cw.Clan
+		switch cw.Result {
+		case "win":
+			cw.Result = "lose"
+		case "lose":
+			cw.Result = "win"
+		}
+		return cw, true
+	default:
+		return cw, false
+	}
+}
+
+// Winner returns the team that won, or is currently winning, the war. The team with the most
+// stars wins, with ties broken by the destruction percentage. Nil is returned for a tie.
+func (cw ClanWar) Winner() *ClanWarTeam {
+	switch {
+	case cw.Clan.Stars > cw.Opponent.Stars:
+		return &cw.Clan
+	case cw.Clan.Stars < cw.Opponent.Stars:
+		return &cw.Opponent
+	case cw.Clan.DestructionPercentage > cw.Opponent.DestructionPercentage:
+		return &cw.Clan
+	case cw.Clan.DestructionPercentage < cw.Opponent.DestructionPercentage:
+		return &cw.Opponent
+	default:
+		return nil
+	}
+}
+
 // ClanWarTeam is the clan that is participating in the clan war.
 type ClanWarTeam struct {
 	Attacks               int             `json:"attacks"`
@@ -65,6 +191,7 @@ type ClanWarAttack struct {
 	DefenderTag           string `json:"defenderTag"`
 	Stars                 int    `json:"stars"`
 	DestructionPercentage int    `json:"destructionPercentage"`
+	Duration              int    `json:"duration"`
 }
 
 // String returns a string representation of a clan war atack
@@ -72,3 +199,8 @@ func (cwa ClanWarAttack) String() string {
 	b, _ := json.Marshal(cwa)
 	return string(b)
 }
+
+// AttackDuration returns the length of time the attack took.
+func (cwa ClanWarAttack) AttackDuration() time.Duration {
+	return time.Duration(cwa.Duration) * time.Second
+}
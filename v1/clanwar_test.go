@@ -0,0 +1,38 @@
+package coc
+
+import (
+	"testing"
+	"time"
+)
+
+func TestWarType(t *testing.T) {
+	prepStart := time.Date(2024, 3, 1, 7, 13, 0, 0, time.UTC)
+	withPrep := func(prep time.Duration) ClanWar {
+		return ClanWar{
+			PreparationStartTime: NewTime(prepStart),
+			StartTime:            NewTime(prepStart.Add(prep)),
+			EndTime:              NewTime(prepStart.Add(prep + 24*time.Hour)),
+		}
+	}
+
+	tests := []struct {
+		name string
+		war  ClanWar
+		want WarType
+	}{
+		{"type set", ClanWar{Type: WarTypeFriendly, WarTag: "#8QJ0PV0RG"}, WarTypeFriendly},
+		{"war tag", ClanWar{WarTag: "#8QJ0PV0RG"}, WarTypeCWL},
+		{"single attack", ClanWar{AttacksPerMember: 1}, WarTypeCWL},
+		{"regular preparation", withPrep(23 * time.Hour), WarTypeRegular},
+		{"friendly preparation", withPrep(4 * time.Hour), WarTypeFriendly},
+		{"unrecognized preparation", withPrep(3 * time.Hour), WarTypeUnknown},
+		{"no times", ClanWar{AttacksPerMember: 2}, WarTypeUnknown},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.war.WarType(); got != tt.want {
+				t.Errorf("WarType() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -2,14 +2,16 @@ package coc
 
 import (
 	"encoding/json"
+	"net/http"
 	"strings"
+	"time"
 
 	"github.com/rbrabson/coc/pkg/log"
 	"github.com/rbrabson/coc/pkg/rest"
 )
 
 const (
-	baseURL = "https://api.clashofclans.com/v1"
+	DefaultBaseURL = "https://api.clashofclans.com/v1"
 )
 
 var (
@@ -24,13 +26,61 @@ var (
 
 // Client is a Clash of Clans client that may be used to retrieve information.
 type Client struct {
-	token string
+	token     string
+	baseURL   string
+	timeout   time.Duration
+	transport http.RoundTripper
+	detectCWL bool
+	expiry    *cacheExpiry
+}
+
+// ClientOption is an optional setting for a client.
+type ClientOption func(*Client)
+
+// WithBaseURL sets the base URL of the Clash of Clans API, such as that of a proxy. The default
+// is DefaultBaseURL.
+func WithBaseURL(url string) ClientOption {
+	return func(c *Client) {
+		if url != "" {
+			c.baseURL = strings.TrimSuffix(url, "/")
+		}
+	}
+}
+
+// WithTimeout sets the time limit for each request sent to the Clash of Clans API. By default,
+// requests don't time out.
+func WithTimeout(timeout time.Duration) ClientOption {
+	return func(c *Client) {
+		c.timeout = timeout
+	}
+}
+
+// WithTransport sets the transport used to send requests to the Clash of Clans API, such as one
+// that caches responses. Transports may wrap rest.DefaultTransport.
+func WithTransport(transport http.RoundTripper) ClientOption {
+	return func(c *Client) {
+		c.transport = transport
+	}
+}
+
+// WithCWLDetection causes GetClanWarCurrent to return ErrInCWL rather than ErrNotInWar when
+// the clan isn't in a regular war but is participating in a clan war league. This requires an
+// additional request for the clan's league group each time the clan isn't in a war, so it is
+// disabled by default.
+func WithCWLDetection() ClientOption {
+	return func(c *Client) {
+		c.detectCWL = true
+	}
 }
 
 // NewClient creates a new Clash of Clans client that access the Clash of Clans API using the
 // provided bearer token
-func NewClient(token string) Client {
-	return Client{token: token}
+func NewClient(token string, opts ...ClientOption) Client {
+	c := Client{token: token, baseURL: DefaultBaseURL, expiry: newCacheExpiry()}
+	for _, opt := range opts {
+		opt(&c)
+	}
+	return c
 }
 
 // GetClan retrieves information about a single clan by clan tag. Clan tags can be found using
@@ -46,14 +96,14 @@ func (c *Client) GetClan(clanTag string) (*Clan, error) {
 	// Build the URL
 	var sb strings.Builder
 	sb.Grow(100)
-	sb.WriteString(baseURL)
+	sb.WriteString(c.baseURL)
 	sb.WriteString("/clans/")
 	sb.WriteString(fmtTag(clanTag))
 	url := sb.String()
 	l.Debug(url)
 
 	// Get the clan
-	body, err := getURL(url, nil, c.token)
+	body, err := c.getURL(url, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -87,7 +137,7 @@ func (c *Client) GetClanLabels(qparms ...QParms) ([]Label, *Paging, error) {
 
 	var sb strings.Builder
 	sb.Grow(100)
-	sb.WriteString(baseURL)
+	sb.WriteString(c.baseURL)
 	sb.WriteString("/labels/clans/")
 	url := sb.String()
 	l.Debug(url)
@@ -97,7 +147,7 @@ func (c *Client) GetClanLabels(qparms ...QParms) ([]Label, *Paging, error) {
 	if len(qparms) >= 1 {
 		qp = &qparms[0]
 	}
-	body, err := getURL(url, getQueryParms(qp), c.token)
+	body, err := c.getURL(url, getQueryParms(qp))
 	if err != nil {
 		return nil, nil, err
 	}
@@ -137,7 +187,7 @@ func (c *Client) GetClanMembers(clanTag string, qparms ...QParms) ([]ClanMember,
 
 	var sb strings.Builder
 	sb.Grow(100)
-	sb.WriteString(baseURL)
+	sb.WriteString(c.baseURL)
 	sb.WriteString("/clans/")
 	sb.WriteString(fmtTag(clanTag))
 	sb.WriteString("/members")
@@ -149,7 +199,7 @@ func (c *Client) GetClanMembers(clanTag string, qparms ...QParms) ([]ClanMember,
 	if len(qparms) >= 1 {
 		qp = &qparms[0]
 	}
-	body, err := getURL(url, getQueryParms(qp), c.token)
+	body, err := c.getURL(url, getQueryParms(qp))
 	if err != nil {
 		return nil, nil, err
 	}
@@ -189,7 +239,7 @@ func (c *Client) GetClanRankings(locationID string, qparms ...QParms) ([]ClanRan
 
 	var sb strings.Builder
 	sb.Grow(100)
-	sb.WriteString(baseURL)
+	sb.WriteString(c.baseURL)
 	sb.WriteString("/locations/")
 	sb.WriteString(fmtTag(locationID))
 	sb.WriteString("/rankings/clans")
@@ -201,7 +251,7 @@ func (c *Client) GetClanRankings(locationID string, qparms ...QParms) ([]ClanRan
 	if len(qparms) >= 1 {
 		qp = &qparms[0]
 	}
-	body, err := getURL(url, getQueryParms(qp), c.token)
+	body, err := c.getURL(url, getQueryParms(qp))
 	if err != nil {
 		return nil, nil, err
 	}
@@ -242,7 +292,7 @@ func (c *Client) GetClanVersusRankings(locationID string, qparms ...QParms) ([]C
 
 	var sb strings.Builder
 	sb.Grow(100)
-	sb.WriteString(baseURL)
+	sb.WriteString(c.baseURL)
 	sb.WriteString("/locations/")
 	sb.WriteString(fmtTag(locationID))
 	sb.WriteString("/rankings/clan-versus")
@@ -254,7 +304,7 @@ func (c *Client) GetClanVersusRankings(locationID string, qparms ...QParms) ([]C
 	if len(qparms) >= 1 {
 		qp = &qparms[0]
 	}
-	body, err := getURL(url, getQueryParms(qp), c.token)
+	body, err := c.getURL(url, getQueryParms(qp))
 	if err != nil {
 		return nil, nil, err
 	}
@@ -320,12 +370,12 @@ func (c *Client) SearchClans(qparms QParms) ([]Clan, *Paging, error) {
 
 	var sb strings.Builder
 	sb.Grow(100)
-	sb.WriteString(baseURL)
+	sb.WriteString(c.baseURL)
 	sb.WriteString("/clans")
 	url := sb.String()
 	l.Debug(url)
 
-	body, err := getURL(url, getQueryParms(&qparms), c.token)
+	body, err := c.getURL(url, getQueryParms(&qparms))
 	if err != nil {
 		return nil, nil, err
 	}
@@ -345,7 +395,8 @@ func (c *Client) SearchClans(qparms QParms) ([]Clan, *Paging, error) {
 	return resp.Clans, &resp.Paging, nil
 }
 
-// GetClanWarLog retrieves clan's clan war log. Supported query parmeters are:
+// GetClanWarLog retrieves clan's clan war log. If the clan's war log is private, ErrPrivateWarLog
+// is returned. Supported query parmeters are:
 //
 // - limit: an integer that limits the number of items returned in the response
 //
@@ -365,7 +416,7 @@ func (c *Client) GetClanWarLog(clanTag string, qparms ...QParms) ([]ClanWar, *Pa
 
 	var sb strings.Builder
 	sb.Grow(100)
-	sb.WriteString(baseURL)
+	sb.WriteString(c.baseURL)
 	sb.WriteString("/clans/")
 	sb.WriteString(fmtTag(clanTag))
 	sb.WriteString("/warlog")
@@ -377,9 +428,9 @@ func (c *Client) GetClanWarLog(clanTag string, qparms ...QParms) ([]ClanWar, *Pa
 	if len(qparms) >= 1 {
 		qp = &qparms[0]
 	}
-	body, err := getURL(url, getQueryParms(qp), c.token)
+	body, err := c.getURL(url, getQueryParms(qp))
 	if err != nil {
-		return nil, nil, err
+		return nil, nil, warError(err)
 	}
 
 	// Parse into an array of clans
@@ -423,7 +474,21 @@ func (c *Client) GetClanWarLog(clanTag string, qparms ...QParms) ([]ClanWar, *Pa
 	return warLog[:i], &resp.Paging, nil
 }
 
-// GetClanWarCurrent retrieves information about clan's current clan war.
+// GetClanWarCurrent retrieves information about clan's current clan war. The following errors
+// are returned when information about the war isn't available:
+//
+// - ErrPrivateWarLog: the clan's war log is private.
+//
+// - ErrNotInWar: the clan isn't in a war.
+//
+// - ErrInCWL: the clan is participating in a clan war league. This is only returned by clients
+// created using WithCWLDetection; otherwise ErrNotInWar is returned. The wars can be retrieved
+// using the GetClanWarLeagueGroup and GetClanWarLeagueWar functions.
+//
+// - ErrClanNotFound: the clan doesn't exist.
+//
+// A war that has ended is returned without an error, so the war's State or Phase should be
+// checked to determine whether it is still in progress.
 func (c *Client) GetClanWarCurrent(clanTag string) (*ClanWar, error) {
 	const M = "Client.GetClanWarCurrent"
 	l := log.New()
@@ -434,7 +499,7 @@ func (c *Client) GetClanWarCurrent(clanTag string) (*ClanWar, error) {
 
 	var sb strings.Builder
 	sb.Grow(100)
-	sb.WriteString(baseURL)
+	sb.WriteString(c.baseURL)
 	sb.WriteString("/clans/")
 	sb.WriteString(fmtTag(clanTag))
 	sb.WriteString("/currentwar")
@@ -442,9 +507,9 @@ func (c *Client) GetClanWarCurrent(clanTag string) (*ClanWar, error) {
 	l.Debug(url)
 
 	// Send the request and get the response
-	body, err := getURL(url, nil, c.token)
+	body, err := c.getURL(url, nil)
 	if err != nil {
-		return nil, err
+		return nil, warError(err)
 	}
 
 	// Parse into a war
@@ -454,11 +519,21 @@ func (c *Client) GetClanWarCurrent(clanTag string) (*ClanWar, error) {
 		return nil, err
 	}
 
-	// Check to see if the clan is in a war
-	if war.State == "notInWar" {
+	// Check to see if the clan is in a war. While in a clan war league, the current war
+	// isn't available, so if requested check the league group instead.
+	if war.State == string(WarPhaseNotInWar) {
+		if c.detectCWL {
+			group, err := c.GetClanWarLeagueGroup(clanTag)
+			if err == nil && (group.State == string(WarPhasePreparation) || group.State == string(WarPhaseInWar)) {
+				return nil, ErrInCWL
+			}
+		}
 		return nil, ErrNotInWar
 	}
 
+	// Set the type of war, as it isn't returned by the server
+	war.Type = war.WarType()
+
 	return &war, nil
 }
 
@@ -473,14 +548,14 @@ func (c *Client) GetClanWarLeagueGroup(clanTag string) (*ClanWarLeagueGroup, err
 
 	var sb strings.Builder
 	sb.Grow(100)
-	sb.WriteString(baseURL)
+	sb.WriteString(c.baseURL)
 	sb.WriteString("/clans/")
 	sb.WriteString(fmtTag(clanTag))
 	sb.WriteString("/currentwar/leaguegroup")
 	url := sb.String()
 	l.Debug(url)
 
-	body, err := getURL(url, nil, c.token)
+	body, err := c.getURL(url, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -507,18 +582,18 @@ func (c *Client) GetClanWarLeagueWar(warTag string) (*ClanWarLeagueWar, error) {
 
 	var sb strings.Builder
 	sb.Grow(100)
-	sb.WriteString(baseURL)
+	sb.WriteString(c.baseURL)
 	sb.WriteString("/clanwarleagues/wars/")
 	sb.WriteString(fmtTag(warTag))
 	url := sb.String()
 	l.Debug(url)
 
-	body, err := getURL(url, nil, c.token)
+	body, err := c.getURL(url, nil)
 	if err != nil {
 		return nil, err
 	}
 
-	// Parse into an array of clans
+	// Parse into a clan war league war
 	var resp ClanWarLeagueWar
 	err = json.Unmarshal(body, &resp)
 	if err != nil {
@@ -526,6 +601,12 @@ func (c *Client) GetClanWarLeagueWar(warTag string) (*ClanWarLeagueWar, error) {
 		return nil, err
 	}
 
+	// The war tag and type of war aren't returned by the server, so set them here
+	if resp.WarTag == "" {
+		resp.WarTag = NormalizeTag(warTag)
+	}
+	resp.Type = WarTypeCWL
+
 	return &resp, nil
 }
 
@@ -540,13 +621,13 @@ func (c *Client) GetLeague(leagueID string) (*League, error) {
 
 	var sb strings.Builder
 	sb.Grow(100)
-	sb.WriteString(baseURL)
+	sb.WriteString(c.baseURL)
 	sb.WriteString("/leagues/")
 	sb.WriteString(fmtTag(leagueID))
 	url := sb.String()
 	l.Debug(url)
 
-	body, err := getURL(url, nil, c.token)
+	body, err := c.getURL(url, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -585,7 +666,7 @@ func (c *Client) GetLeagues(qparms ...QParms) ([]League, *Paging, error) {
 
 	var sb strings.Builder
 	sb.Grow(100)
-	sb.WriteString(baseURL)
+	sb.WriteString(c.baseURL)
 	sb.WriteString("/leagues/")
 	url := sb.String()
 	l.Debug(url)
@@ -595,7 +676,7 @@ func (c *Client) GetLeagues(qparms ...QParms) ([]League, *Paging, error) {
 	if len(qparms) >= 1 {
 		qp = &qparms[0]
 	}
-	body, err := getURL(url, getQueryParms(qp), c.token)
+	body, err := c.getURL(url, getQueryParms(qp))
 	if err != nil {
 		return nil, nil, err
 	}
@@ -636,7 +717,7 @@ func (c *Client) GetLeagueSeasons(leagueID string, qparms ...QParms) ([]LeagueSe
 
 	var sb strings.Builder
 	sb.Grow(100)
-	sb.WriteString(baseURL)
+	sb.WriteString(c.baseURL)
 	sb.WriteString("/leagues/")
 	sb.WriteString(fmtTag(leagueID))
 	sb.WriteString("/seasons")
@@ -648,7 +729,7 @@ func (c *Client) GetLeagueSeasons(leagueID string, qparms ...QParms) ([]LeagueSe
 	if len(qparms) >= 1 {
 		qp = &qparms[0]
 	}
-	body, err := getURL(url, getQueryParms(qp), c.token)
+	body, err := c.getURL(url, getQueryParms(qp))
 	if err != nil {
 		return nil, nil, err
 	}
@@ -689,13 +770,13 @@ func (c *Client) GetLeagueSeasonRankings(leagueID string) ([]LeagueSeasonRanking
 
 	var sb strings.Builder
 	sb.Grow(100)
-	sb.WriteString(baseURL)
+	sb.WriteString(c.baseURL)
 	sb.WriteString("/leagues/")
 	sb.WriteString(fmtTag(leagueID))
 	url := sb.String()
 	l.Debug(url)
 
-	body, err := getURL(url, nil, c.token)
+	body, err := c.getURL(url, nil)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -726,13 +807,13 @@ func (c *Client) GetWarLeague(leagueID string) (*WarLeague, error) {
 
 	var sb strings.Builder
 	sb.Grow(100)
-	sb.WriteString(baseURL)
+	sb.WriteString(c.baseURL)
 	sb.WriteString("/warleagues/")
 	sb.WriteString(fmtTag(leagueID))
 	url := sb.String()
 	l.Debug(url)
 
-	body, err := getURL(url, nil, c.token)
+	body, err := c.getURL(url, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -771,7 +852,7 @@ func (c *Client) GetWarLeagues(qparms ...QParms) ([]WarLeague, *Paging, error) {
 
 	var sb strings.Builder
 	sb.Grow(100)
-	sb.WriteString(baseURL)
+	sb.WriteString(c.baseURL)
 	sb.WriteString("/warleagues/")
 	url := sb.String()
 	l.Debug(url)
@@ -781,7 +862,7 @@ func (c *Client) GetWarLeagues(qparms ...QParms) ([]WarLeague, *Paging, error) {
 	if len(qparms) >= 1 {
 		qp = &qparms[0]
 	}
-	body, err := getURL(url, getQueryParms(qp), c.token)
+	body, err := c.getURL(url, getQueryParms(qp))
 	if err != nil {
 		return nil, nil, err
 	}
@@ -812,13 +893,13 @@ func (c *Client) GetLocation(locationID string) (*Location, error) {
 
 	var sb strings.Builder
 	sb.Grow(100)
-	sb.WriteString(baseURL)
+	sb.WriteString(c.baseURL)
 	sb.WriteString("/locations/")
 	sb.WriteString(fmtTag(locationID))
 	url := sb.String()
 	l.Debug(url)
 
-	body, err := getURL(url, nil, c.token)
+	body, err := c.getURL(url, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -858,7 +939,7 @@ func (c *Client) GetLocations(qparms ...QParms) ([]Location, *Paging, error) {
 
 	var sb strings.Builder
 	sb.Grow(100)
-	sb.WriteString(baseURL)
+	sb.WriteString(c.baseURL)
 	sb.WriteString("/locations")
 	url := sb.String()
 	l.Debug(url)
@@ -868,7 +949,7 @@ func (c *Client) GetLocations(qparms ...QParms) ([]Location, *Paging, error) {
 	if len(qparms) >= 1 {
 		qp = &qparms[0]
 	}
-	body, err := getURL(url, getQueryParms(qp), c.token)
+	body, err := c.getURL(url, getQueryParms(qp))
 	if err != nil {
 		return nil, nil, err
 	}
@@ -901,14 +982,14 @@ func (c *Client) GetPlayer(playerTag string) (*Player, error) {
 	// Build the URL
 	var sb strings.Builder
 	sb.Grow(100)
-	sb.WriteString(baseURL)
+	sb.WriteString(c.baseURL)
 	sb.WriteString("/players/")
 	sb.WriteString(fmtTag(playerTag))
 	url := sb.String()
 	l.Debug(url)
 
 	// Get the player
-	body, err := getURL(url, nil, c.token)
+	body, err := c.getURL(url, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -942,7 +1023,7 @@ func (c *Client) GetPlayerLabels(qparms ...QParms) ([]Label, *Paging, error) {
 
 	var sb strings.Builder
 	sb.Grow(100)
-	sb.WriteString(baseURL)
+	sb.WriteString(c.baseURL)
 	sb.WriteString("/labels/players/")
 	url := sb.String()
 	l.Debug(url)
@@ -952,7 +1033,7 @@ func (c *Client) GetPlayerLabels(qparms ...QParms) ([]Label, *Paging, error) {
 	if len(qparms) >= 1 {
 		qp = &qparms[0]
 	}
-	body, err := getURL(url, getQueryParms(qp), c.token)
+	body, err := c.getURL(url, getQueryParms(qp))
 	if err != nil {
 		return nil, nil, err
 	}
@@ -991,7 +1072,7 @@ func (c *Client) GetPlayerRankings(locationID string, qparms ...QParms) ([]Playe
 
 	var sb strings.Builder
 	sb.Grow(100)
-	sb.WriteString(baseURL)
+	sb.WriteString(c.baseURL)
 	sb.WriteString("/locations/")
 	sb.WriteString(fmtTag(locationID))
 	sb.WriteString("/rankings/players")
@@ -1003,7 +1084,7 @@ func (c *Client) GetPlayerRankings(locationID string, qparms ...QParms) ([]Playe
 	if len(qparms) >= 1 {
 		qp = &qparms[0]
 	}
-	body, err := getURL(url, getQueryParms(qp), c.token)
+	body, err := c.getURL(url, getQueryParms(qp))
 	if err != nil {
 		return nil, nil, err
 	}
@@ -1044,7 +1125,7 @@ func (c *Client) GetPlayerVersusRankings(locationID string, qparms ...QParms) ([
 
 	var sb strings.Builder
 	sb.Grow(100)
-	sb.WriteString(baseURL)
+	sb.WriteString(c.baseURL)
 	sb.WriteString("/locations/")
 	sb.WriteString(fmtTag(locationID))
 	sb.WriteString("/rankings/clan-versus")
@@ -1056,7 +1137,7 @@ func (c *Client) GetPlayerVersusRankings(locationID string, qparms ...QParms) ([
 	if len(qparms) >= 1 {
 		qp = &qparms[0]
 	}
-	body, err := getURL(url, getQueryParms(qp), c.token)
+	body, err := c.getURL(url, getQueryParms(qp))
 	if err != nil {
 		return nil, nil, err
 	}
@@ -1090,7 +1171,7 @@ func (c *Client) VerifyPlayerToken(playerTag string, token string) (bool, error)
 
 	var sb strings.Builder
 	sb.Grow(100)
-	sb.WriteString(baseURL)
+	sb.WriteString(c.baseURL)
 	sb.WriteString("/players/")
 	sb.WriteString(fmtTag(playerTag))
 	sb.WriteString("/verifytoken")
@@ -1105,7 +1186,7 @@ func (c *Client) VerifyPlayerToken(playerTag string, token string) (bool, error)
 	reqBody := sb.String()
 	l.Debug(reqBody)
 
-	body, err := postURL(url, nil, reqBody, c.token)
+	body, err := c.postURL(url, nil, reqBody)
 	if err != nil {
 		return false, err
 	}
@@ -1139,12 +1220,12 @@ func (c *Client) GetGoldPass() (*GoldPass, error) {
 
 	var sb strings.Builder
 	sb.Grow(100)
-	sb.WriteString(baseURL)
+	sb.WriteString(c.baseURL)
 	sb.WriteString("/goldpass/seasons/current/")
 	url := sb.String()
 	l.Debug(url)
 
-	body, err := getURL(url, nil, c.token)
+	body, err := c.getURL(url, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -1172,7 +1253,7 @@ func (c *Client) ListCapitalRaidSeasons(clanTag string, qparms ...QParms) ([]Cla
 
 	var sb strings.Builder
 	sb.Grow(100)
-	sb.WriteString(baseURL)
+	sb.WriteString(c.baseURL)
 	sb.WriteString("/clans/")
 	sb.WriteString(fmtTag(clanTag))
 	sb.WriteString("/capitalraidseasons")
@@ -1183,7 +1264,7 @@ func (c *Client) ListCapitalRaidSeasons(clanTag string, qparms ...QParms) ([]Cla
 	if len(qparms) >= 1 {
 		qp = &qparms[0]
 	}
-	body, err := getURL(url, getQueryParms(qp), c.token)
+	body, err := c.getURL(url, getQueryParms(qp))
 	if err != nil {
 		return nil, nil, err
 	}
@@ -1215,7 +1296,7 @@ func (c *Client) ListCapitalLeagues(qparms ...QParms) ([]CapitalLeague, *Paging,
 
 	var sb strings.Builder
 	sb.Grow(100)
-	sb.WriteString(baseURL)
+	sb.WriteString(c.baseURL)
 	sb.WriteString("/capitalleagues")
 	url := sb.String()
 	l.Debug(url)
@@ -1224,7 +1305,7 @@ func (c *Client) ListCapitalLeagues(qparms ...QParms) ([]CapitalLeague, *Paging,
 	if len(qparms) >= 1 {
 		qp = &qparms[0]
 	}
-	body, err := getURL(url, getQueryParms(qp), c.token)
+	body, err := c.getURL(url, getQueryParms(qp))
 	if err != nil {
 		return nil, nil, err
 	}
@@ -1256,13 +1337,13 @@ func (c *Client) GetCapitalLeague(leagueID string) (*CapitalLeague, error) {
 
 	var sb strings.Builder
 	sb.Grow(100)
-	sb.WriteString(baseURL)
+	sb.WriteString(c.baseURL)
 	sb.WriteString("/capitalleagues/")
 	sb.WriteString(leagueID)
 	url := sb.String()
 	l.Debug(url)
 
-	body, err := getURL(url, nil, c.token)
+	body, err := c.getURL(url, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -1288,7 +1369,7 @@ func (c *Client) GetCapitalRankings(locationID string, qparms ...QParms) ([]Clan
 
 	var sb strings.Builder
 	sb.Grow(100)
-	sb.WriteString(baseURL)
+	sb.WriteString(c.baseURL)
 	sb.WriteString("/locations/")
 	sb.WriteString(locationID)
 	sb.WriteString("/rankings/capitals")
@@ -1299,7 +1380,7 @@ func (c *Client) GetCapitalRankings(locationID string, qparms ...QParms) ([]Clan
 	if len(qparms) >= 1 {
 		qp = &qparms[0]
 	}
-	body, err := getURL(url, getQueryParms(qp), c.token)
+	body, err := c.getURL(url, getQueryParms(qp))
 	if err != nil {
 		return nil, nil, err
 	}
@@ -1320,28 +1401,46 @@ func (c *Client) GetCapitalRankings(locationID string, qparms ...QParms) ([]Clan
 	return resp.Rankings, &resp.Paging, nil
 }
 
+// warError converts errors returned when retrieving information about a clan's wars into the
+// errors defined by this package. Only a denial of access to a clan's wars is reported as a
+// private war log; other 403 errors, such as those for an invalid token or a token used from
+// another IP address, are returned unchanged.
+func warError(err error) error {
+	switch {
+	case isPrivateWarLog(err):
+		return ErrPrivateWarLog
+	case isHTTPStatus(err, http.StatusNotFound):
+		return ErrClanNotFound
+	default:
+		return err
+	}
+}
+
 // getURL retrieves the requested URL and return the results as a byte array
-func getURL(url string, qparms rest.QParms, token string) ([]byte, error) {
-	headers := rest.Headers{"Authorization": "Bearer " + token}
+func (c *Client) getURL(url string, qparms rest.QParms) ([]byte, error) {
+	headers := rest.Headers{"Authorization": "Bearer " + c.token}
 	for k, v := range defaultGetHeaders {
 		headers[k] = v
 	}
-	client := rest.NewClient(headers, qparms)
+	client := c.newRESTClient(headers, qparms)
 
 	body, err := client.Get(url)
 	if err != nil {
 		return nil, err
 	}
+	if e, ok := client.(rest.Expirer); ok {
+		c.expiry.set(url, e.Expires())
+	}
 	return body, nil
 }
 
 // postURL posts the body to the given URL.
-func postURL(url string, qparms rest.QParms, body string, token string) ([]byte, error) {
-	headers := rest.Headers{"Authorization": "Bearer " + token}
+func (c *Client) postURL(url string, qparms rest.QParms, body string) ([]byte, error) {
+	headers := rest.Headers{"Authorization": "Bearer " + c.token}
 	for k, v := range defaultPostHeaders {
 		headers[k] = v
 	}
-	client := rest.NewClient(headers, qparms)
+	client := c.newRESTClient(headers, qparms)
 
 	respBody, err := client.Post(url, body)
 	if err != nil {
@@ -1350,6 +1449,19 @@ func postURL(url string, qparms rest.QParms, body string, token string) ([]byte,
 	return respBody, nil
 }
 
+// newRESTClient creates a REST client that uses the client's timeout and transport, if the REST
+// client supports them
+func (c *Client) newRESTClient(headers rest.Headers, qparms rest.QParms) rest.Client {
+	client := rest.NewClient(headers, qparms)
+	if ts, ok := client.(rest.TimeoutSetter); ok {
+		ts.SetTimeout(c.timeout)
+	}
+	if ts, ok := client.(rest.TransportSetter); ok {
+		ts.SetTransport(c.transport)
+	}
+	return client
+}
+
 // getQueryParms converts the QParms structure into query parms for the REST request
 func getQueryParms(qp *QParms) rest.QParms {
 	const M = "getQueryParms"
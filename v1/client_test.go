@@ -0,0 +1,152 @@
+package coc
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/rbrabson/coc/pkg/rest"
+)
+
+func TestGetClanWarCurrent(t *testing.T) {
+	tests := []struct {
+		name         string
+		war          string
+		group        string
+		opts         []ClientOption
+		wantState    string
+		wantErr      error
+		wantRequests int
+	}{
+		{
+			name:         "in war",
+			war:          `{"state":"inWar","teamSize":15}`,
+			wantState:    "inWar",
+			wantRequests: 1,
+		},
+		{
+			name:         "ended",
+			war:          `{"state":"warEnded","teamSize":15}`,
+			wantState:    "warEnded",
+			wantRequests: 1,
+		},
+		{
+			name:         "not in war",
+			war:          `{"state":"notInWar"}`,
+			group:        `{"state":"inWar"}`,
+			wantErr:      ErrNotInWar,
+			wantRequests: 1,
+		},
+		{
+			name:         "in clan war league",
+			war:          `{"state":"notInWar"}`,
+			group:        `{"state":"inWar"}`,
+			opts:         []ClientOption{WithCWLDetection()},
+			wantErr:      ErrInCWL,
+			wantRequests: 2,
+		},
+		{
+			name:         "clan war league ended",
+			war:          `{"state":"notInWar"}`,
+			group:        `{"state":"ended"}`,
+			opts:         []ClientOption{WithCWLDetection()},
+			wantErr:      ErrNotInWar,
+			wantRequests: 2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var mu sync.Mutex
+			requests := 0
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				mu.Lock()
+				requests++
+				mu.Unlock()
+				if strings.HasSuffix(r.URL.Path, "/leaguegroup") {
+					w.Write([]byte(tt.group))
+					return
+				}
+				w.Write([]byte(tt.war))
+			}))
+			defer srv.Close()
+
+			client := NewClient("token", append([]ClientOption{WithBaseURL(srv.URL)}, tt.opts...)...)
+			war, err := client.GetClanWarCurrent("#2PP")
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("GetClanWarCurrent() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr == nil && war.State != tt.wantState {
+				t.Errorf("GetClanWarCurrent() state = %q, want %q", war.State, tt.wantState)
+			}
+			if requests != tt.wantRequests {
+				t.Errorf("GetClanWarCurrent() sent %d requests, want %d", requests, tt.wantRequests)
+			}
+		})
+	}
+}
+
+func TestWarErrors(t *testing.T) {
+	tests := []struct {
+		name       string
+		status     int
+		body       string
+		wantErr    error
+		wantReason string
+	}{
+		{
+			name:    "private war log",
+			status:  http.StatusForbidden,
+			body:    `{"reason":"accessDenied","message":"Access denied, clan war log is private."}`,
+			wantErr: ErrPrivateWarLog,
+		},
+		{
+			name:       "invalid IP address",
+			status:     http.StatusForbidden,
+			body:       `{"reason":"accessDenied.invalidIp","message":"Invalid authorization: API key does not allow access from IP 10.0.0.1"}`,
+			wantReason: rest.ReasonInvalidIP,
+		},
+		{
+			name:       "invalid token",
+			status:     http.StatusForbidden,
+			body:       `{"reason":"accessDenied","message":"Invalid authorization"}`,
+			wantReason: rest.ReasonAccessDenied,
+		},
+		{
+			name:    "clan not found",
+			status:  http.StatusNotFound,
+			body:    `{"reason":"notFound"}`,
+			wantErr: ErrClanNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+				w.Write([]byte(tt.body))
+			}))
+			defer srv.Close()
+			client := NewClient("token", WithBaseURL(srv.URL))
+
+			_, errCurrent := client.GetClanWarCurrent("#2PP")
+			_, _, errLog := client.GetClanWarLog("#2PP")
+			for name, err := range map[string]error{"GetClanWarCurrent": errCurrent, "GetClanWarLog": errLog} {
+				if tt.wantErr != nil {
+					if !errors.Is(err, tt.wantErr) {
+						t.Errorf("%s() error = %v, want %v", name, err, tt.wantErr)
+					}
+					continue
+				}
+				if errors.Is(err, ErrPrivateWarLog) {
+					t.Errorf("%s() error = %v, want the HTTP error", name, err)
+				}
+				var httpErr rest.ErrHttp
+				if !errors.As(err, &httpErr) || httpErr.StatusCode != tt.status || httpErr.Reason != tt.wantReason {
+					t.Errorf("%s() error = %v, want status %d with reason %q", name, err, tt.status, tt.wantReason)
+				}
+			}
+		})
+	}
+}
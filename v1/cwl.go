@@ -70,16 +70,17 @@ func (cm ClanWarLeagueMember) String() string {
 	return string(b)
 }
 
-// ClanWarLeagueWar is information about an individual clan war league war
+// ClanWarLeagueWar is information about an individual clan war league war. It contains the same
+// information as a regular clan war, so all the helper methods available on a ClanWar may be
+// used on a clan war league war.
 type ClanWarLeagueWar struct {
-	Clan                 ClanWarTeam `json:"clan"`
-	EndTime              string      `json:"endTime"`
-	Opponent             ClanWarTeam `json:"opponent"`
-	PreparationStartTime string      `json:"preparationStartTime"`
-	StartTime            string      `json:"startTime"`
-	State                string      `json:"state"`
-	TeamSize             int         `json:"teamSize"`
-	WarStartTime         string      `json:"warStartTime"`
+	ClanWar
+}
+
+// NewClanWarLeagueWar converts a clan war into a clan war league war.
+func NewClanWarLeagueWar(cw ClanWar) ClanWarLeagueWar {
+	cw.Type = WarTypeCWL
+	return ClanWarLeagueWar{ClanWar: cw}
 }
 
 // String returns a string representation of a clan war league war
@@ -87,3 +88,10 @@ func (lw ClanWarLeagueWar) String() string {
 	b, _ := json.Marshal(lw)
 	return string(b)
 }
+
+// ToClanWar converts the clan war league war into a clan war.
+func (lw ClanWarLeagueWar) ToClanWar() ClanWar {
+	cw := lw.ClanWar
+	cw.Type = WarTypeCWL
+	return cw
+}
@@ -0,0 +1,200 @@
+package coc
+
+import (
+	"encoding/json"
+	"sort"
+)
+
+// CWLScoreFunc calculates the score used to rank a member's performance in a clan war league
+// season. Members with a higher score are ranked higher.
+type CWLScoreFunc func(p CWLMemberPerformance) float64
+
+// CWLScoreWeights are the weights applied to each statistic when scoring a member's performance
+// in a clan war league season.
+type CWLScoreWeights struct {
+	Stars                float64 `json:"stars"`                // Weight per star earned
+	Destruction          float64 `json:"destruction"`          // Weight per percentage point of destruction
+	AttacksMissed        float64 `json:"attacksMissed"`        // Weight per attack that was missed
+	DefenseStarsConceded float64 `json:"defenseStarsConceded"` // Weight per star conceded on defense
+	THDifference         float64 `json:"thDifference"`         // Weight per town hall level attacked above the member's own, per attack
+}
+
+// DefaultCWLScoreWeights are the weights used when no scoring function is provided.
+var DefaultCWLScoreWeights = CWLScoreWeights{
+	Stars:                1,
+	Destruction:          0.01,
+	AttacksMissed:        -2,
+	DefenseStarsConceded: -0.25,
+	THDifference:         0.5,
+}
+
+// String returns a string representation of clan war league score weights
+func (w CWLScoreWeights) String() string {
+	b, _ := json.Marshal(w)
+	return string(b)
+}
+
+// Score calculates the score for a member's performance using the weights.
+func (w CWLScoreWeights) Score(p CWLMemberPerformance) float64 {
+	return w.Stars*float64(p.Stars) +
+		w.Destruction*float64(p.Destruction) +
+		w.AttacksMissed*float64(p.AttacksMissed) +
+		w.DefenseStarsConceded*float64(p.DefenseStarsConceded) +
+		w.THDifference*p.AverageTHDifference*float64(p.AttacksUsed)
+}
+
+// CWLReportOptions are the options used when creating a clan war league performance report.
+type CWLReportOptions struct {
+	Score   CWLScoreFunc // Function used to score each member; defaults to DefaultCWLScoreWeights
+	Bonuses int          // Number of bonus medals that may be allocated
+}
+
+// CWLMemberPerformance is a member's performance across a clan war league season.
+type CWLMemberPerformance struct {
+	Tag                  string  `json:"tag"`
+	Name                 string  `json:"name"`
+	TownHallLevel        int     `json:"townHallLevel"`
+	WarsParticipated     int     `json:"warsParticipated"`
+	AttacksUsed          int     `json:"attacksUsed"`
+	AttacksMissed        int     `json:"attacksMissed"`
+	Stars                int     `json:"stars"`
+	Destruction          int     `json:"destruction"`
+	AverageStars         float64 `json:"averageStars"`
+	AverageDestruction   float64 `json:"averageDestruction"`
+	AverageMirrorOffset  float64 `json:"averageMirrorOffset"`
+	AverageTHDifference  float64 `json:"averageTHDifference"`
+	Defenses             int     `json:"defenses"`
+	DefenseStarsConceded int     `json:"defenseStarsConceded"`
+	Score                float64 `json:"score"`
+	Rank                 int     `json:"rank"`
+}
+
+// String returns a string representation of a member's clan war league performance
+func (p CWLMemberPerformance) String() string {
+	b, _ := json.Marshal(p)
+	return string(b)
+}
+
+// CWLReport is the performance of each of a clan's members in a clan war league season,
+// ordered by rank, along with the members suggested to receive bonus medals.
+type CWLReport struct {
+	ClanTag         string                 `json:"clanTag"`
+	Season          string                 `json:"season"`
+	Members         []CWLMemberPerformance `json:"members"`
+	BonusRecipients []CWLMemberPerformance `json:"bonusRecipients"`
+}
+
+// String returns a string representation of a clan war league report
+func (r CWLReport) String() string {
+	b, _ := json.Marshal(r)
+	return string(b)
+}
+
+// PerformanceReport creates a report of the performance of each of the clan's members across
+// the clan war league season. The mirror offset for an attack is the defender's map position
+// less the attacker's, so a positive offset is an attack on a lower ranked base. The town hall
+// difference is the defender's town hall level less the attacker's. Both are averaged over the
+// attacks whose defender is found in the war, so an attack on an unknown defender doesn't pull
+// the averages towards zero. Attacks are only counted as missed once a war has ended.
+func (s *CWLSeason) PerformanceReport(opts CWLReportOptions) CWLReport {
+	score := opts.Score
+	if score == nil {
+		score = DefaultCWLScoreWeights.Score
+	}
+
+	type totals struct {
+		perf         CWLMemberPerformance
+		matched      int
+		mirrorOffset int
+		thDifference int
+	}
+	members := make(map[string]*totals)
+	for _, round := range s.Rounds {
+		if round.ClanWar == nil {
+			continue
+		}
+		war := round.ClanWar
+		if war.State != string(WarPhaseInWar) && war.State != string(WarPhaseEnded) {
+			continue
+		}
+
+		opponents := make(map[string]ClanWarMember, len(war.Opponent.Members))
+		for _, opponent := range war.Opponent.Members {
+			opponents[opponent.Tag] = opponent
+		}
+
+		for _, member := range war.Clan.Members {
+			t, ok := members[member.Tag]
+			if !ok {
+				t = &totals{perf: CWLMemberPerformance{Tag: member.Tag}}
+				members[member.Tag] = t
+			}
+			p := &t.perf
+			p.Name = member.Name
+			if member.TownhallLevel > p.TownHallLevel {
+				p.TownHallLevel = member.TownhallLevel
+			}
+			p.WarsParticipated++
+
+			for _, attack := range member.Attacks {
+				p.AttacksUsed++
+				p.Stars += attack.Stars
+				p.Destruction += attack.DestructionPercentage
+				if defender, ok := opponents[attack.DefenderTag]; ok {
+					t.matched++
+					t.mirrorOffset += defender.MapPosition - member.MapPosition
+					t.thDifference += defender.TownhallLevel - member.TownhallLevel
+				}
+			}
+			if war.State == string(WarPhaseEnded) {
+				if missed := war.MaxAttacksPerMember() - len(member.Attacks); missed > 0 {
+					p.AttacksMissed += missed
+				}
+			}
+			if member.OpponentAttacks > 0 {
+				p.Defenses++
+				p.DefenseStarsConceded += member.BestOpponentAttack.Stars
+			}
+		}
+	}
+
+	report := CWLReport{
+		ClanTag: s.ClanTag,
+		Season:  s.Group.Season,
+		Members: make([]CWLMemberPerformance, 0, len(members)),
+	}
+	for _, t := range members {
+		p := t.perf
+		if p.AttacksUsed > 0 {
+			attacks := float64(p.AttacksUsed)
+			p.AverageStars = float64(p.Stars) / attacks
+			p.AverageDestruction = float64(p.Destruction) / attacks
+		}
+		if t.matched > 0 {
+			matched := float64(t.matched)
+			p.AverageMirrorOffset = float64(t.mirrorOffset) / matched
+			p.AverageTHDifference = float64(t.thDifference) / matched
+		}
+		p.Score = score(p)
+		report.Members = append(report.Members, p)
+	}
+	sort.Slice(report.Members, func(i, j int) bool {
+		if report.Members[i].Score != report.Members[j].Score {
+			return report.Members[i].Score > report.Members[j].Score
+		}
+		return report.Members[i].Tag < report.Members[j].Tag
+	})
+	for i := range report.Members {
+		report.Members[i].Rank = i + 1
+	}
+
+	bonuses := opts.Bonuses
+	if bonuses > len(report.Members) {
+		bonuses = len(report.Members)
+	}
+	if bonuses > 0 {
+		report.BonusRecipients = report.Members[:bonuses]
+	}
+
+	return report
+}
@@ -0,0 +1,159 @@
+package coc
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+// attack returns an attack on the defender
+func attack(defenderTag string, stars, destruction int) ClanWarAttack {
+	return ClanWarAttack{DefenderTag: defenderTag, Stars: stars, DestructionPercentage: destruction}
+}
+
+// reportSeason returns a season of two rounds for the clan #2PP. In the second round, #PB
+// attacks a base that isn't part of the war and #PC attacks for the first time.
+func reportSeason() *CWLSeason {
+	opponents := ClanWarTeam{
+		Tag: "#8QU",
+		Members: []ClanWarMember{
+			{Tag: "#OA", TownhallLevel: 15, MapPosition: 1},
+			{Tag: "#OB", TownhallLevel: 14, MapPosition: 2},
+			{Tag: "#OC", TownhallLevel: 13, MapPosition: 3},
+		},
+	}
+	round := func(n int, members ...ClanWarMember) CWLRound {
+		war := NewClanWarLeagueWar(ClanWar{
+			State:            string(WarPhaseEnded),
+			AttacksPerMember: 1,
+			Clan:             ClanWarTeam{Tag: "#2PP", Members: members},
+			Opponent:         opponents,
+		})
+		return CWLRound{Round: n, ClanWar: &war}
+	}
+	return &CWLSeason{
+		ClanTag: "#2PP",
+		Group:   ClanWarLeagueGroup{Season: "2024-03"},
+		Rounds: []CWLRound{
+			round(1,
+				ClanWarMember{Tag: "#PA", Name: "A", TownhallLevel: 15, MapPosition: 1, Attacks: []ClanWarAttack{attack("#OA", 3, 100)}},
+				ClanWarMember{Tag: "#PB", Name: "B", TownhallLevel: 14, MapPosition: 2, Attacks: []ClanWarAttack{attack("#OA", 2, 90)}},
+				ClanWarMember{Tag: "#PC", Name: "C", TownhallLevel: 13, MapPosition: 3},
+			),
+			round(2,
+				ClanWarMember{Tag: "#PA", Name: "A", TownhallLevel: 15, MapPosition: 1, Attacks: []ClanWarAttack{attack("#OB", 2, 80)},
+					OpponentAttacks: 1, BestOpponentAttack: attack("#PA", 2, 70)},
+				ClanWarMember{Tag: "#PB", Name: "B", TownhallLevel: 14, MapPosition: 2, Attacks: []ClanWarAttack{attack("#OX", 3, 100)}},
+				ClanWarMember{Tag: "#PC", Name: "C", TownhallLevel: 13, MapPosition: 3, Attacks: []ClanWarAttack{attack("#OC", 3, 100)}},
+			),
+			{Round: 3},
+		},
+	}
+}
+
+func TestPerformanceReportMembers(t *testing.T) {
+	report := reportSeason().PerformanceReport(CWLReportOptions{})
+	tests := []struct {
+		tag                 string
+		attacksUsed         int
+		attacksMissed       int
+		stars               int
+		averageMirrorOffset float64
+		averageTHDifference float64
+		defenseStars        int
+		score               float64
+	}{
+		{tag: "#PA", attacksUsed: 2, stars: 5, averageMirrorOffset: 0.5, averageTHDifference: -0.5, defenseStars: 2, score: 5.8},
+		{tag: "#PB", attacksUsed: 2, stars: 5, averageMirrorOffset: -1, averageTHDifference: 1, score: 7.9},
+		{tag: "#PC", attacksUsed: 1, attacksMissed: 1, stars: 3, score: 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.tag, func(t *testing.T) {
+			var p *CWLMemberPerformance
+			for i := range report.Members {
+				if report.Members[i].Tag == tt.tag {
+					p = &report.Members[i]
+				}
+			}
+			if p == nil {
+				t.Fatalf("PerformanceReport() has no member %s", tt.tag)
+			}
+			if p.WarsParticipated != 2 || p.AttacksUsed != tt.attacksUsed || p.AttacksMissed != tt.attacksMissed || p.Stars != tt.stars || p.DefenseStarsConceded != tt.defenseStars {
+				t.Errorf("PerformanceReport() member = %v", p)
+			}
+			if p.AverageMirrorOffset != tt.averageMirrorOffset || p.AverageTHDifference != tt.averageTHDifference {
+				t.Errorf("PerformanceReport() averages = %v mirror offset, %v town hall difference, want %v, %v",
+					p.AverageMirrorOffset, p.AverageTHDifference, tt.averageMirrorOffset, tt.averageTHDifference)
+			}
+			if math.Abs(p.Score-tt.score) > 1e-9 {
+				t.Errorf("PerformanceReport() score = %v, want %v", p.Score, tt.score)
+			}
+		})
+	}
+}
+
+func TestPerformanceReportRanking(t *testing.T) {
+	tests := []struct {
+		name      string
+		opts      CWLReportOptions
+		wantOrder []string
+		wantBonus []string
+	}{
+		{
+			name:      "default weights",
+			opts:      CWLReportOptions{Bonuses: 2},
+			wantOrder: []string{"#PB", "#PA", "#PC"},
+			wantBonus: []string{"#PB", "#PA"},
+		},
+		{
+			name:      "ties broken by tag",
+			opts:      CWLReportOptions{Score: CWLScoreWeights{Stars: 1}.Score, Bonuses: 1},
+			wantOrder: []string{"#PA", "#PB", "#PC"},
+			wantBonus: []string{"#PA"},
+		},
+		{
+			name:      "defense weighted",
+			opts:      CWLReportOptions{Score: CWLScoreWeights{Stars: 1, DefenseStarsConceded: -1}.Score, Bonuses: 3},
+			wantOrder: []string{"#PB", "#PA", "#PC"},
+			wantBonus: []string{"#PB", "#PA", "#PC"},
+		},
+		{
+			name: "custom score function",
+			opts: CWLReportOptions{
+				Score:   func(p CWLMemberPerformance) float64 { return -float64(p.TownHallLevel) },
+				Bonuses: 5,
+			},
+			wantOrder: []string{"#PC", "#PB", "#PA"},
+			wantBonus: []string{"#PC", "#PB", "#PA"},
+		},
+		{
+			name:      "no bonuses",
+			wantOrder: []string{"#PB", "#PA", "#PC"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report := reportSeason().PerformanceReport(tt.opts)
+			if report.ClanTag != "#2PP" || report.Season != "2024-03" {
+				t.Errorf("PerformanceReport() clan = %s, season = %s", report.ClanTag, report.Season)
+			}
+			var order []string
+			for i, p := range report.Members {
+				if p.Rank != i+1 {
+					t.Errorf("member %s has rank %d, want %d", p.Tag, p.Rank, i+1)
+				}
+				order = append(order, p.Tag)
+			}
+			if !reflect.DeepEqual(order, tt.wantOrder) {
+				t.Errorf("PerformanceReport() order = %v, want %v", order, tt.wantOrder)
+			}
+			var bonus []string
+			for _, p := range report.BonusRecipients {
+				bonus = append(bonus, p.Tag)
+			}
+			if !reflect.DeepEqual(bonus, tt.wantBonus) {
+				t.Errorf("PerformanceReport() bonus recipients = %v, want %v", bonus, tt.wantBonus)
+			}
+		})
+	}
+}
@@ -0,0 +1,226 @@
+package coc
+
+import (
+	"encoding/json"
+	"sort"
+	"sync"
+)
+
+const (
+	// cwlWarWinStars is the number of bonus stars a clan receives for winning a clan war league war
+	cwlWarWinStars = 10
+	// cwlPlaceholderWarTag is the war tag used for wars that haven't been scheduled yet
+	cwlPlaceholderWarTag = "#0"
+	// maxConcurrentCWLRequests limits the number of clan war league wars retrieved at a time
+	maxConcurrentCWLRequests = 8
+)
+
+// CWLSeason is the complete set of information about a clan's clan war league season,
+// including every war in the league group.
+type CWLSeason struct {
+	ClanTag   string             `json:"clanTag"`
+	Group     ClanWarLeagueGroup `json:"group"`
+	Rounds    []CWLRound         `json:"rounds"`
+	Standings []CWLStanding      `json:"standings"`
+}
+
+// String returns a string representation of a clan war league season
+func (s CWLSeason) String() string {
+	b, _ := json.Marshal(s)
+	return string(b)
+}
+
+// CWLRound is a single round of wars in a clan war league season.
+type CWLRound struct {
+	Round   int                `json:"round"`
+	Wars    []ClanWarLeagueWar `json:"wars"`
+	ClanWar *ClanWarLeagueWar  `json:"clanWar,omitempty"`
+}
+
+// String returns a string representation of a clan war league round
+func (r CWLRound) String() string {
+	b, _ := json.Marshal(r)
+	return string(b)
+}
+
+// CWLStanding is the position of a clan within a clan war league group. Destruction is the total
+// destruction across all of the clan's wars, which is the destruction percentage for each war
+// multiplied by the size of the war.
+type CWLStanding struct {
+	Rank        int     `json:"rank"`
+	Tag         string  `json:"tag"`
+	Name        string  `json:"name"`
+	Stars       int     `json:"stars"`
+	Destruction float64 `json:"destruction"`
+	Wins        int     `json:"wins"`
+	Ties        int     `json:"ties"`
+	Losses      int     `json:"losses"`
+	WarsPlayed  int     `json:"warsPlayed"`
+}
+
+// String returns a string representation of a clan war league standing
+func (s CWLStanding) String() string {
+	b, _ := json.Marshal(s)
+	return string(b)
+}
+
+// GetCWLSeason retrieves the clan's current clan war league group along with every war that
+// has been scheduled in the group. The wars are retrieved concurrently. Placeholder wars for
+// rounds that haven't been scheduled are skipped.
+func (c *Client) GetCWLSeason(clanTag string) (*CWLSeason, error) {
+	group, err := c.GetClanWarLeagueGroup(clanTag)
+	if err != nil {
+		return nil, err
+	}
+
+	// Retrieve all the wars in the group
+	type result struct {
+		round int
+		index int
+		war   *ClanWarLeagueWar
+		err   error
+	}
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, maxConcurrentCWLRequests)
+	results := make(chan result)
+	for i, round := range group.Rounds {
+		for j, warTag := range round.WarTags {
+			if warTag == "" || warTag == cwlPlaceholderWarTag {
+				continue
+			}
+			wg.Add(1)
+			go func(round, index int, warTag string) {
+				defer wg.Done()
+				sem <- struct{}{}
+				defer func() { <-sem }()
+				war, err := c.GetClanWarLeagueWar(warTag)
+				results <- result{round: round, index: index, war: war, err: err}
+			}(i, j, warTag)
+		}
+	}
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	// Place the wars in the round in the same order as the war tags
+	wars := make([][]*ClanWarLeagueWar, len(group.Rounds))
+	for i, round := range group.Rounds {
+		wars[i] = make([]*ClanWarLeagueWar, len(round.WarTags))
+	}
+	for r := range results {
+		if r.err != nil {
+			if err == nil {
+				err = r.err
+			}
+			continue
+		}
+		wars[r.round][r.index] = r.war
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return NewCWLSeason(clanTag, *group, wars), nil
+}
+
+// NewCWLSeason creates a clan war league season for the clan from the league group and the
+// wars in each round of the group. This allows a season to be built from wars that have been
+// previously retrieved.
+func NewCWLSeason(clanTag string, group ClanWarLeagueGroup, wars [][]*ClanWarLeagueWar) *CWLSeason {
+	clanTag = NormalizeTag(clanTag)
+	season := CWLSeason{
+		ClanTag: clanTag,
+		Group:   group,
+		Rounds:  make([]CWLRound, 0, len(wars)),
+	}
+
+	for i, roundWars := range wars {
+		round := CWLRound{Round: i + 1, Wars: make([]ClanWarLeagueWar, 0, len(roundWars))}
+		for _, war := range roundWars {
+			if war == nil {
+				continue
+			}
+			round.Wars = append(round.Wars, *war)
+			if cw, ok := war.ForClan(clanTag); ok {
+				clanWar := NewClanWarLeagueWar(cw)
+				round.ClanWar = &clanWar
+			}
+		}
+		season.Rounds = append(season.Rounds, round)
+	}
+	season.Standings = season.calculateStandings()
+
+	return &season
+}
+
+// calculateStandings returns the standings of the clans in the league group. Clans are ranked
+// by the number of stars, including the bonus stars for each war win, with ties broken by the
+// total destruction.
+func (s *CWLSeason) calculateStandings() []CWLStanding {
+	standings := make(map[string]*CWLStanding, len(s.Group.Clans))
+	getStanding := func(team ClanWarTeam) *CWLStanding {
+		tag := NormalizeTag(team.Tag)
+		standing, ok := standings[tag]
+		if !ok {
+			standing = &CWLStanding{Tag: tag, Name: team.Name}
+			standings[tag] = standing
+		}
+		return standing
+	}
+	for _, clan := range s.Group.Clans {
+		getStanding(ClanWarTeam{Tag: clan.Tag, Name: clan.Name})
+	}
+
+	for _, round := range s.Rounds {
+		for _, war := range round.Wars {
+			if war.State != string(WarPhaseInWar) && war.State != string(WarPhaseEnded) {
+				continue
+			}
+			teams := []ClanWarTeam{war.Clan, war.Opponent}
+			for _, team := range teams {
+				standing := getStanding(team)
+				standing.Stars += team.Stars
+				standing.Destruction += float64(team.DestructionPercentage) * float64(war.TeamSize)
+			}
+
+			// Win, loss and tie results are only final once the war ends
+			if war.State != string(WarPhaseEnded) {
+				continue
+			}
+			winner := war.Winner()
+			for _, team := range teams {
+				standing := getStanding(team)
+				standing.WarsPlayed++
+				switch {
+				case winner == nil:
+					standing.Ties++
+				case winner.Tag == team.Tag:
+					standing.Wins++
+					standing.Stars += cwlWarWinStars
+				default:
+					standing.Losses++
+				}
+			}
+		}
+	}
+
+	list := make([]CWLStanding, 0, len(standings))
+	for _, standing := range standings {
+		list = append(list, *standing)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Stars != list[j].Stars {
+			return list[i].Stars > list[j].Stars
+		}
+		if list[i].Destruction != list[j].Destruction {
+			return list[i].Destruction > list[j].Destruction
+		}
+		return list[i].Tag < list[j].Tag
+	})
+	for i := range list {
+		list[i].Rank = i + 1
+	}
+
+	return list
+}
@@ -0,0 +1,207 @@
+package coc
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// cwlWar returns a clan war league war between two clans
+func cwlWar(state string, clanTag string, clanStars int, clanDestruction float32, opponentTag string, opponentStars int, opponentDestruction float32) *ClanWarLeagueWar {
+	return &ClanWarLeagueWar{ClanWar: ClanWar{
+		State:    state,
+		TeamSize: 15,
+		Clan:     ClanWarTeam{Tag: clanTag, Name: "Clan " + clanTag, Stars: clanStars, DestructionPercentage: clanDestruction},
+		Opponent: ClanWarTeam{Tag: opponentTag, Name: "Clan " + opponentTag, Stars: opponentStars, DestructionPercentage: opponentDestruction},
+	}}
+}
+
+// cwlGroup returns a league group for the clans
+func cwlGroup(tags ...string) ClanWarLeagueGroup {
+	group := ClanWarLeagueGroup{State: "inWar"}
+	for _, tag := range tags {
+		group.Clans = append(group.Clans, ClanWarLeagueClan{Tag: tag, Name: "Clan " + tag})
+	}
+	return group
+}
+
+func TestCalculateStandings(t *testing.T) {
+	ended := string(WarPhaseEnded)
+	inWar := string(WarPhaseInWar)
+	tests := []struct {
+		name string
+		wars [][]*ClanWarLeagueWar
+		want []CWLStanding
+	}{
+		{
+			name: "bonus stars for a win",
+			wars: [][]*ClanWarLeagueWar{{
+				cwlWar(ended, "#2PP", 20, 80, "#8QU", 22, 70),
+			}},
+			want: []CWLStanding{
+				{Rank: 1, Tag: "#8QU", Name: "Clan #8QU", Stars: 32, Destruction: 1050, Wins: 1, WarsPlayed: 1},
+				{Rank: 2, Tag: "#2PP", Name: "Clan #2PP", Stars: 20, Destruction: 1200, Losses: 1, WarsPlayed: 1},
+			},
+		},
+		{
+			name: "tie broken on destruction",
+			wars: [][]*ClanWarLeagueWar{
+				{
+					cwlWar(ended, "#2PP", 25, 90, "#8QU", 20, 80),
+					cwlWar(ended, "#9LL", 25, 95, "#CYY", 20, 70),
+				},
+				{
+					cwlWar(ended, "#2PP", 20, 80, "#9LL", 20, 70),
+					cwlWar(ended, "#8QU", 20, 60, "#CYY", 20, 60),
+				},
+			},
+			want: []CWLStanding{
+				{Rank: 1, Tag: "#2PP", Name: "Clan #2PP", Stars: 65, Destruction: 2550, Wins: 2, WarsPlayed: 2},
+				{Rank: 2, Tag: "#9LL", Name: "Clan #9LL", Stars: 55, Destruction: 2475, Wins: 1, Losses: 1, WarsPlayed: 2},
+				{Rank: 3, Tag: "#8QU", Name: "Clan #8QU", Stars: 40, Destruction: 2100, Losses: 1, Ties: 1, WarsPlayed: 2},
+				{Rank: 4, Tag: "#CYY", Name: "Clan #CYY", Stars: 40, Destruction: 1950, Losses: 1, Ties: 1, WarsPlayed: 2},
+			},
+		},
+		{
+			name: "tied war",
+			wars: [][]*ClanWarLeagueWar{{
+				cwlWar(ended, "#8QU", 20, 80, "#2PP", 20, 80),
+			}},
+			want: []CWLStanding{
+				{Rank: 1, Tag: "#2PP", Name: "Clan #2PP", Stars: 20, Destruction: 1200, Ties: 1, WarsPlayed: 1},
+				{Rank: 2, Tag: "#8QU", Name: "Clan #8QU", Stars: 20, Destruction: 1200, Ties: 1, WarsPlayed: 1},
+			},
+		},
+		{
+			name: "war in progress",
+			wars: [][]*ClanWarLeagueWar{
+				{cwlWar(ended, "#2PP", 30, 90, "#8QU", 10, 40)},
+				{
+					cwlWar(inWar, "#8QU", 12, 50, "#2PP", 3, 20),
+					cwlWar(string(WarPhasePreparation), "#9LL", 0, 0, "#CYY", 0, 0),
+					nil,
+				},
+			},
+			want: []CWLStanding{
+				{Rank: 1, Tag: "#2PP", Name: "Clan #2PP", Stars: 43, Destruction: 1650, Wins: 1, WarsPlayed: 1},
+				{Rank: 2, Tag: "#8QU", Name: "Clan #8QU", Stars: 22, Destruction: 1350, Losses: 1, WarsPlayed: 1},
+				{Rank: 3, Tag: "#9LL", Name: "Clan #9LL"},
+				{Rank: 4, Tag: "#CYY", Name: "Clan #CYY"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			season := NewCWLSeason("#2PP", cwlGroup("#2PP", "#8QU", "#9LL", "#CYY"), tt.wars)
+			got := season.Standings
+			if len(got) > len(tt.want) {
+				got = got[:len(tt.want)]
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Standings = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetCWLSeason(t *testing.T) {
+	// Seven rounds of four wars, with the last round not yet scheduled
+	clans := []string{"#2PP", "#8QU", "#9LL", "#CYY", "#G2R", "#JUV", "#LPQ", "#Q0R"}
+	group := cwlGroup(clans...)
+	wars := make(map[string]*ClanWarLeagueWar)
+	for r := 0; r < 7; r++ {
+		var round ClanWarLeagueRound
+		for i := 0; i < 4; i++ {
+			if r == 6 {
+				round.WarTags = append(round.WarTags, cwlPlaceholderWarTag)
+				continue
+			}
+			tag := fmt.Sprintf("#W%d%d", r, i)
+			round.WarTags = append(round.WarTags, tag)
+			clan, opponent := clans[2*i], clans[2*i+1]
+			wars[tag] = cwlWar(string(WarPhaseEnded), clan, 20+r, 80, opponent, 10, 50)
+		}
+		group.Rounds = append(group.Rounds, round)
+	}
+
+	var mu sync.Mutex
+	requested := make(map[string]int)
+	inFlight, maxInFlight := 0, 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/leaguegroup") {
+			json.NewEncoder(w).Encode(group)
+			return
+		}
+		tag := path.Base(r.URL.Path)
+		mu.Lock()
+		requested[tag]++
+		inFlight++
+		if inFlight > maxInFlight {
+			maxInFlight = inFlight
+		}
+		mu.Unlock()
+		time.Sleep(5 * time.Millisecond)
+		mu.Lock()
+		inFlight--
+		mu.Unlock()
+
+		war, ok := wars[tag]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		json.NewEncoder(w).Encode(war)
+	}))
+	defer srv.Close()
+
+	client := NewClient("token", WithBaseURL(srv.URL))
+	season, err := client.GetCWLSeason("#2PP")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if requested[cwlPlaceholderWarTag] != 0 {
+		t.Errorf("GetCWLSeason() requested the placeholder war %d times", requested[cwlPlaceholderWarTag])
+	}
+	for tag := range wars {
+		if requested[tag] != 1 {
+			t.Errorf("GetCWLSeason() requested war %s %d times, want 1", tag, requested[tag])
+		}
+	}
+	if maxInFlight > maxConcurrentCWLRequests {
+		t.Errorf("GetCWLSeason() sent %d concurrent requests, want at most %d", maxInFlight, maxConcurrentCWLRequests)
+	}
+
+	if len(season.Rounds) != 7 {
+		t.Fatalf("GetCWLSeason() returned %d rounds, want 7", len(season.Rounds))
+	}
+	for r, round := range season.Rounds {
+		if r == 6 {
+			if len(round.Wars) != 0 || round.ClanWar != nil {
+				t.Errorf("round %d has wars %v, want none", round.Round, round.Wars)
+			}
+			continue
+		}
+		if len(round.Wars) != 4 {
+			t.Fatalf("round %d has %d wars, want 4", round.Round, len(round.Wars))
+		}
+		for i, war := range round.Wars {
+			if want := fmt.Sprintf("#W%d%d", r, i); war.WarTag != want {
+				t.Errorf("round %d war %d has tag %s, want %s", round.Round, i, war.WarTag, want)
+			}
+		}
+		if round.ClanWar == nil || round.ClanWar.Clan.Tag != "#2PP" || round.ClanWar.Clan.Stars != 20+r {
+			t.Errorf("round %d clan war = %v", round.Round, round.ClanWar)
+		}
+	}
+	if season.Standings[0].Tag != "#2PP" || season.Standings[0].Wins != 6 {
+		t.Errorf("GetCWLSeason() leader = %v, want #2PP with 6 wins", season.Standings[0])
+	}
+}
@@ -1,9 +1,45 @@
 package coc
 
-import "errors"
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/rbrabson/coc/pkg/rest"
+)
+
+const (
+	// invalidAuthorizationMessage is the start of the message given when the token is invalid
+	invalidAuthorizationMessage = "Invalid authorization"
+)
 
 var (
-	ErrClanNotFound = errors.New("clan not found")
-	ErrNotInWar     = errors.New("clan is not in a war")
-	ErrTagMissing   = errors.New("no tag provided")
+	ErrClanNotFound  = errors.New("clan not found")
+	ErrInCWL         = errors.New("clan is in a clan war league")
+	ErrNotInWar      = errors.New("clan is not in a war")
+	ErrPrivateWarLog = errors.New("clan war log is private")
+	ErrTagMissing    = errors.New("no tag provided")
 )
+
+// isHTTPStatus returns an indication as to whether the error is an HTTP error with the given
+// status code.
+func isHTTPStatus(err error, statusCode int) bool {
+	var httpErr rest.ErrHttp
+	if errors.As(err, &httpErr) {
+		return httpErr.StatusCode == statusCode
+	}
+	return false
+}
+
+// isPrivateWarLog returns an indication as to whether the error is the server denying access
+// to a clan's wars because the clan's war log is private. The server gives the same reason when
+// the token itself is invalid, so that case is excluded using the message.
+func isPrivateWarLog(err error) bool {
+	var httpErr rest.ErrHttp
+	if !errors.As(err, &httpErr) {
+		return false
+	}
+	return httpErr.StatusCode == http.StatusForbidden &&
+		httpErr.Reason == rest.ReasonAccessDenied &&
+		!strings.HasPrefix(httpErr.Message, invalidAuthorizationMessage)
+}
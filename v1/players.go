@@ -17,6 +17,7 @@ type Player struct {
 	DonationsReceived    int                 `json:"donationsReceived"`
 	ExpLevel             int                 `json:"expLevel"`
 	Heroes               []Troop             `json:"heroes"`
+	HeroEquipment        []Troop             `json:"heroEquipment"`
 	Labels               []Label             `json:"labels"`
 	League               League              `json:"league"`
 	LegendStatistics     LegendStatistics    `json:"legendStatistics"`
@@ -60,10 +61,11 @@ func (a PlayerAchievement) String() string {
 
 // Troop represents a troop, hero or spell in Clash of Clans
 type Troop struct {
-	Level    int    `json:"level"`
-	MaxLevel int    `json:"maxLevel"`
-	Name     string `json:"name"`
-	Village  string `json:"village"`
+	Level              int    `json:"level"`
+	MaxLevel           int    `json:"maxLevel"`
+	Name               string `json:"name"`
+	Village            string `json:"village"`
+	SuperTroopIsActive bool   `json:"superTroopIsActive,omitempty"`
 }
 
 // String returns a string representation of a troop
@@ -1,6 +1,20 @@
 package coc
 
-import "net/url"
+import (
+	"net/url"
+	"strings"
+)
+
+// NormalizeTag returns the tag in the form used by Clash of Clans, which is in upper case
+// and starts with a '#' character. This allows tags provided by users to be compared with
+// those returned by the server.
+func NormalizeTag(tag string) string {
+	tag = strings.ToUpper(strings.TrimSpace(tag))
+	if len(tag) == 0 || tag[0] == '#' {
+		return tag
+	}
+	return "#" + tag
+}
 
 // fmtTag formats the tag for use in a URL or in a query parameter.
 func fmtTag(tag string) string {
@@ -1,8 +1,8 @@
 package coc
 
 import (
-	"encoding/json"
-	"strings"
+	"bytes"
+	"strconv"
 	"time"
 )
 
@@ -10,14 +10,36 @@ const (
 	cocTimeLayout = "20060102T150405.000Z07:00"
 )
 
+var (
+	jsonNull = []byte("null")
+)
+
 // Time is a redefinition of the time.Time structure.  This allows for unmarshalling of
 // the time format used by Clash of Clans.
 type Time time.Time
 
-// UnmarshalJSON parses a JSON string into a CocTime structure
+// NewTime converts a time.Time into a Time object
+func NewTime(t time.Time) Time {
+	return Time(t)
+}
+
+// UnmarshalJSON parses a JSON string into a CocTime structure. A null or empty string
+// results in the zero time.
 func (ct *Time) UnmarshalJSON(b []byte) error {
+	if bytes.Equal(b, jsonNull) {
+		*ct = Time{}
+		return nil
+	}
+	s, err := strconv.Unquote(string(b))
+	if err != nil {
+		return err
+	}
+	if s == "" {
+		*ct = Time{}
+		return nil
+	}
+
 	// Parse the time using the layout used by Clash of Clans.
-	s := strings.Trim(string(b), "\"")
 	t, err := time.Parse(cocTimeLayout, s)
 	if err != nil {
 		return err
@@ -28,18 +50,68 @@ func (ct *Time) UnmarshalJSON(b []byte) error {
 	return nil
 }
 
-// MarshalJSON converts a Time object into a JSON string
+// MarshalJSON converts a Time object into a JSON string using the layout used by
+// Clash of Clans. The zero time is converted to null.
 func (ct Time) MarshalJSON() ([]byte, error) {
-	return json.Marshal(time.Time(ct))
+	if ct.IsZero() {
+		return jsonNull, nil
+	}
+	return []byte(strconv.Quote(ct.Time().UTC().Format(cocTimeLayout))), nil
 }
 
-// Format prints the Time object
-func (ct Time) Format(s string) string {
-	return ct.String()
+// Format returns a textual representation of the Time object formatted according to the
+// layout, as defined by time.Time.Format.
+func (ct Time) Format(layout string) string {
+	return ct.Time().Format(layout)
 }
 
 // String converts the Time object to a string
 func (ct Time) String() string {
-	t := time.Time(ct)
-	return t.String()
+	return ct.Time().String()
+}
+
+// Time returns the Time object as a time.Time
+func (ct Time) Time() time.Time {
+	return time.Time(ct)
+}
+
+// IsZero reports whether the time is the zero time, which is the case when the server didn't
+// include the time in the response.
+func (ct Time) IsZero() bool {
+	return ct.Time().IsZero()
+}
+
+// Until returns the duration until the time.
+func (ct Time) Until() time.Duration {
+	return time.Until(ct.Time())
+}
+
+// Since returns the time elapsed since the time.
+func (ct Time) Since() time.Duration {
+	return time.Since(ct.Time())
+}
+
+// Sub returns the duration ct-u.
+func (ct Time) Sub(u Time) time.Duration {
+	return ct.Time().Sub(u.Time())
+}
+
+// Add returns the time ct+d.
+func (ct Time) Add(d time.Duration) Time {
+	return Time(ct.Time().Add(d))
+}
+
+// Before reports whether the time is before u.
+func (ct Time) Before(u Time) bool {
+	return ct.Time().Before(u.Time())
+}
+
+// After reports whether the time is after u.
+func (ct Time) After(u Time) bool {
+	return ct.Time().After(u.Time())
+}
+
+// Equal reports whether the time and u represent the same time instant.
+func (ct Time) Equal(u Time) bool {
+	return ct.Time().Equal(u.Time())
 }
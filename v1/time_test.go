@@ -0,0 +1,99 @@
+package coc
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestTimeUnmarshalJSON(t *testing.T) {
+	tests := []struct {
+		name    string
+		json    string
+		want    time.Time
+		wantErr bool
+	}{
+		{"api format", `"20240301T120000.000Z"`, time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC), false},
+		{"milliseconds", `"20240301T120000.250Z"`, time.Date(2024, 3, 1, 12, 0, 0, 250*int(time.Millisecond), time.UTC), false},
+		{"null", `null`, time.Time{}, false},
+		{"empty string", `""`, time.Time{}, false},
+		{"invalid layout", `"2024-03-01T12:00:00Z"`, time.Time{}, true},
+		{"not a string", `20240301`, time.Time{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ct := NewTime(time.Now())
+			err := json.Unmarshal([]byte(tt.json), &ct)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("UnmarshalJSON() error = %v, want error %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !ct.Time().Equal(tt.want) {
+				t.Errorf("UnmarshalJSON() = %v, want %v", ct, tt.want)
+			}
+		})
+	}
+}
+
+func TestTimeMarshalJSON(t *testing.T) {
+	tests := []struct {
+		name string
+		time Time
+		want string
+	}{
+		{"utc", NewTime(time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)), `"20240301T120000.000Z"`},
+		{"converted to utc", NewTime(time.Date(2024, 3, 1, 7, 0, 0, 500*int(time.Millisecond), time.FixedZone("EST", -5*60*60))), `"20240301T120000.500Z"`},
+		{"zero", Time{}, `null`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, err := json.Marshal(tt.time)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(b) != tt.want {
+				t.Errorf("MarshalJSON() = %s, want %s", b, tt.want)
+			}
+		})
+	}
+}
+
+func TestTimeRoundTrip(t *testing.T) {
+	type war struct {
+		StartTime Time `json:"startTime"`
+		EndTime   Time `json:"endTime"`
+	}
+	in := `{"startTime":"20240301T120000.000Z","endTime":null}`
+	var w war
+	if err := json.Unmarshal([]byte(in), &w); err != nil {
+		t.Fatal(err)
+	}
+	if !w.EndTime.IsZero() {
+		t.Errorf("EndTime = %v, want the zero time", w.EndTime)
+	}
+	b, err := json.Marshal(w)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != in {
+		t.Errorf("Marshal() = %s, want %s", b, in)
+	}
+}
+
+func TestTimeFormat(t *testing.T) {
+	ct := NewTime(time.Date(2024, 3, 1, 12, 4, 5, 0, time.UTC))
+	tests := []struct {
+		layout string
+		want   string
+	}{
+		{time.RFC3339, "2024-03-01T12:04:05Z"},
+		{"2006-01-02", "2024-03-01"},
+		{cocTimeLayout, "20240301T120405.000Z"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.layout, func(t *testing.T) {
+			if got := ct.Format(tt.layout); got != tt.want {
+				t.Errorf("Format(%q) = %q, want %q", tt.layout, got, tt.want)
+			}
+		})
+	}
+}
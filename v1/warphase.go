@@ -0,0 +1,133 @@
+package coc
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// WarPhase is a phase of a clan war.
+type WarPhase string
+
+const (
+	WarPhaseNotInWar    WarPhase = "notInWar"    // The clan isn't in a war
+	WarPhasePreparation WarPhase = "preparation" // Preparation day, before attacks may be made
+	WarPhaseInWar       WarPhase = "inWar"       // Battle day, when attacks may be made
+	WarPhaseEnded       WarPhase = "warEnded"    // The war has ended
+)
+
+// Clock provides the current time. It allows the time used when calculating war phases to be
+// replaced, such as when testing.
+type Clock interface {
+	Now() time.Time
+}
+
+// ClockFunc is an adapter that allows an ordinary function to be used as a Clock.
+type ClockFunc func() time.Time
+
+// Now returns the current time
+func (f ClockFunc) Now() time.Time {
+	return f()
+}
+
+// SystemClock is a Clock that returns the current system time.
+var SystemClock Clock = ClockFunc(time.Now)
+
+// WarPhaseTransition is the time at which a clan war moves into a new phase.
+type WarPhaseTransition struct {
+	Phase WarPhase `json:"phase"`
+	Time  Time     `json:"time"`
+}
+
+// String returns a string representation of a war phase transition
+func (t WarPhaseTransition) String() string {
+	b, _ := json.Marshal(t)
+	return string(b)
+}
+
+// Phase returns the phase of the war at the time provided by the clock. If the clock is nil,
+// the system clock is used. The phase is calculated from the war's start and end times, so it
+// remains accurate for an older snapshot of the war. If the war doesn't include the times, the
+// state returned by the server is used instead.
+func (cw ClanWar) Phase(clock Clock) WarPhase {
+	if cw.StartTime.IsZero() || cw.EndTime.IsZero() {
+		switch WarPhase(cw.State) {
+		case WarPhasePreparation, WarPhaseInWar, WarPhaseEnded:
+			return WarPhase(cw.State)
+		}
+		return WarPhaseNotInWar
+	}
+
+	now := getClock(clock).Now()
+	switch {
+	case now.Before(cw.StartTime.Time()):
+		return WarPhasePreparation
+	case now.Before(cw.EndTime.Time()):
+		return WarPhaseInWar
+	default:
+		return WarPhaseEnded
+	}
+}
+
+// PhaseRemaining returns the time remaining in the current phase of the war. Zero is returned
+// if the war has ended or the clan isn't in a war.
+func (cw ClanWar) PhaseRemaining(clock Clock) time.Duration {
+	switch cw.Phase(clock) {
+	case WarPhasePreparation:
+		return cw.TimeUntilStart(clock)
+	case WarPhaseInWar:
+		return cw.TimeUntilEnd(clock)
+	default:
+		return 0
+	}
+}
+
+// TimeUntilStart returns the time remaining until attacks may be made in the war. Zero is
+// returned if battle day has already started.
+func (cw ClanWar) TimeUntilStart(clock Clock) time.Duration {
+	return timeUntil(cw.StartTime, clock)
+}
+
+// TimeUntilEnd returns the time remaining until the war ends. Zero is returned if the war has
+// already ended.
+func (cw ClanWar) TimeUntilEnd(clock Clock) time.Duration {
+	return timeUntil(cw.EndTime, clock)
+}
+
+// Schedule returns the upcoming phase transitions for the war, in the order they will occur.
+// Transitions that have already occurred at the time provided by the clock aren't included.
+func (cw ClanWar) Schedule(clock Clock) []WarPhaseTransition {
+	now := getClock(clock).Now()
+	transitions := []WarPhaseTransition{
+		{Phase: WarPhasePreparation, Time: cw.PreparationStartTime},
+		{Phase: WarPhaseInWar, Time: cw.StartTime},
+		{Phase: WarPhaseEnded, Time: cw.EndTime},
+	}
+
+	schedule := make([]WarPhaseTransition, 0, len(transitions))
+	for _, t := range transitions {
+		if !t.Time.IsZero() && t.Time.Time().After(now) {
+			schedule = append(schedule, t)
+		}
+	}
+	return schedule
+}
+
+// timeUntil returns the time remaining until t, or zero if t has passed or isn't set.
+func timeUntil(t Time, clock Clock) time.Duration {
+	if t.IsZero() {
+		return 0
+	}
+	d := t.Time().Sub(getClock(clock).Now())
+	if d < 0 {
+		return 0
+	}
+	return d
+}
+
+// getClock returns the clock, or the system clock if no clock is provided.
+func getClock(clock Clock) Clock {
+	if clock == nil {
+		return SystemClock
+	}
+	return clock
+}
@@ -0,0 +1,120 @@
+package coc
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+// fixedClock returns a clock that always reports the given time
+func fixedClock(t time.Time) Clock {
+	return ClockFunc(func() time.Time { return t })
+}
+
+func TestPhase(t *testing.T) {
+	prepStart := time.Date(2024, 3, 1, 7, 0, 0, 0, time.UTC)
+	start := prepStart.Add(23 * time.Hour)
+	end := start.Add(24 * time.Hour)
+	war := ClanWar{
+		State:                string(WarPhaseInWar),
+		PreparationStartTime: NewTime(prepStart),
+		StartTime:            NewTime(start),
+		EndTime:              NewTime(end),
+	}
+
+	tests := []struct {
+		name          string
+		war           ClanWar
+		now           time.Time
+		want          WarPhase
+		wantRemaining time.Duration
+	}{
+		{"preparation", war, prepStart.Add(time.Hour), WarPhasePreparation, 22 * time.Hour},
+		{"battle day starts", war, start, WarPhaseInWar, 24 * time.Hour},
+		{"battle day", war, start.Add(90 * time.Minute), WarPhaseInWar, 22*time.Hour + 30*time.Minute},
+		{"ended", war, end, WarPhaseEnded, 0},
+		{"stale state", war, end.Add(48 * time.Hour), WarPhaseEnded, 0},
+		{"state only", ClanWar{State: string(WarPhasePreparation)}, start, WarPhasePreparation, 0},
+		{"not in war", ClanWar{State: string(WarPhaseNotInWar)}, start, WarPhaseNotInWar, 0},
+		{"unknown state", ClanWar{}, start, WarPhaseNotInWar, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clock := fixedClock(tt.now)
+			if got := tt.war.Phase(clock); got != tt.want {
+				t.Errorf("Phase() = %q, want %q", got, tt.want)
+			}
+			if got := tt.war.PhaseRemaining(clock); got != tt.wantRemaining {
+				t.Errorf("PhaseRemaining() = %v, want %v", got, tt.wantRemaining)
+			}
+		})
+	}
+}
+
+func TestSchedule(t *testing.T) {
+	prepStart := time.Date(2024, 3, 1, 7, 0, 0, 0, time.UTC)
+	start := prepStart.Add(23 * time.Hour)
+	end := start.Add(24 * time.Hour)
+	war := ClanWar{
+		PreparationStartTime: NewTime(prepStart),
+		StartTime:            NewTime(start),
+		EndTime:              NewTime(end),
+	}
+
+	tests := []struct {
+		name string
+		war  ClanWar
+		now  time.Time
+		want []WarPhaseTransition
+	}{
+		{
+			name: "before preparation",
+			war:  war,
+			now:  prepStart.Add(-time.Minute),
+			want: []WarPhaseTransition{
+				{Phase: WarPhasePreparation, Time: NewTime(prepStart)},
+				{Phase: WarPhaseInWar, Time: NewTime(start)},
+				{Phase: WarPhaseEnded, Time: NewTime(end)},
+			},
+		},
+		{
+			name: "preparation",
+			war:  war,
+			now:  prepStart,
+			want: []WarPhaseTransition{
+				{Phase: WarPhaseInWar, Time: NewTime(start)},
+				{Phase: WarPhaseEnded, Time: NewTime(end)},
+			},
+		},
+		{
+			name: "battle day",
+			war:  war,
+			now:  start.Add(time.Hour),
+			want: []WarPhaseTransition{
+				{Phase: WarPhaseEnded, Time: NewTime(end)},
+			},
+		},
+		{
+			name: "ended",
+			war:  war,
+			now:  end,
+			want: []WarPhaseTransition{},
+		},
+		{
+			name: "missing times",
+			war:  ClanWar{EndTime: NewTime(end)},
+			now:  start,
+			want: []WarPhaseTransition{
+				{Phase: WarPhaseEnded, Time: NewTime(end)},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.war.Schedule(fixedClock(tt.now))
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Schedule() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}